@@ -0,0 +1,172 @@
+package transform
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CorpusReport summarizes how a corpus would be chunked, for capacity
+// and cost planning before running ChunkActivity.
+type CorpusReport struct {
+	// Documents is the number of documents analyzed.
+	Documents int
+
+	// Tokens is the distribution of document sizes in chunking tokens
+	// (see ChunkOptions.Separator and ChunkOptions.CJKTokens), leaving
+	// out documents over ChunkOptions.MaxContentBytes.
+	Tokens TokenDistribution
+
+	// Chunked is the number of documents that would be split.
+	Chunked int
+
+	// Chunks is the projected number of output documents, counting an
+	// unsplit document as one.
+	Chunks int
+
+	// ChunksPerDocument is Chunks divided by Documents.
+	ChunksPerDocument float64
+
+	// Warnings describes options that would make the real run fail or
+	// behave differently than requested.
+	Warnings []string
+}
+
+// TokenDistribution describes the token counts of a set of documents.
+type TokenDistribution struct {
+	Total  int
+	Min    int
+	Max    int
+	Mean   float64
+	Median int
+	P90    int
+}
+
+// AnalyzeCorpus reports the document sizes and projected chunk counts of
+// docs under opts without keeping any chunks. The projection uses the
+// same boundaries as ChunkActivity (see EstimateChunkCount), so its
+// counts match a real run.
+func AnalyzeCorpus(docs []Document, opts ChunkOptions) CorpusReport {
+	report := CorpusReport{Documents: len(docs)}
+
+	resolved, warnings, err := resolveChunkOptions(opts)
+	report.Warnings = warnings
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("invalid options: %v; chunks not projected", err))
+		resolved = opts
+	}
+
+	counts := make([]int, 0, len(docs))
+	for _, doc := range docs {
+		// Oversized content is not tokenized, as in ChunkActivity.
+		if resolved.MaxContentBytes <= 0 || len(doc.Content) <= resolved.MaxContentBytes {
+			spans, _ := chunkTokenLayout(normalizeLineEndings(doc.Content), resolved)
+			counts = append(counts, len(spans))
+		}
+		if err != nil {
+			continue
+		}
+
+		n := estimateChunkCount(doc, resolved)
+		if n == 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"document %s content exceeds the limit of %d bytes", doc.ID, resolved.MaxContentBytes))
+		}
+		if n > 1 {
+			report.Chunked++
+			if resolved.EmitParent {
+				report.Chunks++
+			}
+		}
+		if resolved.MaxChunksPerDoc > 0 && n > resolved.MaxChunksPerDoc && resolved.OnMaxChunks != MaxChunksMergeTail {
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"document %s produces %d chunks, exceeding the cap of %d", doc.ID, n, resolved.MaxChunksPerDoc))
+		}
+		report.Chunks += n
+	}
+
+	report.Tokens = tokenDistribution(counts)
+	if report.Documents > 0 {
+		report.ChunksPerDocument = float64(report.Chunks) / float64(report.Documents)
+	}
+	return report
+}
+
+// EstimateChunkCount returns the number of documents ChunkActivity would
+// produce for doc under opts, without building the chunks where the
+// strategy allows it. Invalid options count doc as a single document, and
+// a document over MaxContentBytes as none.
+func EstimateChunkCount(doc Document, opts ChunkOptions) int {
+	resolved, _, err := resolveChunkOptions(opts)
+	if err != nil {
+		return 1
+	}
+	n := estimateChunkCount(doc, resolved)
+	if resolved.EmitParent && n > 1 {
+		n++
+	}
+	return n
+}
+
+// estimateChunkCount returns the number of chunks doc is split into
+// under resolved opts, before any cap error and without an emitted
+// parent.
+func estimateChunkCount(doc Document, opts ChunkOptions) int {
+	if !opts.Selector.Matches(doc) {
+		return 1
+	}
+	if doc.IsChunk() && opts.OnAlreadyChunked != AlreadyChunkedRechunk {
+		return 1
+	}
+	if opts.AllowMetadataOverrides {
+		opts, _ = withMetadataOverrides(doc, opts)
+	}
+	if opts.SourceField != "" {
+		var err error
+		if doc, err = withSourceField(doc, opts.SourceField); err != nil {
+			return 1
+		}
+	}
+	if opts.MaxContentBytes > 0 && len(doc.Content) > opts.MaxContentBytes {
+		return 0
+	}
+	doc.Content = normalizeLineEndings(doc.Content)
+
+	var n int
+	switch {
+	case doc.Content == "" || skipChunking(doc, opts.SkipMetadataKey):
+		return 1
+	case (opts.Strategy == StrategyTokens || opts.Strategy == "") && !opts.PreserveLists:
+		spans, _ := chunkTokenLayout(doc.Content, opts)
+		n = len(chunkBoundaries(len(spans), opts))
+	default:
+		var stats ChunkStats
+		n = len(splitDocument(doc, opts, &stats))
+	}
+
+	n = max(n, 1)
+	if opts.MaxChunksPerDoc > 0 && opts.OnMaxChunks == MaxChunksMergeTail {
+		n = min(n, opts.MaxChunksPerDoc)
+	}
+	return n
+}
+
+// tokenDistribution summarizes counts.
+func tokenDistribution(counts []int) TokenDistribution {
+	if len(counts) == 0 {
+		return TokenDistribution{}
+	}
+
+	sorted := append([]int(nil), counts...)
+	sort.Ints(sorted)
+
+	var d TokenDistribution
+	for _, n := range sorted {
+		d.Total += n
+	}
+	d.Min = sorted[0]
+	d.Max = sorted[len(sorted)-1]
+	d.Mean = float64(d.Total) / float64(len(sorted))
+	d.Median = sorted[len(sorted)/2]
+	d.P90 = sorted[(len(sorted)*9)/10]
+	return d
+}
@@ -0,0 +1,111 @@
+package transform
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeCorpusMatchesChunk(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "a", Content: strings.Repeat("word ", 25)},
+		{ID: "b", Content: "short doc"},
+		{ID: "c", Content: strings.Repeat("alpha beta. ", 30)},
+		{ID: "d", Content: ""},
+	}
+
+	tests := []struct {
+		name string
+		opts ChunkOptions
+	}{
+		{"tokens", ChunkOptions{MaxTokens: 10, Overlap: 2}},
+		{"balanced", ChunkOptions{Strategy: StrategyBalanced, MaxTokens: 10}},
+		{"merge tail", ChunkOptions{MaxTokens: 5, MaxChunksPerDoc: 3, OnMaxChunks: MaxChunksMergeTail}},
+		{"chars", ChunkOptions{Strategy: StrategyChars, MaxTokens: 40}},
+		{"emit parent", ChunkOptions{MaxTokens: 10, EmitParent: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := ChunkActivity(context.Background(), ChunkInput{Documents: docs, Options: tt.opts})
+			if err != nil {
+				t.Fatalf("ChunkActivity: %v", err)
+			}
+
+			report := AnalyzeCorpus(docs, tt.opts)
+			if report.Chunks != out.Count {
+				t.Errorf("Chunks = %d, ChunkActivity produced %d", report.Chunks, out.Count)
+			}
+			if report.Documents != len(docs) {
+				t.Errorf("Documents = %d, want %d", report.Documents, len(docs))
+			}
+			for _, doc := range docs {
+				want := 0
+				for _, chunk := range out.Documents {
+					if chunk.ID == doc.ID || chunk.ParentID == doc.ID {
+						want++
+					}
+				}
+				if got := EstimateChunkCount(doc, tt.opts); got != want {
+					t.Errorf("EstimateChunkCount(%s) = %d, want %d", doc.ID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeCorpusReport(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "a", Content: strings.Repeat("w ", 30)},
+		{ID: "b", Content: strings.Repeat("w ", 10)},
+		{ID: "c", Content: strings.Repeat("w ", 5)},
+		{ID: "d", Content: strings.Repeat("w ", 15)},
+	}
+
+	report := AnalyzeCorpus(docs, ChunkOptions{MaxTokens: 10})
+
+	wantTokens := TokenDistribution{Total: 60, Min: 5, Max: 30, Mean: 15, Median: 15, P90: 30}
+	if report.Tokens != wantTokens {
+		t.Errorf("Tokens = %+v, want %+v", report.Tokens, wantTokens)
+	}
+	if report.Chunked != 2 {
+		t.Errorf("Chunked = %d, want 2", report.Chunked)
+	}
+	if report.Chunks != 7 {
+		t.Errorf("Chunks = %d, want 7", report.Chunks)
+	}
+	if report.ChunksPerDocument != 1.75 {
+		t.Errorf("ChunksPerDocument = %v, want 1.75", report.ChunksPerDocument)
+	}
+}
+
+func TestAnalyzeCorpusWarnings(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "a", Content: strings.Repeat("w ", 30)}}
+
+	report := AnalyzeCorpus(docs, ChunkOptions{MaxTokens: 10, MaxChunksPerDoc: 2})
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "exceeding the cap") {
+		t.Errorf("Warnings = %q, want a cap warning", report.Warnings)
+	}
+
+	report = AnalyzeCorpus(docs, ChunkOptions{MaxTokens: 10, Overlap: -1})
+	if report.Chunks != 0 || len(report.Warnings) == 0 {
+		t.Errorf("report = %+v, want no projection and a warning", report)
+	}
+}
+
+func TestAnalyzeCorpusEmpty(t *testing.T) {
+	t.Parallel()
+
+	report := AnalyzeCorpus(nil, ChunkOptions{})
+	if report.Documents != 0 || report.Chunks != 0 || report.ChunksPerDocument != 0 {
+		t.Errorf("report = %+v, want zero", report)
+	}
+}
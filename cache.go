@@ -0,0 +1,97 @@
+package transform
+
+import (
+	"container/list"
+	"sync"
+)
+
+// documentCache is an LRU cache of loaded documents keyed by
+// DataRef.Checksum. Refs are content-addressed, so a cached entry never
+// goes stale.
+var documentCache = struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}{
+	order:   list.New(),
+	entries: make(map[string]*list.Element),
+}
+
+// documentCacheEntry is an element of documentCache.order.
+type documentCacheEntry struct {
+	checksum string
+	docs     []Document
+}
+
+// SetDocumentCacheSize enables an in-process LRU cache of up to size refs
+// for LoadDocuments, e.g. to speed up interactive sessions that reload
+// the same refs. Zero, the default, disables the cache and drops its
+// entries; shrinking it evicts the least recently used refs.
+func SetDocumentCacheSize(size int) {
+	documentCache.mu.Lock()
+	defer documentCache.mu.Unlock()
+	documentCache.size = max(size, 0)
+	evictDocuments()
+}
+
+// ClearDocumentCache drops every cached ref, keeping the cache size.
+func ClearDocumentCache() {
+	documentCache.mu.Lock()
+	defer documentCache.mu.Unlock()
+	documentCache.order.Init()
+	clear(documentCache.entries)
+}
+
+// cachedDocuments returns a copy of the documents cached for checksum.
+func cachedDocuments(checksum string) ([]Document, bool) {
+	documentCache.mu.Lock()
+	defer documentCache.mu.Unlock()
+	elem, ok := documentCache.entries[checksum]
+	if !ok {
+		return nil, false
+	}
+	documentCache.order.MoveToFront(elem)
+	return cloneDocuments(elem.Value.(*documentCacheEntry).docs), true
+}
+
+// cacheDocuments stores a copy of docs under checksum when the cache is
+// enabled.
+func cacheDocuments(checksum string, docs []Document) {
+	documentCache.mu.Lock()
+	defer documentCache.mu.Unlock()
+	if documentCache.size == 0 || checksum == "" {
+		return
+	}
+
+	if elem, ok := documentCache.entries[checksum]; ok {
+		documentCache.order.MoveToFront(elem)
+		return
+	}
+	entry := &documentCacheEntry{checksum: checksum, docs: cloneDocuments(docs)}
+	documentCache.entries[checksum] = documentCache.order.PushFront(entry)
+	evictDocuments()
+}
+
+// evictDocuments drops the least recently used refs beyond the cache size.
+// The caller must hold documentCache.mu.
+func evictDocuments() {
+	for documentCache.order.Len() > documentCache.size {
+		elem := documentCache.order.Back()
+		documentCache.order.Remove(elem)
+		delete(documentCache.entries, elem.Value.(*documentCacheEntry).checksum)
+	}
+}
+
+// cloneDocuments copies docs with their own metadata and attributes,
+// including nested attribute maps and slices, so callers cannot modify
+// cached documents.
+func cloneDocuments(docs []Document) []Document {
+	cp := make([]Document, len(docs))
+	for i, doc := range docs {
+		doc.Metadata = copyMetadata(doc.Metadata)
+		doc.Attributes = doc.Attributes.deepClone()
+		cp[i] = doc
+	}
+	return cp
+}
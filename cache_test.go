@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLoadDocumentsCache(t *testing.T) {
+	SetDocumentCacheSize(1)
+	t.Cleanup(func() { SetDocumentCacheSize(0) })
+
+	ctx := context.Background()
+	ref, err := StoreDocuments(ctx, []Document{{
+		ID:         "a",
+		Content:    "one",
+		Metadata:   map[string]string{"k": "v"},
+		Attributes: Attributes{"tags": []any{"x"}, "owner": map[string]any{"name": "ann"}},
+	}})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	docs, err := LoadDocuments(ctx, ref)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	docs[0].Metadata["k"] = "changed"
+	docs[0].Attributes["tags"].([]any)[0] = "changed"
+	docs[0].Attributes["owner"].(map[string]any)["name"] = "changed"
+
+	// A hit is served by checksum without reading the storage key.
+	moved := ref
+	moved.StorageKey = "missing"
+	cached, err := LoadDocuments(ctx, moved)
+	if err != nil {
+		t.Fatalf("expected cache hit, got %v", err)
+	}
+	if cached[0].Metadata["k"] != "v" {
+		t.Errorf("cached metadata = %v, want caller changes not cached", cached[0].Metadata)
+	}
+	if tags := cached[0].Attributes["tags"].([]any); tags[0] != "x" {
+		t.Errorf("cached tags = %v, want caller changes not cached", tags)
+	}
+	if owner := cached[0].Attributes["owner"].(map[string]any); owner["name"] != "ann" {
+		t.Errorf("cached owner = %v, want caller changes not cached", owner)
+	}
+
+	other, err := StoreDocuments(ctx, []Document{{ID: "b", Content: "two"}})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if _, err := LoadDocuments(ctx, other); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := LoadDocuments(ctx, moved); err == nil {
+		t.Error("expected least recently used ref to be evicted")
+	}
+
+	ClearDocumentCache()
+	other.StorageKey = "missing"
+	if _, err := LoadDocuments(ctx, other); err == nil {
+		t.Error("expected miss after ClearDocumentCache")
+	}
+}
@@ -2,7 +2,12 @@ package transform
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/resolute-sh/resolute/core"
@@ -10,6 +15,10 @@ import (
 
 // ChunkOptions configures document chunking behavior.
 type ChunkOptions struct {
+	// Strategy selects how documents are split into chunks.
+	// Default: StrategyTokens
+	Strategy ChunkStrategy
+
 	// MaxTokens is the maximum number of tokens per chunk.
 	// Tokens are approximated as words (space-separated).
 	// Default: 512
@@ -20,12 +29,345 @@ type ChunkOptions struct {
 	// Default: 50
 	Overlap int
 
+	// OverlapPlacement controls where chunk windows are anchored and
+	// therefore which tokens are duplicated into neighboring chunks.
+	// Default: OverlapLeading
+	OverlapPlacement OverlapPlacement
+
 	// Separator is the preferred split point within text.
-	// Chunking will prefer to split at these boundaries.
+	// Chunking will prefer to split at these boundaries. "\r\n" and
+	// "\r" line endings in content are converted to "\n" before
+	// splitting, so "\n" separators match content from any platform.
+	// Documents returned whole, such as skipped or short ones, keep
+	// their original line endings.
+	// A run of separators, such as "\n\n\n\n" with "\n\n", leaves
+	// empty segments between them; they hold no tokens and collapse into
+	// a single paragraph break, so no strategy emits an empty chunk for
+	// them (see PreserveBlankSegments).
 	// Default: "\n\n"
 	Separator string
+
+	// ParagraphMarkers are secondary separators tried in order when
+	// Separator splits the content into a single segment, so sources
+	// that use a different paragraph convention keep their structure.
+	// A nil value uses DefaultParagraphMarkers(); an empty slice disables
+	// the fallback. An empty marker is a last resort: content that
+	// contains none of the other markers and no whitespace, such as CJK
+	// text or minified code, is split into single characters.
+	ParagraphMarkers []string
+
+	// RequireSeparator makes chunking fail when a document that has to be
+	// split does not contain Separator, instead of silently treating the
+	// whole content as a single paragraph.
+	RequireSeparator bool
+
+	// SkipMetadataKey names a metadata key that marks documents as atomic.
+	// Documents whose value for the key parses as true (see
+	// strconv.ParseBool) are returned unchanged regardless of length.
+	// Default: "" (disabled)
+	SkipMetadataKey string
+
+	// IDAllocator names a registered IDAllocator (see RegisterIDAllocator)
+	// that assigns each input document a new ID before chunking, so chunk
+	// IDs and ParentIDs derive from the allocated ID.
+	// Default: "" (keep document IDs)
+	IDAllocator string
+
+	// PreserveLists treats bulleted and numbered list items as units that
+	// StrategyTokens does not split, keeping related items together up to
+	// MaxTokens. Chunks made up mostly of list items are marked with
+	// Metadata["content_type"]="list". Combine with SliceContent to keep
+	// the item line breaks in chunk content.
+	PreserveLists bool
+
+	// SliceContent builds each chunk's Content as a substring of the
+	// parent's Content instead of joining its tokens with single spaces.
+	// Chunks share the parent's memory and keep its original whitespace
+	// and separators, which avoids a string allocation per chunk.
+	SliceContent bool
+
+	// TrimChunks trims leading and trailing whitespace from the content
+	// of every chunk produced by splitting a document. Documents that are
+	// not split are returned unchanged. A nil value trims; set it to false
+	// to keep chunk content exactly as the strategy produced it.
+	// Default: true
+	TrimChunks *bool
+
+	// CacheTokenCounts stores each output document's token count in
+	// Metadata (see WithTokenCount) so later transforms can reuse it.
+	CacheTokenCounts bool
+
+	// WindowSize is the number of sentences on each side of a sentence
+	// stored as its context window by StrategySentenceWindow.
+	// Default: 3
+	WindowSize int
+
+	// SentenceSplitter names a registered SentenceSplitter (see
+	// RegisterSentenceSplitter) used by StrategySentenceWindow.
+	// Default: SentenceSplitterEnglish
+	SentenceSplitter string
+
+	// OnAlreadyChunked controls how documents that are already chunks
+	// (see Document.IsChunk) are handled.
+	// Default: AlreadyChunkedPassthrough
+	OnAlreadyChunked AlreadyChunkedPolicy
+
+	// MaxChunksPerDoc caps the number of chunks produced from a single
+	// document, guarding against accidental fan-out. OnMaxChunks decides
+	// what happens when the cap is exceeded.
+	// Default: 0 (unlimited)
+	MaxChunksPerDoc int
+
+	// OnMaxChunks controls how documents exceeding MaxChunksPerDoc are
+	// handled.
+	// Default: MaxChunksError
+	OnMaxChunks MaxChunksPolicy
+
+	// HeadingPrefix repeats a section's heading line at the start of
+	// every chunk of that section under StrategyMarkdown, so chunks after
+	// the first keep their heading as context. The prefix is not counted
+	// against MaxTokens: windows and their Overlap are computed on the
+	// section's body tokens only and the prefix is added afterwards, so
+	// the overlap a model sees between two prefixed chunks is Overlap
+	// plus the heading. ChunkStats reports the added tokens separately
+	// in PrefixTokens.
+	HeadingPrefix bool
+
+	// SourceField chunks the named metadata field instead of Content.
+	// The field's text becomes the chunks' Content and the field itself
+	// is not copied into their metadata. Documents without the field are
+	// rejected.
+	// Default: "" (chunk Content)
+	SourceField string
+
+	// RecordTokenRanges stores each chunk's [start, end) token range
+	// within its parent in Metadata["token_start"] and
+	// Metadata["token_end"], so DedupeOverlap can stitch retrieved chunks
+	// back together. StrategyChars chunks record their character range
+	// in Metadata["char_start"] and Metadata["char_end"] instead.
+	// StrategySentenceWindow chunks do not overlap and are not annotated.
+	RecordTokenRanges bool
+
+	// KeepSeparator controls whether the text of Separator is kept as
+	// tokens when splitting, e.g. so a timestamp or speaker label used as
+	// the separator stays with its entry. StrategySentenceWindow ignores
+	// it.
+	// Default: SeparatorDrop
+	KeepSeparator SeparatorMode
+
+	// AtomicCodeBlocks emits each fenced code block as its own chunk
+	// under StrategyMarkdown, with Metadata["content_type"] set to "code"
+	// and Metadata["language"] to the fence's info string. Blocks longer
+	// than MaxTokens are split at line boundaries and each piece is
+	// re-fenced.
+	AtomicCodeBlocks bool
+
+	// SnapToWords moves StrategyChars chunk boundaries that fall inside a
+	// word to whitespace, so chunks neither start nor end with a partial
+	// word. A word longer than a whole chunk is still cut. A nil value
+	// snaps.
+	// Default: true
+	SnapToWords *bool
+
+	// URLFragmentTemplate deep-links each chunk's URL to its passage by
+	// setting the URL fragment from the template, replacing any fragment
+	// of the parent URL. "{index}" expands to the ChunkIndex and
+	// "{offset}" to the byte offset in the parent Content where the
+	// chunk's text starts, e.g. "chunk-{index}" or "char={offset}".
+	// Documents without a URL and unsplit documents are unchanged.
+	// Default: "" (chunks share the parent URL)
+	URLFragmentTemplate string
+
+	// DryRun previews chunking: the output reports the Count, Warnings
+	// and Stats the options would produce but carries no Documents, so
+	// options can be validated on a large batch before embedding it.
+	DryRun bool
+
+	// AllowMetadataOverrides lets a document override MaxTokens, Overlap
+	// and Strategy for itself with Metadata["max_tokens"],
+	// Metadata["overlap"] and Metadata["strategy"], so upstream
+	// providers can express per-document chunking intent. Invalid
+	// overrides add a warning and the document uses these options.
+	AllowMetadataOverrides bool
+
+	// Selector limits chunking to the documents it matches; the others
+	// are passed through unsplit.
+	Selector Selector
+
+	// CJKTokens counts each Chinese, Japanese or Korean character as a
+	// token, a common approximation for scripts written without spaces,
+	// so MaxTokens bounds CJK and mixed content instead of treating a
+	// whole run of CJK text as one word. Chunks keep CJK runs unspaced.
+	// StrategySentenceWindow and StrategyChars ignore it.
+	CJKTokens bool
+
+	// EmitParent keeps each split document in the output, flagged with
+	// Metadata["is_parent"]="true" and followed by its chunks, for
+	// parent-document retrieval: chunks are matched and their parent is
+	// returned as context. The parent is the document the chunks were cut
+	// from, after SourceField and IDAllocator apply, and counts once in
+	// Count. Unsplit documents are emitted as usual, without the flag.
+	EmitParent bool
+
+	// Order is the order in which chunks are emitted, for backends
+	// sensitive to write order.
+	// Default: OrderSequential
+	Order ChunkOrder
+
+	// MaxContentBytes guards the worker against pathological inputs: a
+	// document whose content (after SourceField) exceeds it fails the
+	// activity before it is tokenized, or is skipped with a warning
+	// under ContinueOnError.
+	// Default: 0 (unlimited)
+	MaxContentBytes int
+
+	// PreserveBlankSegments treats an empty segment between two
+	// separators as an intentional section boundary under
+	// StrategyBalanced: when a document is split, no chunk or overlap
+	// spans the blank segment and each side is balanced on its own.
+	// Other strategies collapse blank segments as usual.
+	PreserveBlankSegments bool
+
+	// Anchored keeps chunks stable when content is appended to a
+	// document between runs, so only the tail needs re-embedding. Chunk
+	// windows start at fixed multiples of MaxTokens-Overlap from the
+	// start of the document, as with OverlapLeading, and a document that
+	// fits in one chunk is still emitted as chunk 0 rather than
+	// unchanged, so its ID does not change once it grows. ParagraphMarkers
+	// are ignored, since a marker in appended text would otherwise change
+	// how the existing text is tokenized. If text is only appended, and
+	// starts with whitespace or Separator, every chunk that ended before
+	// the old end of the document keeps its ID and Content; the old last
+	// chunk keeps its ID and may grow, and new chunks follow it.
+	// MaxChunksPerDoc and MaxChunksMergeTail only change the last chunk.
+	// Requires StrategyTokens without PreserveLists and with
+	// OverlapLeading.
+	Anchored bool
+
+	// ContinueOnError drops documents that fail MaxContentBytes from the
+	// output and records a warning for each instead of failing the batch.
+	ContinueOnError bool
 }
 
+// ChunkOrder determines the order of ChunkActivity's output documents.
+type ChunkOrder string
+
+const (
+	// OrderSequential emits every chunk of a document, in order, before
+	// the chunks of the next document.
+	OrderSequential ChunkOrder = "sequential"
+
+	// OrderInterleaved emits the first output document of every input
+	// document, then the second of every document, and so on, for a
+	// breadth-first write across documents. With EmitParent every parent
+	// precedes every chunk.
+	OrderInterleaved ChunkOrder = "interleaved"
+
+	// OrderReverse emits the sequential order reversed.
+	OrderReverse ChunkOrder = "reverse"
+)
+
+// MetadataIsParent flags a document emitted alongside its own chunks by
+// ChunkOptions.EmitParent.
+const MetadataIsParent = "is_parent"
+
+// SeparatorMode determines what happens to separator text when splitting.
+type SeparatorMode string
+
+const (
+	// SeparatorDrop discards the separator.
+	SeparatorDrop SeparatorMode = "drop"
+
+	// SeparatorPrefix keeps the separator at the start of the segment
+	// that follows it.
+	SeparatorPrefix SeparatorMode = "prefix"
+
+	// SeparatorSuffix keeps the separator at the end of the segment that
+	// precedes it.
+	SeparatorSuffix SeparatorMode = "suffix"
+)
+
+// ChunkStrategy determines how a document is split into chunks.
+type ChunkStrategy string
+
+const (
+	// StrategyTokens splits documents into overlapping windows of at
+	// most MaxTokens tokens.
+	StrategyTokens ChunkStrategy = "tokens"
+
+	// StrategySentenceWindow emits one chunk per sentence, the embed
+	// target, and stores the surrounding WindowSize sentences on each
+	// side in Metadata["window"] for use as context at retrieval time.
+	StrategySentenceWindow ChunkStrategy = "sentence_window"
+
+	// StrategyBalanced splits documents into the fewest chunks that fit
+	// MaxTokens and distributes tokens evenly across them, so there is no
+	// undersized final chunk. Boundaries snap to nearby Separator breaks.
+	StrategyBalanced ChunkStrategy = "balanced"
+
+	// StrategyMarkdown splits markdown documents at headings and splits
+	// sections longer than MaxTokens into token windows. Each chunk's
+	// Title is its section heading, falling back to the parent title,
+	// and the parent title is kept in Metadata["document_title"].
+	StrategyMarkdown ChunkStrategy = "markdown"
+
+	// StrategyChars splits documents into windows of at most MaxTokens
+	// characters, overlapping by Overlap characters, for content where
+	// whitespace tokens are meaningless. See SnapToWords.
+	StrategyChars ChunkStrategy = "chars"
+)
+
+// MetadataNoChunk is a conventional SkipMetadataKey for content that
+// must never be split.
+const MetadataNoChunk = "no_chunk"
+
+// MetadataWindow is the metadata key holding a sentence's context window.
+const MetadataWindow = "window"
+
+// Metadata keys read as per-document overrides when
+// ChunkOptions.AllowMetadataOverrides is set.
+const (
+	MetadataMaxTokens = "max_tokens"
+	MetadataOverlap   = "overlap"
+	MetadataStrategy  = "strategy"
+)
+
+// DefaultWindowSize is the sentence window size used when
+// ChunkOptions.WindowSize is zero.
+const DefaultWindowSize = 3
+
+// AlreadyChunkedPolicy determines how chunking treats documents that are
+// already chunks of a parent document.
+type AlreadyChunkedPolicy string
+
+const (
+	// AlreadyChunkedPassthrough leaves existing chunks untouched.
+	AlreadyChunkedPassthrough AlreadyChunkedPolicy = "passthrough"
+
+	// AlreadyChunkedError fails the activity when a chunk is encountered.
+	AlreadyChunkedError AlreadyChunkedPolicy = "error"
+
+	// AlreadyChunkedRechunk splits existing chunks again, treating each
+	// chunk as a parent document.
+	AlreadyChunkedRechunk AlreadyChunkedPolicy = "rechunk"
+)
+
+// MaxChunksPolicy determines how chunking treats documents that would
+// produce more than MaxChunksPerDoc chunks.
+type MaxChunksPolicy string
+
+const (
+	// MaxChunksError fails the activity when a document exceeds the cap.
+	MaxChunksError MaxChunksPolicy = "error"
+
+	// MaxChunksMergeTail keeps MaxChunksPerDoc chunks and merges the rest
+	// of the document into the last one, which may then exceed MaxTokens.
+	// For range-based strategies the last chunk extends to the end of the
+	// document; otherwise the remaining chunks are joined with Separator,
+	// so overlapping text between them may repeat.
+	MaxChunksMergeTail MaxChunksPolicy = "merge_tail"
+)
+
 // DefaultChunkOptions returns sensible defaults for chunking.
 func DefaultChunkOptions() ChunkOptions {
 	return ChunkOptions{
@@ -35,6 +377,120 @@ func DefaultChunkOptions() ChunkOptions {
 	}
 }
 
+// Merge returns o with every non-zero field of override applied on top,
+// for layering per-request options over a baseline. Zero fields of
+// override inherit the value from o, so an override cannot reset a field
+// to its zero value: Overlap 0 (no overlap), empty strings and false
+// booleans all mean "unset". ParagraphMarkers is the exception: nil
+// inherits, while a non-nil empty slice overrides and disables the
+// fallback markers.
+func (o ChunkOptions) Merge(override ChunkOptions) ChunkOptions {
+	if override.Strategy != "" {
+		o.Strategy = override.Strategy
+	}
+	if override.MaxTokens != 0 {
+		o.MaxTokens = override.MaxTokens
+	}
+	if override.Overlap != 0 {
+		o.Overlap = override.Overlap
+	}
+	if override.OverlapPlacement != "" {
+		o.OverlapPlacement = override.OverlapPlacement
+	}
+	if override.Separator != "" {
+		o.Separator = override.Separator
+	}
+	if override.ParagraphMarkers != nil {
+		o.ParagraphMarkers = override.ParagraphMarkers
+	}
+	o.RequireSeparator = o.RequireSeparator || override.RequireSeparator
+	if override.SkipMetadataKey != "" {
+		o.SkipMetadataKey = override.SkipMetadataKey
+	}
+	if override.IDAllocator != "" {
+		o.IDAllocator = override.IDAllocator
+	}
+	o.PreserveLists = o.PreserveLists || override.PreserveLists
+	o.SliceContent = o.SliceContent || override.SliceContent
+	if override.TrimChunks != nil {
+		o.TrimChunks = override.TrimChunks
+	}
+	o.CacheTokenCounts = o.CacheTokenCounts || override.CacheTokenCounts
+	if override.WindowSize != 0 {
+		o.WindowSize = override.WindowSize
+	}
+	if override.SentenceSplitter != "" {
+		o.SentenceSplitter = override.SentenceSplitter
+	}
+	if override.OnAlreadyChunked != "" {
+		o.OnAlreadyChunked = override.OnAlreadyChunked
+	}
+	if override.MaxChunksPerDoc != 0 {
+		o.MaxChunksPerDoc = override.MaxChunksPerDoc
+	}
+	if override.OnMaxChunks != "" {
+		o.OnMaxChunks = override.OnMaxChunks
+	}
+	o.HeadingPrefix = o.HeadingPrefix || override.HeadingPrefix
+	if override.SourceField != "" {
+		o.SourceField = override.SourceField
+	}
+	o.RecordTokenRanges = o.RecordTokenRanges || override.RecordTokenRanges
+	if override.KeepSeparator != "" {
+		o.KeepSeparator = override.KeepSeparator
+	}
+	o.AtomicCodeBlocks = o.AtomicCodeBlocks || override.AtomicCodeBlocks
+	if override.SnapToWords != nil {
+		o.SnapToWords = override.SnapToWords
+	}
+	if override.URLFragmentTemplate != "" {
+		o.URLFragmentTemplate = override.URLFragmentTemplate
+	}
+	o.DryRun = o.DryRun || override.DryRun
+	o.AllowMetadataOverrides = o.AllowMetadataOverrides || override.AllowMetadataOverrides
+	o.EmitParent = o.EmitParent || override.EmitParent
+	if override.Order != "" {
+		o.Order = override.Order
+	}
+	if override.MaxContentBytes != 0 {
+		o.MaxContentBytes = override.MaxContentBytes
+	}
+	o.PreserveBlankSegments = o.PreserveBlankSegments || override.PreserveBlankSegments
+	o.Anchored = o.Anchored || override.Anchored
+	o.ContinueOnError = o.ContinueOnError || override.ContinueOnError
+	if !override.Selector.IsZero() {
+		o.Selector = override.Selector
+	}
+	o.CJKTokens = o.CJKTokens || override.CJKTokens
+	return o
+}
+
+// OverlapPlacement determines how overlapping chunk windows are laid out.
+type OverlapPlacement string
+
+const (
+	// OverlapLeading anchors windows at the start of the document.
+	// Each chunk after the first begins with the last Overlap tokens of
+	// its predecessor, and any short remainder falls in the last chunk.
+	OverlapLeading OverlapPlacement = "leading"
+
+	// OverlapTrailing anchors windows at the end of the document.
+	// Each chunk before the last ends with the first Overlap tokens of
+	// its successor, and any short remainder falls in the first chunk.
+	OverlapTrailing OverlapPlacement = "trailing"
+
+	// OverlapSymmetric centers windows over the document so the short
+	// remainder is split between the first and last chunks.
+	OverlapSymmetric OverlapPlacement = "symmetric"
+
+	// OverlapTapered anchors windows at the start of the document like
+	// OverlapLeading but drops the overlap between the first two and the
+	// last two chunks, where there is no outer context to bridge, so the
+	// document's opening and closing tokens are embedded only once.
+	// Middle chunks overlap by Overlap tokens.
+	OverlapTapered OverlapPlacement = "tapered"
+)
+
 // ChunkInput is the input for the Chunk transformer.
 type ChunkInput struct {
 	Documents []Document
@@ -45,6 +501,13 @@ type ChunkInput struct {
 type ChunkOutput struct {
 	Documents []Document
 	Count     int
+
+	// Warnings describes defaults, clamping, and fallbacks applied while
+	// chunking that changed the effective behavior of the options.
+	Warnings []string
+
+	// Stats describes the overlap actually realized while chunking.
+	Stats ChunkStats
 }
 
 // ToDocuments implements DocumentSource for ChunkOutput.
@@ -54,21 +517,25 @@ func (o ChunkOutput) ToDocuments() []Document {
 
 // ChunkActivity splits large documents into smaller chunks.
 func ChunkActivity(ctx context.Context, input ChunkInput) (ChunkOutput, error) {
-	opts := input.Options
-	if opts.MaxTokens == 0 {
-		opts = DefaultChunkOptions()
+	opts, warnings, err := resolveChunkOptions(input.Options)
+	if err != nil {
+		return ChunkOutput{}, err
 	}
 
-	var chunked []Document
-
-	for _, doc := range input.Documents {
-		chunks := chunkDocument(doc, opts)
-		chunked = append(chunked, chunks...)
+	result, err := chunkDocuments(ctx, input.Documents, opts)
+	if err != nil {
+		return ChunkOutput{}, err
+	}
+	count := len(result.Documents)
+	if opts.DryRun {
+		result.Documents = []Document{}
 	}
 
 	return ChunkOutput{
-		Documents: chunked,
-		Count:     len(chunked),
+		Documents: result.Documents,
+		Count:     count,
+		Warnings:  append(warnings, result.Warnings...),
+		Stats:     result.Stats,
 	}, nil
 }
 
@@ -95,6 +562,8 @@ type MergeAndChunkInput struct {
 type MergeAndChunkOutput struct {
 	Documents []Document
 	Count     int
+	Warnings  []string
+	Stats     ChunkStats
 }
 
 // ToDocuments implements DocumentSource for MergeAndChunkOutput.
@@ -104,25 +573,30 @@ func (o MergeAndChunkOutput) ToDocuments() []Document {
 
 // MergeAndChunkActivity combines multiple sources and chunks the result.
 func MergeAndChunkActivity(ctx context.Context, input MergeAndChunkInput) (MergeAndChunkOutput, error) {
-	var docs []Document
+	docs := []Document{}
 	for _, source := range input.Sources {
 		docs = append(docs, source.ToDocuments()...)
 	}
 
-	opts := input.Options
-	if opts.MaxTokens == 0 {
-		opts = DefaultChunkOptions()
+	opts, warnings, err := resolveChunkOptions(input.Options)
+	if err != nil {
+		return MergeAndChunkOutput{}, err
 	}
 
-	var chunked []Document
-	for _, doc := range docs {
-		chunks := chunkDocument(doc, opts)
-		chunked = append(chunked, chunks...)
+	result, err := chunkDocuments(ctx, docs, opts)
+	if err != nil {
+		return MergeAndChunkOutput{}, err
+	}
+	count := len(result.Documents)
+	if opts.DryRun {
+		result.Documents = []Document{}
 	}
 
 	return MergeAndChunkOutput{
-		Documents: chunked,
-		Count:     len(chunked),
+		Documents: result.Documents,
+		Count:     count,
+		Warnings:  append(warnings, result.Warnings...),
+		Stats:     result.Stats,
 	}, nil
 }
 
@@ -140,71 +614,935 @@ func MergeAndChunk(opts ChunkOptions) *core.Node[MergeAndChunkInput, MergeAndChu
 	return core.NewNode("transform.MergeAndChunk", MergeAndChunkActivity, MergeAndChunkInput{Options: opts})
 }
 
+// MergeAndChunkToRefInput is the input for MergeAndChunkToRefActivity.
+type MergeAndChunkToRefInput struct {
+	// Sources and the documents stored at Refs are merged in that order.
+	Sources []DocumentSource
+	Refs    []core.DataRef
+	Options ChunkOptions
+}
+
+// MergeAndChunkToRefOutput is the output of MergeAndChunkToRefActivity.
+// Ref holds the chunked documents; see LoadDocuments.
+type MergeAndChunkToRefOutput struct {
+	Ref      core.DataRef
+	Count    int
+	Warnings []string
+	Stats    ChunkStats
+}
+
+// MergeAndChunkToRefActivity merges and chunks like MergeAndChunkActivity
+// and stores the result, so a large fan-in passes only a DataRef through
+// the workflow history. Under DryRun nothing is stored and Ref is empty.
+// Like MergeRefsActivity, a retry stores a ref with the same Checksum.
+func MergeAndChunkToRefActivity(ctx context.Context, input MergeAndChunkToRefInput) (MergeAndChunkToRefOutput, error) {
+	sources := append([]DocumentSource(nil), input.Sources...)
+	for _, ref := range input.Refs {
+		docs, err := LoadDocuments(ctx, ref)
+		if err != nil {
+			return MergeAndChunkToRefOutput{}, err
+		}
+		sources = append(sources, DocumentBatch{Documents: docs})
+	}
+
+	out, err := MergeAndChunkActivity(ctx, MergeAndChunkInput{Sources: sources, Options: input.Options})
+	if err != nil {
+		return MergeAndChunkToRefOutput{}, err
+	}
+
+	var ref core.DataRef
+	if !input.Options.DryRun {
+		if ref, err = StoreDocuments(ctx, out.Documents); err != nil {
+			return MergeAndChunkToRefOutput{}, err
+		}
+	}
+
+	return MergeAndChunkToRefOutput{
+		Ref:      ref,
+		Count:    out.Count,
+		Warnings: out.Warnings,
+		Stats:    out.Stats,
+	}, nil
+}
+
+// MergeAndChunkToRef creates a node that merges sources and chunks in one
+// step and stores the chunks, returning their DataRef.
+//
+// Example:
+//
+//	flow := core.NewFlow("knowledge-base").
+//	    ThenParallel("fetch", jiraNode, confluenceNode, pagerdutyNode).
+//	    Then(transform.MergeAndChunkToRef(transform.ChunkOptions{MaxTokens: 512})).
+//	    Then(transform.EmbedRef(transform.EmbedRefInput{
+//	        SourceRef: core.OutputRef("chunks"),
+//	        Options:   transform.EmbedOptions{Embedder: "ollama"},
+//	    })).
+//	    Build()
+func MergeAndChunkToRef(opts ChunkOptions) *core.Node[MergeAndChunkToRefInput, MergeAndChunkToRefOutput] {
+	return core.NewNode("transform.MergeAndChunkToRef", MergeAndChunkToRefActivity, MergeAndChunkToRefInput{Options: opts})
+}
+
+// resolveChunkOptions applies defaults to opts and validates them.
+// It returns warnings describing any substitution or clamping applied.
+func resolveChunkOptions(opts ChunkOptions) (ChunkOptions, []string, error) {
+	var warnings []string
+
+	if opts.MaxTokens < 0 {
+		return ChunkOptions{}, nil, fmt.Errorf("max tokens must not be negative, got %d", opts.MaxTokens)
+	}
+	if opts.Overlap < 0 {
+		return ChunkOptions{}, nil, fmt.Errorf("overlap must not be negative, got %d", opts.Overlap)
+	}
+
+	// Only the unset fields take their defaults, so an explicit Overlap
+	// or Separator survives a forgotten MaxTokens.
+	if opts.MaxTokens == 0 {
+		opts = DefaultChunkOptions().Merge(opts)
+		warnings = append(warnings, "max tokens not set; using default chunk options")
+	}
+
+	switch opts.Strategy {
+	case StrategyTokens, StrategySentenceWindow, StrategyBalanced, StrategyMarkdown, StrategyChars, "":
+	default:
+		return ChunkOptions{}, nil, fmt.Errorf("unknown chunk strategy: %q", opts.Strategy)
+	}
+	if opts.WindowSize < 0 {
+		return ChunkOptions{}, nil, fmt.Errorf("window size must not be negative, got %d", opts.WindowSize)
+	}
+	if _, ok := lookupSentenceSplitter(opts.SentenceSplitter); !ok {
+		return ChunkOptions{}, nil, fmt.Errorf("unknown sentence splitter: %q", opts.SentenceSplitter)
+	}
+	if opts.MaxChunksPerDoc < 0 {
+		return ChunkOptions{}, nil, fmt.Errorf("max chunks per document must not be negative, got %d", opts.MaxChunksPerDoc)
+	}
+	switch opts.OverlapPlacement {
+	case OverlapLeading, OverlapTrailing, OverlapSymmetric, OverlapTapered, "":
+	default:
+		return ChunkOptions{}, nil, fmt.Errorf("unknown overlap placement: %q", opts.OverlapPlacement)
+	}
+	switch opts.OnAlreadyChunked {
+	case AlreadyChunkedPassthrough, AlreadyChunkedError, AlreadyChunkedRechunk, "":
+	default:
+		return ChunkOptions{}, nil, fmt.Errorf("unknown already-chunked policy: %q", opts.OnAlreadyChunked)
+	}
+	switch opts.OnMaxChunks {
+	case MaxChunksError, MaxChunksMergeTail, "":
+	default:
+		return ChunkOptions{}, nil, fmt.Errorf("unknown max chunks policy: %q", opts.OnMaxChunks)
+	}
+	switch opts.KeepSeparator {
+	case SeparatorDrop, SeparatorPrefix, SeparatorSuffix, "":
+	default:
+		return ChunkOptions{}, nil, fmt.Errorf("unknown separator mode: %q", opts.KeepSeparator)
+	}
+	if opts.MaxContentBytes < 0 {
+		return ChunkOptions{}, nil, fmt.Errorf("max content bytes must not be negative, got %d", opts.MaxContentBytes)
+	}
+	switch opts.Order {
+	case OrderSequential, OrderInterleaved, OrderReverse, "":
+	default:
+		return ChunkOptions{}, nil, fmt.Errorf("unknown chunk order: %q", opts.Order)
+	}
+
+	if opts.Anchored {
+		switch {
+		case opts.Strategy != StrategyTokens && opts.Strategy != "":
+			return ChunkOptions{}, nil, fmt.Errorf("anchored chunking requires the %s strategy, got %q", StrategyTokens, opts.Strategy)
+		case opts.OverlapPlacement != OverlapLeading && opts.OverlapPlacement != "":
+			return ChunkOptions{}, nil, fmt.Errorf("anchored chunking requires %s overlap placement, got %q", OverlapLeading, opts.OverlapPlacement)
+		case opts.PreserveLists:
+			return ChunkOptions{}, nil, errors.New("anchored chunking does not support preserve lists")
+		}
+		opts.ParagraphMarkers = []string{}
+	}
+
+	if opts.Overlap >= opts.MaxTokens {
+		warnings = append(warnings, fmt.Sprintf(
+			"overlap %d is not less than max tokens %d; chunks advance by one token", opts.Overlap, opts.MaxTokens))
+	}
+
+	return opts, warnings, nil
+}
+
+// chunkResult is the result of chunking a set of documents.
+type chunkResult struct {
+	Documents []Document
+	Warnings  []string
+	Stats     ChunkStats
+}
+
+// ChunkStats describes the overlap realized while chunking, which can
+// differ from the requested Overlap when windows are clamped, placed, or
+// merged. Only documents that were split into several chunks count.
+type ChunkStats struct {
+	// SplitDocuments is the number of documents split into chunks.
+	SplitDocuments int
+
+	// Chunks is the number of chunks produced from split documents.
+	Chunks int
+
+	// ChunkTokens is the total number of tokens across those chunks.
+	ChunkTokens int
+
+	// OverlapTokens is the number of tokens duplicated into a
+	// neighboring chunk of the same document.
+	OverlapTokens int
+
+	// AvgOverlapTokens is the average overlap between adjacent chunks.
+	AvgOverlapTokens float64
+
+	// PrefixTokens is the number of tokens added by HeadingPrefix. They
+	// are not part of ChunkTokens or OverlapTokens, which count body
+	// tokens.
+	PrefixTokens int
+
+	// DuplicatedFraction is the fraction of rendered chunk tokens
+	// (ChunkTokens plus PrefixTokens) that are duplicates, overlap or
+	// repeated headings, i.e. the embedding overhead of the overlap.
+	DuplicatedFraction float64
+}
+
+// addRanges records the token ranges of one document's chunks.
+func (s *ChunkStats) addRanges(ranges [][2]int) {
+	for i, r := range ranges {
+		s.ChunkTokens += r[1] - r[0]
+		if i > 0 && ranges[i-1][1] > r[0] {
+			s.OverlapTokens += ranges[i-1][1] - r[0]
+		}
+	}
+}
+
+// add accumulates the stats of one split document into s.
+func (s *ChunkStats) add(doc ChunkStats, chunks int) {
+	s.SplitDocuments++
+	s.Chunks += chunks
+	s.ChunkTokens += doc.ChunkTokens
+	s.OverlapTokens += doc.OverlapTokens
+	s.PrefixTokens += doc.PrefixTokens
+}
+
+// finish computes the derived averages.
+func (s *ChunkStats) finish() {
+	if pairs := s.Chunks - s.SplitDocuments; pairs > 0 {
+		s.AvgOverlapTokens = float64(s.OverlapTokens) / float64(pairs)
+	}
+	if rendered := s.ChunkTokens + s.PrefixTokens; rendered > 0 {
+		s.DuplicatedFraction = float64(s.OverlapTokens+s.PrefixTokens) / float64(rendered)
+	}
+}
+
+// chunkDocuments splits each document into chunks, applying the
+// OnAlreadyChunked policy to documents that are already chunks.
+func chunkDocuments(ctx context.Context, docs []Document, opts ChunkOptions) (chunkResult, error) {
+	result := chunkResult{Documents: make([]Document, 0, len(docs))}
+	var split, missingSeparator int
+
+	// starts holds the index of each input document's first output
+	// document, for reordering.
+	starts := make([]int, 0, len(docs))
+	log := debugLogger(ctx)
+
+	var alloc IDAllocator
+	if opts.IDAllocator != "" {
+		var err error
+		if alloc, err = newIDAllocator(opts.IDAllocator); err != nil {
+			return chunkResult{}, err
+		}
+	}
+
+	for _, doc := range docs {
+		starts = append(starts, len(result.Documents))
+		if !opts.Selector.Matches(doc) {
+			result.Documents = append(result.Documents, doc)
+			continue
+		}
+		if doc.IsChunk() {
+			switch opts.OnAlreadyChunked {
+			case AlreadyChunkedError:
+				return chunkResult{}, fmt.Errorf("document %s is already a chunk of %s", doc.ID, doc.ParentID)
+			case AlreadyChunkedRechunk:
+			default:
+				result.Documents = append(result.Documents, doc)
+				continue
+			}
+		}
+
+		opts := opts
+		if opts.AllowMetadataOverrides {
+			var warning string
+			if opts, warning = withMetadataOverrides(doc, opts); warning != "" {
+				result.Warnings = append(result.Warnings, warning)
+			}
+		}
+
+		if opts.SourceField != "" {
+			var err error
+			if doc, err = withSourceField(doc, opts.SourceField); err != nil {
+				return chunkResult{}, err
+			}
+		}
+		if opts.MaxContentBytes > 0 && len(doc.Content) > opts.MaxContentBytes {
+			err := fmt.Errorf("document %s content is %d bytes, exceeding the limit of %d",
+				doc.ID, len(doc.Content), opts.MaxContentBytes)
+			if !opts.ContinueOnError {
+				return chunkResult{}, err
+			}
+			result.Warnings = append(result.Warnings, err.Error()+"; skipped")
+			continue
+		}
+		if alloc != nil {
+			doc.ID = alloc.Allocate(doc)
+		}
+		// Windows and old Mac line endings would hide "\n" separators.
+		// Only the text being split is normalized; a document returned
+		// whole keeps its own content.
+		content := doc.Content
+		doc.Content = normalizeLineEndings(content)
+
+		var stats ChunkStats
+		chunks := splitDocument(doc, opts, &stats)
+		if len(chunks) == 1 && !chunks[0].IsChunk() {
+			chunks[0].Content = content
+		}
+		if opts.MaxChunksPerDoc > 0 && len(chunks) > opts.MaxChunksPerDoc {
+			if opts.OnMaxChunks != MaxChunksMergeTail {
+				return chunkResult{}, fmt.Errorf("document %s produces %d chunks, exceeding the cap of %d",
+					doc.ID, len(chunks), opts.MaxChunksPerDoc)
+			}
+			chunks = mergeTailChunks(chunks, opts.MaxChunksPerDoc, opts.Separator)
+		}
+		if len(chunks) > 1 {
+			if log != nil {
+				log.DebugContext(ctx, "transform: document chunked", "id", doc.ID, "chunks", len(chunks), "strategy", opts.Strategy)
+			}
+			split++
+			result.Stats.add(stats, len(chunks))
+			if opts.TrimChunks == nil || *opts.TrimChunks {
+				for i := range chunks {
+					chunks[i].Content = strings.TrimSpace(chunks[i].Content)
+				}
+			}
+			if opts.Separator != "" && !strings.Contains(doc.Content, opts.Separator) {
+				if opts.RequireSeparator {
+					return chunkResult{}, fmt.Errorf("separator %q not found in document %s", opts.Separator, doc.ID)
+				}
+				missingSeparator++
+			}
+		}
+		if opts.URLFragmentTemplate != "" && len(chunks) > 1 {
+			linkFragments(chunks, doc.Content, opts.URLFragmentTemplate)
+		}
+		if opts.EmitParent && len(chunks) > 1 {
+			parent := doc
+			parent.Content = content
+			chunks = append([]Document{parent.withMetadataCopy(MetadataIsParent, "true")}, chunks...)
+		}
+		if opts.CacheTokenCounts {
+			for i := range chunks {
+				chunks[i] = WithTokenCount(chunks[i])
+			}
+		}
+		result.Documents = append(result.Documents, chunks...)
+	}
+
+	if missingSeparator > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"separator %q not found in %d of %d split documents", opts.Separator, missingSeparator, split))
+	}
+	result.Stats.finish()
+	result.Documents = orderChunks(result.Documents, starts, opts.Order)
+
+	return result, nil
+}
+
+// orderChunks reorders the sequential output docs, in which the output
+// of the i-th input document starts at starts[i], according to order.
+func orderChunks(docs []Document, starts []int, order ChunkOrder) []Document {
+	switch order {
+	case OrderReverse:
+		slices.Reverse(docs)
+		return docs
+	case OrderInterleaved:
+	default:
+		return docs
+	}
+
+	ordered := make([]Document, 0, len(docs))
+	for round := 0; len(ordered) < len(docs); round++ {
+		for i, start := range starts {
+			end := len(docs)
+			if i+1 < len(starts) {
+				end = starts[i+1]
+			}
+			if start+round < end {
+				ordered = append(ordered, docs[start+round])
+			}
+		}
+	}
+	return ordered
+}
+
+// withSourceField returns doc with its Content replaced by the metadata
+// field and the field removed from a copy of its metadata.
+func withSourceField(doc Document, field string) (Document, error) {
+	content, ok := doc.Metadata[field]
+	if !ok {
+		return Document{}, fmt.Errorf("document %s has no metadata field %q", doc.ID, field)
+	}
+
+	doc.Metadata = copyMetadata(doc.Metadata)
+	delete(doc.Metadata, field)
+	doc.Content = content
+	return doc, nil
+}
+
+// withMetadataOverrides returns opts with the chunk overrides in doc's
+// metadata applied. If an override is invalid it returns opts unchanged
+// and a warning.
+func withMetadataOverrides(doc Document, opts ChunkOptions) (ChunkOptions, string) {
+	o := opts
+	for _, key := range []string{MetadataMaxTokens, MetadataOverlap, MetadataStrategy} {
+		value, ok := doc.Metadata[key]
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch key {
+		case MetadataMaxTokens:
+			if o.MaxTokens, err = strconv.Atoi(value); err == nil && o.MaxTokens <= 0 {
+				err = errors.New("must be positive")
+			}
+		case MetadataOverlap:
+			o.Overlap, err = strconv.Atoi(value)
+		case MetadataStrategy:
+			o.Strategy = ChunkStrategy(value)
+		}
+		if err == nil {
+			_, _, err = resolveChunkOptions(o)
+		}
+		if err != nil {
+			return opts, fmt.Sprintf("document %s: invalid chunk override %s=%q (%v); using activity options", doc.ID, key, value, err)
+		}
+	}
+	return o, ""
+}
+
+// skipChunking reports whether doc is flagged as atomic under key.
+func skipChunking(doc Document, key string) bool {
+	if key == "" {
+		return false
+	}
+	skip, err := strconv.ParseBool(doc.Metadata[key])
+	return err == nil && skip
+}
+
+// capRanges extends the last allowed range to the end of the document
+// when MaxChunksMergeTail is in effect and ranges exceeds the cap.
+func capRanges(ranges [][2]int, numTokens int, opts ChunkOptions) [][2]int {
+	limit := opts.MaxChunksPerDoc
+	if opts.OnMaxChunks != MaxChunksMergeTail || limit <= 0 || len(ranges) <= limit {
+		return ranges
+	}
+
+	ranges = ranges[:limit]
+	ranges[limit-1][1] = numTokens
+	return ranges
+}
+
+// mergeTailChunks joins chunks beyond limit into the last allowed chunk.
+func mergeTailChunks(chunks []Document, limit int, sep string) []Document {
+	if sep == "" {
+		sep = " "
+	}
+
+	contents := make([]string, 0, len(chunks)-limit+1)
+	for _, chunk := range chunks[limit-1:] {
+		contents = append(contents, chunk.Content)
+	}
+
+	chunks = chunks[:limit]
+	chunks[limit-1].Content = strings.Join(contents, sep)
+
+	// The merged content repeats the overlap between the merged chunks,
+	// so its token range no longer describes it.
+	if _, ok := chunks[limit-1].Metadata[MetadataTokenStart]; ok {
+		metadata := copyMetadata(chunks[limit-1].Metadata)
+		delete(metadata, MetadataTokenStart)
+		delete(metadata, MetadataTokenEnd)
+		chunks[limit-1].Metadata = metadata
+	}
+	return chunks
+}
+
 // chunkDocument splits a single document into chunks.
 func chunkDocument(doc Document, opts ChunkOptions) []Document {
+	var stats ChunkStats
+	return splitDocument(doc, opts, &stats)
+}
+
+// splitDocument splits a single document into chunks, recording the
+// realized chunk ranges in stats.
+func splitDocument(doc Document, opts ChunkOptions, stats *ChunkStats) []Document {
+	if doc.Content == "" || skipChunking(doc, opts.SkipMetadataKey) {
+		return []Document{doc}
+	}
+
+	switch opts.Strategy {
+	case StrategySentenceWindow:
+		return chunkBySentenceWindow(doc, opts, stats)
+	case StrategyBalanced:
+		return chunkBalanced(doc, opts, stats)
+	case StrategyMarkdown:
+		return chunkMarkdown(doc, opts, stats)
+	case StrategyChars:
+		return chunkByChars(doc, opts, stats)
+	default:
+		return chunkByTokens(doc, opts, stats)
+	}
+}
+
+// chunkByTokens splits a document into overlapping token windows.
+func chunkByTokens(doc Document, opts ChunkOptions, stats *ChunkStats) []Document {
 	content := doc.Content
-	if content == "" {
+
+	// A cached count is only valid for whitespace separators, where
+	// tokenization is equivalent to splitting on whitespace. The
+	// separator in effect can differ from opts.Separator: a paragraph
+	// marker, or the character split of an empty marker.
+	if n, ok := cachedTokenCount(doc); ok && n <= opts.MaxTokens && !opts.CJKTokens && !opts.Anchored &&
+		isWhitespace(opts.Separator) && isWhitespace(paragraphSeparator(content, opts.Separator, opts.ParagraphMarkers)) {
 		return []Document{doc}
 	}
 
-	tokens := tokenize(content, opts.Separator)
-	if len(tokens) <= opts.MaxTokens {
+	spans, _ := chunkTokenLayout(content, opts)
+	if len(spans) == 0 || (len(spans) <= opts.MaxTokens && !opts.Anchored) {
 		return []Document{doc}
 	}
 
+	var items [][2]int
+	if opts.PreserveLists {
+		items = itemTokenRanges(spans, listItems(content))
+	}
+
+	var ranges [][2]int
+	if len(items) > 0 {
+		ranges = listBoundaries(len(spans), items, opts)
+	} else {
+		ranges = chunkBoundaries(len(spans), opts)
+	}
+	ranges = capRanges(ranges, len(spans), opts)
+	stats.addRanges(ranges)
+
 	var chunks []Document
-	chunkIdx := 0
 
-	for start := 0; start < len(tokens); {
-		end := start + opts.MaxTokens
-		if end > len(tokens) {
-			end = len(tokens)
+	for chunkIdx, r := range ranges {
+		chunk := newChunk(doc, chunkIdx, joinSpans(content, spans[r[0]:r[1]], opts.SliceContent))
+		if len(items) > 0 && listDominant(r, items) {
+			chunk = chunk.withMetadataCopy(MetadataContentType, ContentTypeList)
+		}
+		if opts.RecordTokenRanges {
+			recordTokenRange(&chunk, r)
 		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
 
-		chunkContent := strings.Join(tokens[start:end], " ")
+// chunkBySentenceWindow emits one chunk per sentence with its surrounding
+// sentences stored in Metadata["window"].
+func chunkBySentenceWindow(doc Document, opts ChunkOptions, stats *ChunkStats) []Document {
+	k := opts.WindowSize
+	if k == 0 {
+		k = DefaultWindowSize
+	}
+
+	splitter, ok := lookupSentenceSplitter(opts.SentenceSplitter)
+	if !ok {
+		splitter = EnglishSentenceSplitter{}
+	}
+	sentences := splitSentences(doc.Content, opts.Separator, splitter)
+	if len(sentences) <= 1 {
+		return []Document{doc.withMetadataCopy(MetadataWindow, strings.Join(sentences, " "))}
+	}
 
-		chunk := Document{
-			ID:         doc.ID + "#" + itoa(chunkIdx),
-			Content:    chunkContent,
-			Title:      doc.Title,
-			Source:     doc.Source,
-			URL:        doc.URL,
-			Metadata:   copyMetadata(doc.Metadata),
-			ChunkIndex: chunkIdx,
-			ParentID:   doc.ID,
-			UpdatedAt:  doc.UpdatedAt,
+	chunks := make([]Document, 0, len(sentences))
+	for i, sentence := range sentences {
+		lo, hi := i-k, i+k+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(sentences) {
+			hi = len(sentences)
 		}
 
+		stats.ChunkTokens += len(strings.Fields(sentence))
+
+		chunk := newChunk(doc, i, sentence)
+		if chunk.Metadata == nil {
+			chunk.Metadata = make(map[string]string, 1)
+		}
+		chunk.Metadata[MetadataWindow] = strings.Join(sentences[lo:hi], " ")
 		chunks = append(chunks, chunk)
-		chunkIdx++
+	}
+
+	return chunks
+}
 
-		if end >= len(tokens) {
+// newChunk creates the index-th chunk of doc with the given content.
+// The chunk inherits the parent's fields and a copy of its metadata.
+func newChunk(doc Document, index int, content string) Document {
+	return Document{
+		ID:         ChunkID(doc.ID, index, ChunkIDSeparator, 0),
+		Content:    content,
+		Title:      doc.Title,
+		Source:     doc.Source,
+		URL:        doc.URL,
+		Metadata:   copyMetadata(doc.Metadata),
+		Attributes: doc.Attributes.clone(),
+		ChunkIndex: index,
+		ParentID:   doc.ID,
+		UpdatedAt:  doc.UpdatedAt,
+	}
+}
+
+// chunkBoundaries returns the [start, end) token ranges of the chunks of
+// a document with numTokens tokens. Windows hold at most MaxTokens tokens,
+// advance by MaxTokens-Overlap tokens (at least one), and are anchored
+// according to OverlapPlacement. Every token is covered, no range is
+// empty, and every range contains at least one token not in its
+// predecessor.
+func chunkBoundaries(numTokens int, opts ChunkOptions) [][2]int {
+	if numTokens <= 0 {
+		return nil
+	}
+	if opts.MaxTokens <= 0 || numTokens <= opts.MaxTokens {
+		return [][2]int{{0, numTokens}}
+	}
+
+	overlap := opts.Overlap
+	if overlap < 0 {
+		overlap = 0
+	}
+	stride := opts.MaxTokens - overlap
+	if stride < 1 {
+		stride = 1
+	}
+	if opts.OverlapPlacement == OverlapTapered {
+		return taperedBoundaries(numTokens, opts.MaxTokens, stride)
+	}
+
+	var ranges [][2]int
+	for start := 0; ; start += stride {
+		end := start + opts.MaxTokens
+		if end >= numTokens {
+			ranges = append(ranges, [2]int{start, numTokens})
 			break
 		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+
+	return dropOverlapOnly(placeWindows(ranges, numTokens, opts.OverlapPlacement))
+}
 
-		step := opts.MaxTokens - opts.Overlap
-		if step < 1 {
-			step = 1
+// taperedBoundaries lays out OverlapTapered windows of width tokens: the
+// second chunk starts where the first ends, the last chunk starts where
+// its predecessor ends, and the chunks between them advance by stride.
+func taperedBoundaries(numTokens, width, stride int) [][2]int {
+	ranges := [][2]int{{0, width}}
+	for {
+		end := ranges[len(ranges)-1][1]
+		if numTokens-end <= width {
+			return append(ranges, [2]int{end, numTokens})
 		}
-		start += step
+
+		start := end
+		if len(ranges) > 1 {
+			start = end - width + stride
+		}
+		ranges = append(ranges, [2]int{start, start + width})
 	}
+}
 
-	return chunks
+// dropOverlapOnly omits ranges that add no tokens past the end of the
+// range before them, such as a final window made entirely of overlap,
+// so aggressive Overlap settings never emit a redundant chunk.
+func dropOverlapOnly(ranges [][2]int) [][2]int {
+	kept := ranges[:0]
+	for _, r := range ranges {
+		if n := len(kept); n > 0 && r[1] <= kept[n-1][1] {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// placeWindows re-anchors start-anchored ranges according to placement.
+func placeWindows(ranges [][2]int, numTokens int, placement OverlapPlacement) [][2]int {
+	if len(ranges) < 2 {
+		return ranges
+	}
+
+	// slack is how far the last window would extend past the end of the
+	// document if it were full-size.
+	width := ranges[0][1] - ranges[0][0]
+	last := ranges[len(ranges)-1]
+	slack := width - (last[1] - last[0])
+
+	var shift int
+	switch placement {
+	case OverlapTrailing:
+		shift = slack
+	case OverlapSymmetric:
+		shift = slack / 2
+	default:
+		return ranges
+	}
+
+	placed := make([][2]int, len(ranges))
+	for i, r := range ranges {
+		start := r[0] - shift
+		end := start + width
+		if start < 0 {
+			start = 0
+		}
+		if end > numTokens {
+			end = numTokens
+		}
+		placed[i] = [2]int{start, end}
+	}
+
+	return placed
 }
 
 // tokenize splits text into tokens (words).
-func tokenize(text, separator string) []string {
-	paragraphs := strings.Split(text, separator)
+func tokenize(text, separator string, markers []string) []string {
+	spans := tokenSpans(text, separator, markers)
 
-	var tokens []string
-	for _, para := range paragraphs {
-		words := strings.Fields(para)
-		tokens = append(tokens, words...)
+	tokens := make([]string, len(spans))
+	for i, s := range spans {
+		tokens[i] = text[s[0]:s[1]]
 	}
 
 	return tokens
 }
 
+// tokenSpans returns the [start, end) byte offsets of each token in text.
+// Tokens are the whitespace-separated words of each paragraph.
+func tokenSpans(text, separator string, markers []string) [][2]int {
+	spans, _ := tokenLayout(text, separator, markers)
+	return spans
+}
+
+// chunkTokenLayout returns the token layout of text under opts.
+func chunkTokenLayout(text string, opts ChunkOptions) ([][2]int, []int) {
+	spans, breaks := tokenLayoutKeep(text, opts.Separator, opts.ParagraphMarkers, opts.KeepSeparator)
+	if opts.CJKTokens {
+		spans, breaks = splitCJKSpans(text, spans, breaks)
+	}
+	return spans, breaks
+}
+
+// splitCJKSpans splits every CJK character of the token spans into its
+// own span, renumbering the paragraph breaks to match.
+func splitCJKSpans(text string, spans [][2]int, breaks []int) ([][2]int, []int) {
+	out := make([][2]int, 0, len(spans))
+	next := 0
+	renumbered := make([]int, 0, len(breaks))
+	for i, s := range spans {
+		if next < len(breaks) && breaks[next] == i {
+			renumbered = append(renumbered, len(out))
+			next++
+		}
+
+		start := s[0]
+		for j := s[0]; j < s[1]; {
+			r, size := utf8.DecodeRuneInString(text[j:s[1]])
+			if isCJK(r) {
+				if start < j {
+					out = append(out, [2]int{start, j})
+				}
+				out = append(out, [2]int{j, j + size})
+				start = j + size
+			}
+			j += size
+		}
+		if start < s[1] {
+			out = append(out, [2]int{start, s[1]})
+		}
+	}
+	return out, renumbered
+}
+
+// isCJK reports whether r is a Han, Hiragana, Katakana or Hangul
+// character.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// tokenLayout returns the token spans of text and the indices of tokens
+// that begin a new paragraph (excluding the first token).
+func tokenLayout(text, separator string, markers []string) ([][2]int, []int) {
+	return tokenLayoutKeep(text, separator, markers, SeparatorDrop)
+}
+
+// tokenLayoutKeep is tokenLayout with the separator's own words kept as
+// tokens of the following or preceding paragraph according to mode.
+func tokenLayoutKeep(text, separator string, markers []string, mode SeparatorMode) ([][2]int, []int) {
+	used := paragraphSeparator(text, separator, markers)
+	if used == "" {
+		// An empty separator splits text into single characters. Spans
+		// use the decoded width so invalid bytes never split a rune.
+		spans := make([][2]int, 0, utf8.RuneCountInString(text))
+		for i := 0; i < len(text); {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if !unicode.IsSpace(r) {
+				spans = append(spans, [2]int{i, i + size})
+			}
+			i += size
+		}
+		return spans, nil
+	}
+
+	var spans [][2]int
+	var breaks []int
+	start, offset := 0, 0
+	for {
+		if n := len(spans); n > 0 && (len(breaks) == 0 || breaks[len(breaks)-1] != n) {
+			breaks = append(breaks, n)
+		}
+
+		i := strings.Index(text[offset:], used)
+		if i < 0 {
+			spans = appendFieldSpans(spans, text[start:], start)
+			break
+		}
+		end := offset + i
+		if mode == SeparatorSuffix {
+			end += len(used)
+		}
+		spans = appendFieldSpans(spans, text[start:end], start)
+
+		offset += i + len(used)
+		start = offset
+		if mode == SeparatorPrefix {
+			start -= len(used)
+		}
+	}
+
+	if n := len(breaks); n > 0 && breaks[n-1] == len(spans) {
+		breaks = breaks[:n-1]
+	}
+	return spans, breaks
+}
+
+// appendFieldSpans appends the spans of the whitespace-separated fields
+// of s, shifted by offset, to spans.
+func appendFieldSpans(spans [][2]int, s string, offset int) [][2]int {
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				spans = append(spans, [2]int{offset + start, offset + i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{offset + start, offset + len(s)})
+	}
+
+	return spans
+}
+
+// joinSpans builds chunk content from consecutive token spans of text.
+// When slice is set it returns the original substring covering the spans
+// without allocating; otherwise tokens are joined with single spaces.
+// Adjacent spans, such as characters, are joined without a space.
+func joinSpans(text string, spans [][2]int, slice bool) string {
+	if len(spans) == 0 {
+		return ""
+	}
+	if slice {
+		return text[spans[0][0]:spans[len(spans)-1][1]]
+	}
+
+	n := len(spans) - 1
+	for _, s := range spans {
+		n += s[1] - s[0]
+	}
+
+	var b strings.Builder
+	b.Grow(n)
+	for i, s := range spans {
+		if i > 0 && spans[i-1][1] != s[0] {
+			b.WriteByte(' ')
+		}
+		b.WriteString(text[s[0]:s[1]])
+	}
+
+	return b.String()
+}
+
+// splitParagraphs splits text on separator. When that yields a single
+// segment, each marker is tried in order until one splits the text.
+// It also returns the separator that produced the split.
+func splitParagraphs(text, separator string, markers []string) ([]string, string) {
+	used := paragraphSeparator(text, separator, markers)
+	return strings.Split(text, used), used
+}
+
+// paragraphSeparator returns separator if it occurs in text, otherwise the
+// first marker that does. A nil markers slice uses DefaultParagraphMarkers().
+// An empty marker selects character splitting when no other marker
+// occurs and text has no inner whitespace to split words on.
+func paragraphSeparator(text, separator string, markers []string) string {
+	if separator == "" || strings.Contains(text, separator) {
+		return separator
+	}
+
+	if markers == nil {
+		markers = DefaultParagraphMarkers()
+	}
+	var chars bool
+	for _, marker := range markers {
+		if marker == "" {
+			chars = true
+			continue
+		}
+		if strings.Contains(text, marker) {
+			return marker
+		}
+	}
+	if chars && strings.IndexFunc(strings.TrimSpace(text), unicode.IsSpace) < 0 {
+		return ""
+	}
+
+	return separator
+}
+
+// lineEndings converts "\r\n" and "\r" line endings to "\n".
+var lineEndings = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// normalizeLineEndings converts s to "\n" line endings.
+func normalizeLineEndings(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	return lineEndings.Replace(s)
+}
+
+// isWhitespace reports whether s is non-empty and consists only of whitespace.
+func isWhitespace(s string) bool {
+	return s != "" && strings.TrimSpace(s) == ""
+}
+
 // copyMetadata creates a copy of metadata map.
 func copyMetadata(m map[string]string) map[string]string {
 	if m == nil {
@@ -244,8 +1582,24 @@ func itoa(i int) string {
 	return string(buf[pos:])
 }
 
-// EstimateTokens estimates the number of tokens in a string.
-// Uses a simple heuristic: ~4 characters per token (average for English).
+// EstimateTokens estimates the number of tokens in a string with the
+// default tokenizer (see SetDefaultTokenizer). Without one it uses a
+// simple heuristic: ~4 characters per token (average for English).
 func EstimateTokens(s string) int {
+	return EstimateTokensWith(s, defaultTokenizer())
+}
+
+// EstimateTokensWith counts the tokens in s with t. A nil tokenizer
+// uses the ~4 characters per token heuristic.
+func EstimateTokensWith(s string, t Tokenizer) int {
+	if t == nil {
+		return estimateHeuristic(s)
+	}
+	return t.CountTokens(s)
+}
+
+// estimateHeuristic approximates BPE token counts at ~4 characters per
+// token.
+func estimateHeuristic(s string) int {
 	return utf8.RuneCountInString(s) / 4
 }
@@ -0,0 +1,158 @@
+package transform
+
+import "strings"
+
+// chunkBalanced splits a document into evenly sized chunks.
+func chunkBalanced(doc Document, opts ChunkOptions, stats *ChunkStats) []Document {
+	spans, breaks := chunkTokenLayout(doc.Content, opts)
+	if len(spans) <= opts.MaxTokens {
+		return []Document{doc}
+	}
+
+	var boundaries []int
+	if opts.PreserveBlankSegments {
+		boundaries = blankBoundaries(doc.Content, paragraphSeparator(doc.Content, opts.Separator, opts.ParagraphMarkers), spans)
+	}
+	ranges := capRanges(sectionedRanges(len(spans), breaks, boundaries, opts), len(spans), opts)
+	stats.addRanges(ranges)
+
+	var chunks []Document
+	for i, r := range ranges {
+		chunk := newChunk(doc, i, joinSpans(doc.Content, spans[r[0]:r[1]], opts.SliceContent))
+		if opts.RecordTokenRanges {
+			recordTokenRange(&chunk, r)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
+// sectionedRanges balances each section between consecutive boundaries
+// on its own, so no range crosses a boundary.
+func sectionedRanges(numTokens int, breaks, boundaries []int, opts ChunkOptions) [][2]int {
+	if len(boundaries) == 0 {
+		return balancedRanges(numTokens, breaks, opts)
+	}
+
+	var ranges [][2]int
+	start := 0
+	for _, end := range append(boundaries, numTokens) {
+		if end-start <= opts.MaxTokens {
+			ranges = append(ranges, [2]int{start, end})
+			start = end
+			continue
+		}
+
+		var local []int
+		for _, b := range breaks {
+			if b > start && b < end {
+				local = append(local, b-start)
+			}
+		}
+		for _, r := range balancedRanges(end-start, local, opts) {
+			ranges = append(ranges, [2]int{start + r[0], start + r[1]})
+		}
+		start = end
+	}
+	return ranges
+}
+
+// blankBoundaries returns the indices of the tokens that follow an empty
+// segment of text split on separator, i.e. a run of separators between
+// non-empty segments, in increasing order.
+func blankBoundaries(text, separator string, spans [][2]int) []int {
+	if separator == "" || len(spans) == 0 {
+		return nil
+	}
+
+	var boundaries []int
+	offset, next := 0, 0
+	for _, segment := range strings.Split(text, separator) {
+		end := offset + len(segment)
+		if strings.TrimSpace(segment) == "" {
+			// The boundary falls before the first token after the
+			// blank segment.
+			for next < len(spans) && spans[next][0] < end {
+				next++
+			}
+			if n := len(boundaries); next > 0 && next < len(spans) && (n == 0 || boundaries[n-1] != next) {
+				boundaries = append(boundaries, next)
+			}
+		}
+		offset = end + len(separator)
+	}
+	return boundaries
+}
+
+// balancedRanges partitions numTokens tokens into the fewest cores of at
+// most MaxTokens-Overlap tokens, sized as evenly as possible. Each core
+// boundary snaps to the nearest paragraph break within a quarter of the
+// core size when doing so keeps every core within its limit. Chunks after
+// the first are extended backwards by Overlap tokens, or by MaxTokens-1
+// when Overlap is not less than MaxTokens and cores hold a single token.
+func balancedRanges(numTokens int, breaks []int, opts ChunkOptions) [][2]int {
+	stride := opts.MaxTokens - opts.Overlap
+	if stride < 1 {
+		stride = 1
+	}
+	overlap := opts.MaxTokens - stride
+
+	count := (numTokens + stride - 1) / stride
+	tolerance := numTokens / count / 4
+
+	bounds := make([]int, 0, count+1)
+	bounds = append(bounds, 0)
+	for k := 1; k < count; k++ {
+		prev := bounds[len(bounds)-1]
+		target := k * numTokens / count
+
+		// The boundary must leave room for the remaining cores.
+		lo := numTokens - (count-k)*stride
+		if lo < prev+1 {
+			lo = prev + 1
+		}
+		hi := prev + stride
+
+		best := target
+		bestDist := tolerance + 1
+		for _, b := range breaks {
+			if b < lo || b > hi {
+				continue
+			}
+			if d := abs(b - target); d < bestDist {
+				best, bestDist = b, d
+			}
+		}
+		if best < lo {
+			best = lo
+		}
+		if best > hi {
+			best = hi
+		}
+		bounds = append(bounds, best)
+	}
+	bounds = append(bounds, numTokens)
+
+	ranges := make([][2]int, count)
+	for k := 0; k < count; k++ {
+		start := bounds[k]
+		if k > 0 {
+			start -= overlap
+			if start < 0 {
+				start = 0
+			}
+		}
+		ranges[k] = [2]int{start, bounds[k+1]}
+	}
+
+	return ranges
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,188 @@
+package transform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBalancedRanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		numTokens int
+		breaks    []int
+		opts      ChunkOptions
+		want      [][2]int
+	}{
+		{
+			name:      "even distribution",
+			numTokens: 22,
+			opts:      ChunkOptions{MaxTokens: 10},
+			want:      [][2]int{{0, 7}, {7, 14}, {14, 22}},
+		},
+		{
+			name:      "snaps to nearby break",
+			numTokens: 22,
+			breaks:    []int{6, 15},
+			opts:      ChunkOptions{MaxTokens: 10},
+			want:      [][2]int{{0, 6}, {6, 15}, {15, 22}},
+		},
+		{
+			name:      "ignores distant break",
+			numTokens: 22,
+			breaks:    []int{2},
+			opts:      ChunkOptions{MaxTokens: 10},
+			want:      [][2]int{{0, 7}, {7, 14}, {14, 22}},
+		},
+		{
+			name:      "overlap extends later chunks",
+			numTokens: 20,
+			opts:      ChunkOptions{MaxTokens: 10, Overlap: 2},
+			want:      [][2]int{{0, 6}, {4, 13}, {11, 20}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := balancedRanges(tt.numTokens, tt.breaks, tt.opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("range %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBalancedRangesRespectMaxTokens(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range []ChunkOptions{
+		{MaxTokens: 10, Overlap: 3},
+		{MaxTokens: 10, Overlap: 10},
+		{MaxTokens: 10, Overlap: 15},
+	} {
+		overlap := min(opts.Overlap, opts.MaxTokens-1)
+		for n := 11; n < 200; n += 7 {
+			breaks := []int{n / 3, n / 2, n - 3}
+
+			ranges := balancedRanges(n, breaks, opts)
+			if ranges[0][0] != 0 || ranges[len(ranges)-1][1] != n {
+				t.Errorf("overlap %d, n=%d: ranges %v do not span the document", opts.Overlap, n, ranges)
+			}
+
+			for i, r := range ranges {
+				if r[1]-r[0] > opts.MaxTokens {
+					t.Errorf("overlap %d, n=%d: range %d %v exceeds MaxTokens", opts.Overlap, n, i, r)
+				}
+				if i > 0 && r[0] != max(ranges[i-1][1]-overlap, 0) {
+					t.Errorf("overlap %d, n=%d: range %d %v does not overlap previous %v", opts.Overlap, n, i, r, ranges[i-1])
+				}
+			}
+		}
+	}
+}
+
+func TestChunkBalanced(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Join(numberedWords(6), " ") + "\n\n" + strings.Join(numberedWords(16)[6:], " ")
+	doc := Document{ID: "doc", Content: content, Source: "test"}
+
+	chunks := chunkDocument(doc, ChunkOptions{Strategy: StrategyBalanced, MaxTokens: 10, Separator: "\n\n"})
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	if got := chunks[0].Content; got != strings.Join(numberedWords(6), " ") {
+		t.Errorf("first chunk = %q, want it to end at the paragraph break", got)
+	}
+}
+
+func TestChunkBalancedBlankSegments(t *testing.T) {
+	t.Parallel()
+
+	words := numberedWords(12)
+	content := strings.Join(words[:3], " ") + "\n\n\n\n" + strings.Join(words[3:], " ")
+	doc := Document{ID: "doc", Content: content}
+
+	tests := []struct {
+		name     string
+		preserve bool
+		want     [][2]int
+	}{
+		{"collapsed", false, [][2]int{{0, 6}, {6, 12}}},
+		{"preserved", true, [][2]int{{0, 3}, {3, 7}, {7, 12}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			chunks := chunkDocument(doc, ChunkOptions{
+				Strategy: StrategyBalanced, MaxTokens: 8, Separator: "\n\n", PreserveBlankSegments: tt.preserve,
+			})
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.want))
+			}
+			for i, chunk := range chunks {
+				if want := strings.Join(words[tt.want[i][0]:tt.want[i][1]], " "); chunk.Content != want {
+					t.Errorf("chunk %d: Content = %q, want %q", i, chunk.Content, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBlankBoundaries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want []int
+	}{
+		{"single separator", "a b\n\nc", nil},
+		{"run", "a b\n\n\n\nc", []int{2}},
+		{"odd run", "a b\n\n\nc", nil},
+		{"whitespace segment", "a\n\n  \n\nb\n\n\n\n\n\nc", []int{1, 2}},
+		{"leading and trailing", "\n\n\n\na\n\n\n\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spans, _ := tokenLayout(tt.text, "\n\n", nil)
+			if got := blankBoundaries(tt.text, "\n\n", spans); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkRepeatedSeparatorsNoEmptyChunks(t *testing.T) {
+	t.Parallel()
+
+	content := "# Title\n\n\n\none two three\n\n\n\n\n\nfour five. six seven\n\n\n\neight"
+	strategies := []ChunkStrategy{StrategyTokens, StrategyBalanced, StrategyMarkdown, StrategySentenceWindow}
+	for _, strategy := range strategies {
+		for _, preserve := range []bool{false, true} {
+			out := mustChunk(t, ChunkInput{
+				Documents: []Document{{ID: "doc", Content: content}},
+				Options:   ChunkOptions{Strategy: strategy, MaxTokens: 3, Separator: "\n\n", PreserveBlankSegments: preserve},
+			})
+			for _, chunk := range out.Documents {
+				if strings.TrimSpace(chunk.Content) == "" {
+					t.Errorf("%s (preserve %v): empty chunk %s", strategy, preserve, chunk.ID)
+				}
+			}
+		}
+	}
+}
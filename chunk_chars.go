@@ -0,0 +1,110 @@
+package transform
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// chunkByChars splits a document into overlapping character windows.
+func chunkByChars(doc Document, opts ChunkOptions, stats *ChunkStats) []Document {
+	content := doc.Content
+	n := utf8.RuneCountInString(content)
+	if n <= opts.MaxTokens {
+		return []Document{doc}
+	}
+
+	// offsets[i] is the byte offset of rune i; space[i] reports whether
+	// rune i is whitespace.
+	offsets := make([]int, 0, n+1)
+	space := make([]bool, 0, n)
+	for i, r := range content {
+		offsets = append(offsets, i)
+		space = append(space, unicode.IsSpace(r))
+	}
+	offsets = append(offsets, len(content))
+
+	snap := opts.SnapToWords == nil || *opts.SnapToWords
+	ranges := capRanges(charBoundaries(space, opts.MaxTokens, opts.Overlap, snap), n, opts)
+	stats.addRanges(ranges)
+
+	trim := opts.TrimChunks == nil || *opts.TrimChunks
+	chunks := make([]Document, len(ranges))
+	for i, r := range ranges {
+		chunks[i] = newChunk(doc, i, content[offsets[r[0]]:offsets[r[1]]])
+		if opts.RecordTokenRanges {
+			// Split chunks are trimmed later, so record the range of
+			// the text that remains.
+			if trim {
+				for r[0] < r[1] && space[r[0]] {
+					r[0]++
+				}
+				for r[1] > r[0] && space[r[1]-1] {
+					r[1]--
+				}
+			}
+			recordCharRange(&chunks[i], r)
+		}
+	}
+	return chunks
+}
+
+// charBoundaries returns the [start, end) rune ranges of windows of at
+// most size runes advancing by size-overlap runes (at least one). With
+// snap, an end inside a word moves back to the whitespace before it, and
+// a start inside a word moves back to the word's start, or forward to the
+// next word if that would not advance the window. No text is lost.
+func charBoundaries(space []bool, size, overlap int, snap bool) [][2]int {
+	n := len(space)
+	if overlap < 0 {
+		overlap = 0
+	}
+	midWord := func(i int) bool {
+		return i > 0 && i < n && !space[i-1] && !space[i]
+	}
+
+	var ranges [][2]int
+	start, prevEnd := 0, 0
+	for {
+		end := min(start+size, n)
+		if snap && midWord(end) {
+			e := end
+			for e > start && !space[e-1] {
+				e--
+			}
+			if e > prevEnd && e > start {
+				end = e
+			}
+		}
+		ranges = append(ranges, [2]int{start, end})
+		if end == n {
+			return ranges
+		}
+
+		next := end - overlap
+		if next <= start {
+			next = start + 1
+		}
+		if snap && midWord(next) {
+			// Prefer keeping the whole word in the overlap; otherwise
+			// start at the next word.
+			s := next
+			for s > start && !space[s-1] {
+				s--
+			}
+			if s > start {
+				next = s
+			} else {
+				for next < end && !space[next] {
+					next++
+				}
+				for next < n && space[next] {
+					next++
+				}
+				if next == n {
+					return ranges
+				}
+			}
+		}
+		start, prevEnd = next, end
+	}
+}
@@ -0,0 +1,77 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkByChars(t *testing.T) {
+	t.Parallel()
+
+	const content = "the quick brown fox jumps over the lazy dog"
+	doc := Document{ID: "doc", Content: content}
+	noSnap := false
+
+	tests := []struct {
+		name string
+		opts ChunkOptions
+		want []string
+	}{
+		{
+			name: "snapped by default",
+			opts: ChunkOptions{Strategy: StrategyChars, MaxTokens: 12, Overlap: 4},
+			want: []string{"the quick", "quick brown", "brown fox", "fox jumps", "jumps over", "over the", "the lazy dog"},
+		},
+		{
+			name: "raw offsets",
+			opts: ChunkOptions{Strategy: StrategyChars, MaxTokens: 12, Overlap: 4, SnapToWords: &noSnap},
+			want: []string{"the quick br", "k brown fox", "fox jumps ov", "s over the l", "he lazy dog"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := mustChunk(t, ChunkInput{Documents: []Document{doc}, Options: tt.opts})
+			got := make([]string, out.Count)
+			for i, chunk := range out.Documents {
+				got[i] = chunk.Content
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharBoundariesCoverText(t *testing.T) {
+	t.Parallel()
+
+	text := "a supercalifragilistic word and   spaces  " + strings.Repeat("x", 30) + " end"
+	space := make([]bool, 0, len(text))
+	for _, r := range text {
+		space = append(space, r == ' ')
+	}
+
+	for size := 2; size <= 20; size++ {
+		for overlap := 0; overlap < size; overlap++ {
+			ranges := charBoundaries(space, size, overlap, true)
+			if ranges[0][0] != 0 || ranges[len(ranges)-1][1] != len(space) {
+				t.Fatalf("size %d overlap %d: ranges %v do not span the text", size, overlap, ranges)
+			}
+			for i, r := range ranges {
+				if r[1] <= r[0] || r[1]-r[0] > size {
+					t.Fatalf("size %d overlap %d: bad range %v", size, overlap, r)
+				}
+				if i > 0 {
+					for j := ranges[i-1][1]; j < r[0]; j++ {
+						if !space[j] {
+							t.Fatalf("size %d overlap %d: gap before %v skips text", size, overlap, r)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package transform
+
+import (
+	"sort"
+	"strings"
+)
+
+// Metadata keys and values describing chunk content.
+const (
+	// MetadataContentType holds the dominant structure of a chunk.
+	MetadataContentType = "content_type"
+
+	// ContentTypeList marks chunks made up mostly of list items.
+	ContentTypeList = "list"
+
+	// ContentTypeCode marks chunks holding a fenced code block.
+	ContentTypeCode = "code"
+)
+
+// listItems returns the byte ranges of list items in text. An item starts
+// at a line beginning with "-", "*" or "N." followed by a space, and
+// extends over directly following indented continuation lines.
+func listItems(text string) [][2]int {
+	var items [][2]int
+	open := false
+
+	for offset := 0; offset < len(text); {
+		end := strings.IndexByte(text[offset:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += offset
+		}
+		line := text[offset:end]
+
+		switch {
+		case isListItem(line):
+			items = append(items, [2]int{offset, end})
+			open = true
+		case open && strings.TrimSpace(line) != "" && (line[0] == ' ' || line[0] == '\t'):
+			items[len(items)-1][1] = end
+		default:
+			open = false
+		}
+
+		offset = end + 1
+	}
+
+	return items
+}
+
+// isListItem reports whether line starts a bulleted or numbered list item.
+func isListItem(line string) bool {
+	line = strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+		return true
+	}
+
+	digits := 0
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	return digits > 0 && strings.HasPrefix(line[digits:], ". ")
+}
+
+// itemTokenRanges maps byte ranges of list items to token index ranges
+// over spans. Items containing no tokens are dropped.
+func itemTokenRanges(spans [][2]int, items [][2]int) [][2]int {
+	ranges := make([][2]int, 0, len(items))
+	for _, item := range items {
+		lo := sort.Search(len(spans), func(i int) bool { return spans[i][0] >= item[0] })
+		hi := sort.Search(len(spans), func(i int) bool { return spans[i][1] > item[1] })
+		if lo < hi {
+			ranges = append(ranges, [2]int{lo, hi})
+		}
+	}
+	return ranges
+}
+
+// listBoundaries computes chunk ranges like chunkBoundaries but moves
+// boundaries that would fall inside a list item to the item's start, so
+// items are only split when a single item exceeds MaxTokens. Overlap that
+// would start mid-item is extended to the item start, or dropped when
+// that would not advance the window.
+func listBoundaries(numTokens int, items [][2]int, opts ChunkOptions) [][2]int {
+	if numTokens == 0 {
+		return nil
+	}
+
+	size := opts.MaxTokens
+	if size <= 0 || numTokens <= size {
+		return [][2]int{{0, numTokens}}
+	}
+	overlap := opts.Overlap
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap >= size {
+		overlap = size - 1
+	}
+
+	var ranges [][2]int
+	for start := 0; ; {
+		end := start + size
+		if end >= numTokens {
+			ranges = append(ranges, [2]int{start, numTokens})
+			return ranges
+		}
+		if item, ok := containingItem(items, end); ok && item[0] > start {
+			end = item[0]
+		}
+		ranges = append(ranges, [2]int{start, end})
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		if item, ok := containingItem(items, next); ok {
+			if item[0] > start {
+				next = item[0]
+			} else {
+				next = end
+			}
+		}
+		start = next
+	}
+}
+
+// containingItem returns the item that token boundary i falls strictly
+// inside of.
+func containingItem(items [][2]int, i int) ([2]int, bool) {
+	j := sort.Search(len(items), func(j int) bool { return items[j][1] > i })
+	if j < len(items) && items[j][0] < i {
+		return items[j], true
+	}
+	return [2]int{}, false
+}
+
+// listDominant reports whether more than half of the tokens in r fall
+// inside list items.
+func listDominant(r [2]int, items [][2]int) bool {
+	inList := 0
+	for _, item := range items {
+		lo, hi := max(item[0], r[0]), min(item[1], r[1])
+		if lo < hi {
+			inList += hi - lo
+		}
+	}
+	return 2*inList > r[1]-r[0]
+}
@@ -0,0 +1,112 @@
+package transform
+
+import (
+	"testing"
+)
+
+const listDoc = "Follow these steps to install.\n\n" +
+	"- download the installer package\n" +
+	"- run the setup wizard now\n" +
+	"- accept the license terms\n" +
+	"- restart the machine afterwards\n\n" +
+	"That is all."
+
+func TestListItems(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "bullets",
+			text: "intro\n- one\n* two\nafter",
+			want: []string{"- one", "* two"},
+		},
+		{
+			name: "numbered with continuation",
+			text: "1. first step\n   continued here\n2. second\n\n3.not an item",
+			want: []string{"1. first step\n   continued here", "2. second"},
+		},
+		{
+			name: "no lists",
+			text: "plain -text with 3.5 numbers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			items := listItems(tt.text)
+			if len(items) != len(tt.want) {
+				t.Fatalf("got %d items, want %d", len(items), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := tt.text[items[i][0]:items[i][1]]; got != want {
+					t.Errorf("items[%d] = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestListBoundariesKeepItemsWhole(t *testing.T) {
+	t.Parallel()
+
+	spans := tokenSpans(listDoc, "\n\n", nil)
+	items := itemTokenRanges(spans, listItems(listDoc))
+	if len(items) != 4 {
+		t.Fatalf("got %d items, want 4", len(items))
+	}
+
+	for _, overlap := range []int{0, 2, 9} {
+		ranges := listBoundaries(len(spans), items, ChunkOptions{MaxTokens: 10, Overlap: overlap})
+
+		if ranges[0][0] != 0 || ranges[len(ranges)-1][1] != len(spans) {
+			t.Errorf("overlap %d: ranges %v do not cover [0, %d)", overlap, ranges, len(spans))
+		}
+		for i, r := range ranges {
+			if i > 0 && r[0] <= ranges[i-1][0] {
+				t.Errorf("overlap %d: range %v does not advance past %v", overlap, r, ranges[i-1])
+			}
+			for _, bound := range r {
+				if item, ok := containingItem(items, bound); ok {
+					t.Errorf("overlap %d: boundary %d splits item %v", overlap, bound, item)
+				}
+			}
+		}
+	}
+}
+
+func TestChunkPreserveLists(t *testing.T) {
+	t.Parallel()
+
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{{ID: "doc", Content: listDoc}},
+		Options:   ChunkOptions{MaxTokens: 10, Separator: "\n\n", PreserveLists: true, SliceContent: true},
+	})
+
+	want := []struct {
+		content string
+		list    bool
+	}{
+		{"Follow these steps to install.\n\n- download the installer package", false},
+		{"- run the setup wizard now", true},
+		{"- accept the license terms\n- restart the machine afterwards", true},
+		{"That is all.", false},
+	}
+	if out.Count != len(want) {
+		t.Fatalf("got %d chunks, want %d", out.Count, len(want))
+	}
+	for i, w := range want {
+		chunk := out.Documents[i]
+		if chunk.Content != w.content {
+			t.Errorf("chunk %d content = %q, want %q", i, chunk.Content, w.content)
+		}
+		if got := chunk.Metadata[MetadataContentType] == ContentTypeList; got != w.list {
+			t.Errorf("chunk %d list = %v, want %v", i, got, w.list)
+		}
+	}
+}
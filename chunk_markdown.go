@@ -0,0 +1,309 @@
+package transform
+
+import (
+	"strings"
+)
+
+// MetadataDocumentTitle is the metadata key holding a chunk's parent title
+// when the chunk's Title is set to its section heading.
+const MetadataDocumentTitle = "document_title"
+
+// MetadataLanguage is the metadata key holding a code chunk's language
+// from its fence info string.
+const MetadataLanguage = "language"
+
+// markdownSection is a heading and the text up to the next heading.
+type markdownSection struct {
+	Heading string
+	Level   int
+	Content string
+}
+
+// markdownSegment is a run of prose or a fenced code block in a section.
+type markdownSegment struct {
+	Text string
+
+	// Code segments keep their fence lines and body separately so split
+	// pieces can be re-fenced.
+	Code     bool
+	Language string
+	Open     string
+	Body     string
+	Close    string
+}
+
+// chunkMarkdown splits a markdown document into chunks by section.
+func chunkMarkdown(doc Document, opts ChunkOptions, stats *ChunkStats) []Document {
+	if spans, _ := chunkTokenLayout(doc.Content, opts); len(spans) <= opts.MaxTokens {
+		return []Document{doc}
+	}
+
+	var chunks []Document
+	var offset int
+	for _, section := range splitMarkdownSections(doc.Content) {
+		segments := []markdownSegment{{Text: section.Content}}
+		if opts.AtomicCodeBlocks {
+			segments = splitCodeBlocks(section.Content)
+		}
+
+		var sectionChunks int
+		emit := func(content string, r [2]int, ranged bool) *Document {
+			prefixed := opts.HeadingPrefix && sectionChunks > 0 && section.Heading != ""
+			if prefixed {
+				heading := headingLine(section)
+				spans, _ := chunkTokenLayout(heading, opts)
+				stats.PrefixTokens += len(spans)
+				content = heading + content
+			}
+			chunk := newChunk(doc, len(chunks), content)
+			if section.Heading != "" {
+				chunk.Title = section.Heading
+			}
+			if doc.Title != "" {
+				if chunk.Metadata == nil {
+					chunk.Metadata = make(map[string]string, 1)
+				}
+				chunk.Metadata[MetadataDocumentTitle] = doc.Title
+			}
+			// A heading prefix is not part of the token range.
+			if opts.RecordTokenRanges && ranged && !prefixed {
+				recordTokenRange(&chunk, [2]int{offset + r[0], offset + r[1]})
+			}
+			chunks = append(chunks, chunk)
+			sectionChunks++
+			return &chunks[len(chunks)-1]
+		}
+
+		for _, seg := range segments {
+			spans, _ := chunkTokenLayout(seg.Text, opts)
+			if len(spans) == 0 {
+				continue
+			}
+
+			if seg.Code {
+				pieces := []string{seg.Text}
+				if len(spans) > opts.MaxTokens {
+					pieces = splitCodeBlock(seg, opts.MaxTokens)
+				}
+				stats.addRanges([][2]int{{0, len(spans)}})
+				for _, piece := range pieces {
+					// Split pieces repeat the fence lines.
+					chunk := emit(piece, [2]int{0, len(spans)}, len(pieces) == 1)
+					chunk.Metadata = withCodeMetadata(chunk.Metadata, seg.Language)
+				}
+				offset += len(spans)
+				continue
+			}
+
+			ranges := chunkBoundaries(len(spans), opts)
+			stats.addRanges(ranges)
+			for _, r := range ranges {
+				emit(joinSpans(seg.Text, spans[r[0]:r[1]], opts.SliceContent), r, true)
+			}
+			offset += len(spans)
+		}
+	}
+
+	if len(chunks) == 0 {
+		return []Document{doc}
+	}
+	return chunks
+}
+
+// withCodeMetadata marks a chunk's own metadata map as a code block in
+// language.
+func withCodeMetadata(metadata map[string]string, language string) map[string]string {
+	if metadata == nil {
+		metadata = make(map[string]string, 2)
+	}
+	metadata[MetadataContentType] = ContentTypeCode
+	if language != "" {
+		metadata[MetadataLanguage] = language
+	}
+	return metadata
+}
+
+// splitCodeBlocks splits section content into prose and fenced code
+// block segments. An unclosed fence extends to the end of the content.
+func splitCodeBlocks(content string) []markdownSegment {
+	var segments []markdownSegment
+	var prose, body strings.Builder
+	var code markdownSegment
+	fence := ""
+
+	flushProse := func() {
+		if prose.Len() > 0 {
+			segments = append(segments, markdownSegment{Text: prose.String()})
+			prose.Reset()
+		}
+	}
+	flushCode := func() {
+		code.Body = body.String()
+		code.Text = code.Open + code.Body + code.Close
+		segments = append(segments, code)
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence == "" {
+			if f := codeFence(trimmed); f != "" {
+				flushProse()
+				fence = f
+				code = markdownSegment{Code: true, Open: line, Language: fenceLanguage(trimmed, f)}
+				continue
+			}
+			prose.WriteString(line)
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, fence) && strings.TrimLeft(trimmed, fence[:1]) == "" {
+			code.Close = line
+			flushCode()
+			fence = ""
+			continue
+		}
+		body.WriteString(line)
+	}
+	if fence != "" {
+		flushCode()
+	}
+	flushProse()
+
+	return segments
+}
+
+// fenceLanguage returns the first word of a fence's info string.
+func fenceLanguage(line, fence string) string {
+	info := strings.Fields(strings.TrimLeft(line, fence[:1]))
+	if len(info) == 0 {
+		return ""
+	}
+	return info[0]
+}
+
+// splitCodeBlock splits a code block that exceeds maxTokens at line
+// boundaries into pieces that each fit, fence lines included, and
+// re-fences every piece. A single line longer than the budget forms its
+// own piece.
+func splitCodeBlock(seg markdownSegment, maxTokens int) []string {
+	budget := maxTokens - len(strings.Fields(seg.Open)) - len(strings.Fields(seg.Close))
+	if budget < 1 {
+		budget = 1
+	}
+
+	var pieces []string
+	var piece strings.Builder
+	var tokens int
+	flush := func() {
+		if piece.Len() == 0 {
+			return
+		}
+		text := piece.String()
+		if seg.Close != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		pieces = append(pieces, seg.Open+text+seg.Close)
+		piece.Reset()
+		tokens = 0
+	}
+
+	for _, line := range strings.SplitAfter(seg.Body, "\n") {
+		n := len(strings.Fields(line))
+		if tokens > 0 && tokens+n > budget {
+			flush()
+		}
+		piece.WriteString(line)
+		tokens += n
+	}
+	flush()
+
+	return pieces
+}
+
+// splitMarkdownSections splits content at ATX headings ("# Title") that
+// are not inside fenced code blocks. Text before the first heading forms
+// a section with an empty heading. Each section's content includes its
+// heading line.
+func splitMarkdownSections(content string) []markdownSection {
+	var sections []markdownSection
+	current := markdownSection{}
+	var body strings.Builder
+	fence := ""
+
+	flush := func() {
+		current.Content = body.String()
+		if strings.TrimSpace(current.Content) != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if f := codeFence(trimmed); f != "" {
+			switch {
+			case fence == "":
+				fence = f
+			case strings.HasPrefix(trimmed, fence) && strings.TrimLeft(trimmed, fence[:1]) == "":
+				fence = ""
+			}
+		}
+
+		if fence == "" {
+			if level, heading := parseHeading(line); level > 0 {
+				flush()
+				current = markdownSection{Heading: heading, Level: level}
+			}
+		}
+
+		body.WriteString(line)
+	}
+	flush()
+
+	return sections
+}
+
+// headingLine renders a section's heading as an ATX heading line.
+func headingLine(section markdownSection) string {
+	return strings.Repeat("#", section.Level) + " " + section.Heading + "\n"
+}
+
+// codeFence returns the fence marker if line opens or closes a fenced
+// code block.
+func codeFence(line string) string {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, marker) {
+			n := len(line) - len(strings.TrimLeft(line, marker[:1]))
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// parseHeading returns the level and text of an ATX heading line, or a
+// zero level if line is not a heading.
+func parseHeading(line string) (int, string) {
+	line = strings.TrimRight(line, "\r\n")
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 {
+		return 0, ""
+	}
+	line = strings.TrimLeft(line, " ")
+
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+
+	text := strings.TrimSpace(rest)
+	if closing := strings.TrimRight(text, "#"); closing != text && (closing == "" || strings.HasSuffix(closing, " ")) {
+		text = strings.TrimSpace(closing)
+	}
+	return level, text
+}
@@ -0,0 +1,131 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitMarkdownSections(t *testing.T) {
+	t.Parallel()
+
+	content := "Intro text.\n\n# Install\n\nRun it.\n\n```sh\n# not a heading\n```\n\n## Configure ##\n\nEdit config.\n#hashtag is not a heading\n"
+	sections := splitMarkdownSections(content)
+
+	want := []markdownSection{
+		{Heading: "", Level: 0},
+		{Heading: "Install", Level: 1},
+		{Heading: "Configure", Level: 2},
+	}
+	if len(sections) != len(want) {
+		t.Fatalf("got %d sections %+v, want %d", len(sections), sections, len(want))
+	}
+	for i, w := range want {
+		if sections[i].Heading != w.Heading || sections[i].Level != w.Level {
+			t.Errorf("section %d = (%q, %d), want (%q, %d)", i, sections[i].Heading, sections[i].Level, w.Heading, w.Level)
+		}
+	}
+	if !strings.Contains(sections[1].Content, "# not a heading") {
+		t.Error("fenced code block should stay in the Install section")
+	}
+
+	var joined strings.Builder
+	for _, s := range sections {
+		joined.WriteString(s.Content)
+	}
+	if joined.String() != content {
+		t.Error("sections do not reassemble into the original content")
+	}
+}
+
+func TestChunkMarkdownTitles(t *testing.T) {
+	t.Parallel()
+
+	content := "Preamble words here.\n\n# Setup\n\none two three four five six\n\n# Usage\n\nseven eight"
+	doc := Document{ID: "doc", Title: "Guide", Content: content, Source: "test"}
+
+	chunks := chunkDocument(doc, ChunkOptions{Strategy: StrategyMarkdown, MaxTokens: 5, Overlap: 1, Separator: "\n\n"})
+
+	wantTitles := []string{"Guide", "Setup", "Setup", "Usage"}
+	if len(chunks) != len(wantTitles) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(wantTitles))
+	}
+	for i, chunk := range chunks {
+		if chunk.Title != wantTitles[i] {
+			t.Errorf("chunk %d: Title = %q, want %q", i, chunk.Title, wantTitles[i])
+		}
+		if chunk.Metadata[MetadataDocumentTitle] != "Guide" {
+			t.Errorf("chunk %d: document_title = %q, want %q", i, chunk.Metadata[MetadataDocumentTitle], "Guide")
+		}
+		if chunk.ChunkIndex != i || chunk.ID != "doc#"+itoa(i) {
+			t.Errorf("chunk %d: ID = %q, ChunkIndex = %d", i, chunk.ID, chunk.ChunkIndex)
+		}
+	}
+}
+
+func TestChunkMarkdownAtomicCodeBlocks(t *testing.T) {
+	t.Parallel()
+
+	content := "# Usage\n\nCall the client before use.\n\n```go\nc := client.New()\nc.Run()\n```\n\nThen check the result.\n\n~~~\na b\nc d\ne f\n~~~\n"
+	doc := Document{ID: "doc", Content: content}
+
+	chunks := chunkDocument(doc, ChunkOptions{Strategy: StrategyMarkdown, MaxTokens: 7, Separator: "\n\n", AtomicCodeBlocks: true})
+
+	want := []struct{ content, contentType, language string }{
+		{"# Usage Call the client before use.", "", ""},
+		{"```go\nc := client.New()\nc.Run()\n```", ContentTypeCode, "go"},
+		{"Then check the result.", "", ""},
+		{"~~~\na b\nc d\n~~~", ContentTypeCode, ""},
+		{"~~~\ne f\n~~~", ContentTypeCode, ""},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks %q, want %d", len(chunks), chunks, len(want))
+	}
+	for i, w := range want {
+		got := chunks[i]
+		if strings.TrimSpace(got.Content) != w.content {
+			t.Errorf("chunk %d content = %q, want %q", i, got.Content, w.content)
+		}
+		if got.Metadata[MetadataContentType] != w.contentType || got.Metadata[MetadataLanguage] != w.language {
+			t.Errorf("chunk %d metadata = %v, want content type %q, language %q", i, got.Metadata, w.contentType, w.language)
+		}
+		if got.Title != "Usage" {
+			t.Errorf("chunk %d title = %q, want %q", i, got.Title, "Usage")
+		}
+	}
+}
+
+func TestChunkMarkdownHeadingPrefixOverlap(t *testing.T) {
+	t.Parallel()
+
+	words := numberedWords(12)
+	doc := Document{ID: "doc", Content: "# Setup\n\n" + strings.Join(words, " ")}
+	out := mustChunk(t, ChunkInput{Documents: []Document{doc}, Options: ChunkOptions{
+		Strategy: StrategyMarkdown, MaxTokens: 5, Overlap: 1, Separator: "\n\n", HeadingPrefix: true,
+	}})
+
+	// Windows and overlap are laid out on the body tokens, including the
+	// section's own heading line; the prefix is added afterwards.
+	want := []string{
+		"# Setup w0 w1 w2",
+		"# Setup\nw2 w3 w4 w5 w6",
+		"# Setup\nw6 w7 w8 w9 w10",
+		"# Setup\nw10 w11",
+	}
+	if out.Count != len(want) {
+		t.Fatalf("got %d chunks, want %d", out.Count, len(want))
+	}
+	for i, chunk := range out.Documents {
+		if chunk.Content != want[i] {
+			t.Errorf("chunk %d: Content = %q, want %q", i, chunk.Content, want[i])
+		}
+	}
+
+	stats := out.Stats
+	if stats.OverlapTokens != 3 || stats.PrefixTokens != 6 || stats.ChunkTokens != 17 {
+		t.Errorf("got overlap %d, prefix %d, chunk tokens %d; want 3, 6, 17",
+			stats.OverlapTokens, stats.PrefixTokens, stats.ChunkTokens)
+	}
+	if want := 9.0 / 23; stats.DuplicatedFraction != want {
+		t.Errorf("DuplicatedFraction = %v, want %v", stats.DuplicatedFraction, want)
+	}
+}
@@ -1,7 +1,11 @@
 package transform
 
 import (
+	"context"
+	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestChunkDocument(t *testing.T) {
@@ -76,6 +80,1008 @@ func TestChunkDocument(t *testing.T) {
 	}
 }
 
+func TestChunkActivityAlreadyChunked(t *testing.T) {
+	t.Parallel()
+
+	chunk := Document{
+		ID:         "doc#2",
+		Content:    "word1 word2 word3 word4 word5 word6 word7 word8 word9 word10 word11 word12",
+		Source:     "test",
+		ParentID:   "doc",
+		ChunkIndex: 2,
+	}
+
+	tests := []struct {
+		name       string
+		policy     AlreadyChunkedPolicy
+		wantErr    bool
+		wantChunks int
+		wantID     string
+	}{
+		{
+			name:       "default passes through",
+			wantChunks: 1,
+			wantID:     "doc#2",
+		},
+		{
+			name:       "passthrough",
+			policy:     AlreadyChunkedPassthrough,
+			wantChunks: 1,
+			wantID:     "doc#2",
+		},
+		{
+			name:    "error",
+			policy:  AlreadyChunkedError,
+			wantErr: true,
+		},
+		{
+			name:       "rechunk",
+			policy:     AlreadyChunkedRechunk,
+			wantChunks: 2,
+			wantID:     "doc#2#0",
+		},
+		{
+			name:    "unknown policy",
+			policy:  "bogus",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := ChunkActivity(context.Background(), ChunkInput{
+				Documents: []Document{chunk},
+				Options:   ChunkOptions{MaxTokens: 8, Overlap: 2, Separator: "\n\n", OnAlreadyChunked: tt.policy},
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if out.Count != tt.wantChunks {
+				t.Errorf("got %d chunks, want %d", out.Count, tt.wantChunks)
+			}
+			if out.Documents[0].ID != tt.wantID {
+				t.Errorf("first chunk ID = %q, want %q", out.Documents[0].ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestChunkActivityUnknownOverlapPlacement(t *testing.T) {
+	t.Parallel()
+
+	_, err := ChunkActivity(context.Background(), ChunkInput{
+		Documents: []Document{{ID: "doc", Content: "one two three"}},
+		Options:   ChunkOptions{MaxTokens: 8, OverlapPlacement: "center"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `unknown overlap placement: "center"`) {
+		t.Errorf("err = %v, want unknown overlap placement", err)
+	}
+}
+
+func TestChunkActivityUnknownAlreadyChunkedPolicy(t *testing.T) {
+	t.Parallel()
+
+	// The policy is validated even when no document is a chunk.
+	_, err := ChunkActivity(context.Background(), ChunkInput{
+		Documents: []Document{{ID: "doc", Content: "one two three"}},
+		Options:   ChunkOptions{MaxTokens: 8, OnAlreadyChunked: "bogus"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "already-chunked policy") {
+		t.Errorf("err = %v, want unknown already-chunked policy", err)
+	}
+}
+
+func TestChunkSkipMetadataKey(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Join(numberedWords(100), " ")
+	docs := []Document{
+		{ID: "atomic", Content: content, Metadata: map[string]string{MetadataNoChunk: "true"}},
+		{ID: "plain", Content: content, Metadata: map[string]string{MetadataNoChunk: "false"}},
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		wantChunks int
+	}{
+		{name: "disabled", key: "", wantChunks: 20},
+		{name: "flagged document kept whole", key: MetadataNoChunk, wantChunks: 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := mustChunk(t, ChunkInput{
+				Documents: docs,
+				Options:   ChunkOptions{MaxTokens: 10, Separator: "\n\n", SkipMetadataKey: tt.key},
+			})
+
+			if out.Count != tt.wantChunks {
+				t.Fatalf("got %d chunks, want %d", out.Count, tt.wantChunks)
+			}
+			if tt.key != "" {
+				if out.Documents[0].ID != "atomic" || out.Documents[0].Content != content {
+					t.Errorf("flagged document was modified: ID %q", out.Documents[0].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestChunkSourceField(t *testing.T) {
+	t.Parallel()
+
+	doc := Document{ID: "doc", Content: "summary", Metadata: map[string]string{"body": "one two three four", "lang": "en"}}
+
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{doc},
+		Options:   ChunkOptions{MaxTokens: 2, Separator: " ", SourceField: "body"},
+	})
+
+	want := []string{"one two", "three four"}
+	if out.Count != len(want) {
+		t.Fatalf("got %d chunks, want %d", out.Count, len(want))
+	}
+	for i, chunk := range out.Documents {
+		if chunk.Content != want[i] {
+			t.Errorf("chunk %d content = %q, want %q", i, chunk.Content, want[i])
+		}
+		if _, ok := chunk.Metadata["body"]; ok || chunk.Metadata["lang"] != "en" {
+			t.Errorf("chunk %d metadata = %v, want source field removed", i, chunk.Metadata)
+		}
+	}
+	if _, ok := doc.Metadata["body"]; !ok {
+		t.Error("input metadata was mutated")
+	}
+
+	_, err := ChunkActivity(context.Background(), ChunkInput{
+		Documents: []Document{{ID: "bare", Content: "summary"}},
+		Options:   ChunkOptions{MaxTokens: 2, SourceField: "body"},
+	})
+	if err == nil {
+		t.Error("expected error for document without the source field")
+	}
+}
+
+func TestChunkURLFragmentTemplate(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "doc", Content: "one two\nthree  four five six", URL: "https://example.com/a#top"},
+		{ID: "short", Content: "one", URL: "https://example.com/b"},
+	}
+
+	tests := []struct {
+		template string
+		want     []string
+	}{
+		{template: "", want: []string{"https://example.com/a#top", "https://example.com/a#top", "https://example.com/a#top"}},
+		{template: "chunk-{index}", want: []string{"https://example.com/a#chunk-0", "https://example.com/a#chunk-1", "https://example.com/a#chunk-2"}},
+		{template: "#char={offset}", want: []string{"https://example.com/a#char=0", "https://example.com/a#char=8", "https://example.com/a#char=20"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			t.Parallel()
+
+			out := mustChunk(t, ChunkInput{
+				Documents: docs,
+				Options:   ChunkOptions{MaxTokens: 2, Separator: " ", URLFragmentTemplate: tt.template},
+			})
+			if out.Count != len(tt.want)+1 {
+				t.Fatalf("got %d documents, want %d", out.Count, len(tt.want)+1)
+			}
+			for i, want := range tt.want {
+				if got := out.Documents[i].URL; got != want {
+					t.Errorf("chunk %d URL = %q, want %q", i, got, want)
+				}
+			}
+			if got := out.Documents[len(tt.want)].URL; got != "https://example.com/b" {
+				t.Errorf("unsplit document URL = %q, want unchanged", got)
+			}
+		})
+	}
+}
+
+func TestChunkKeepSeparator(t *testing.T) {
+	t.Parallel()
+
+	const content = "boot ok ::: disk full ::: net down"
+
+	tests := []struct {
+		mode       SeparatorMode
+		wantTokens string
+		wantBreaks []int
+	}{
+		{mode: "", wantTokens: "boot ok disk full net down", wantBreaks: []int{2, 4}},
+		{mode: SeparatorDrop, wantTokens: "boot ok disk full net down", wantBreaks: []int{2, 4}},
+		{mode: SeparatorPrefix, wantTokens: "boot ok ::: disk full ::: net down", wantBreaks: []int{2, 5}},
+		{mode: SeparatorSuffix, wantTokens: "boot ok ::: disk full ::: net down", wantBreaks: []int{3, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			t.Parallel()
+
+			spans, breaks := chunkTokenLayout(content, ChunkOptions{Separator: " ::: ", KeepSeparator: tt.mode})
+			if got := joinSpans(content, spans, false); got != tt.wantTokens {
+				t.Errorf("tokens = %q, want %q", got, tt.wantTokens)
+			}
+			if !reflect.DeepEqual(breaks, tt.wantBreaks) {
+				t.Errorf("breaks = %v, want %v", breaks, tt.wantBreaks)
+			}
+		})
+	}
+
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{{ID: "log", Content: content}},
+		Options:   ChunkOptions{Strategy: StrategyBalanced, MaxTokens: 3, Separator: " ::: ", KeepSeparator: SeparatorPrefix, SliceContent: true},
+	})
+	want := []string{"boot ok", "::: disk full", "::: net down"}
+	if out.Count != len(want) {
+		t.Fatalf("got %d chunks, want %d", out.Count, len(want))
+	}
+	for i := range want {
+		if out.Documents[i].Content != want[i] {
+			t.Errorf("chunk %d = %q, want %q", i, out.Documents[i].Content, want[i])
+		}
+	}
+
+	_, err := ChunkActivity(context.Background(), ChunkInput{
+		Documents: []Document{{ID: "log", Content: content}},
+		Options:   ChunkOptions{MaxTokens: 3, KeepSeparator: "both"},
+	})
+	if err == nil {
+		t.Error("expected error for unknown separator mode")
+	}
+}
+
+func TestChunkTrimChunks(t *testing.T) {
+	t.Parallel()
+
+	doc := Document{ID: "doc", Content: "  alpha beta gamma.  \n\n  delta epsilon. Zeta eta theta.  \n\n\tiota kappa  \n"}
+
+	tests := []struct {
+		name string
+		opts ChunkOptions
+	}{
+		{name: "tokens joined", opts: ChunkOptions{MaxTokens: 3, Separator: "\n\n"}},
+		{name: "tokens sliced", opts: ChunkOptions{MaxTokens: 3, Overlap: 1, Separator: "\n\n", SliceContent: true}},
+		{name: "balanced sliced", opts: ChunkOptions{Strategy: StrategyBalanced, MaxTokens: 4, Separator: "\n\n", SliceContent: true}},
+		{name: "sentence window", opts: ChunkOptions{Strategy: StrategySentenceWindow, MaxTokens: 3, Separator: "\n\n"}},
+		{name: "merge tail", opts: ChunkOptions{MaxTokens: 3, Separator: " ", MaxChunksPerDoc: 2, OnMaxChunks: MaxChunksMergeTail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := mustChunk(t, ChunkInput{Documents: []Document{doc}, Options: tt.opts})
+			if out.Count < 2 {
+				t.Fatalf("got %d chunks, want a split document", out.Count)
+			}
+			for i, chunk := range out.Documents {
+				if chunk.Content != strings.TrimSpace(chunk.Content) {
+					t.Errorf("chunk %d has edge whitespace: %q", i, chunk.Content)
+				}
+			}
+		})
+	}
+
+}
+
+func TestChunkMaxChunksPerDoc(t *testing.T) {
+	t.Parallel()
+
+	words := numberedWords(100)
+	doc := Document{ID: "doc", Content: strings.Join(words, " ")}
+
+	tests := []struct {
+		name     string
+		opts     ChunkOptions
+		wantErr  bool
+		wantLast string
+	}{
+		{
+			name:     "under cap",
+			opts:     ChunkOptions{MaxTokens: 10, Separator: "\n\n", MaxChunksPerDoc: 10},
+			wantLast: strings.Join(words[90:], " "),
+		},
+		{
+			name:    "error by default",
+			opts:    ChunkOptions{MaxTokens: 10, Separator: "\n\n", MaxChunksPerDoc: 3},
+			wantErr: true,
+		},
+		{
+			name:     "merge tail",
+			opts:     ChunkOptions{MaxTokens: 10, Overlap: 2, Separator: "\n\n", MaxChunksPerDoc: 3, OnMaxChunks: MaxChunksMergeTail},
+			wantLast: strings.Join(words[16:], " "),
+		},
+		{
+			name:     "merge tail balanced",
+			opts:     ChunkOptions{Strategy: StrategyBalanced, MaxTokens: 10, Separator: "\n\n", MaxChunksPerDoc: 3, OnMaxChunks: MaxChunksMergeTail},
+			wantLast: strings.Join(words[20:], " "),
+		},
+		{
+			name:    "unknown policy",
+			opts:    ChunkOptions{MaxTokens: 10, MaxChunksPerDoc: 3, OnMaxChunks: "truncate"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := ChunkActivity(context.Background(), ChunkInput{Documents: []Document{doc}, Options: tt.opts})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if out.Count > tt.opts.MaxChunksPerDoc {
+				t.Fatalf("got %d chunks, want at most %d", out.Count, tt.opts.MaxChunksPerDoc)
+			}
+			if last := out.Documents[out.Count-1].Content; last != tt.wantLast {
+				t.Errorf("last chunk = %q, want %q", last, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestMergeTailChunks(t *testing.T) {
+	t.Parallel()
+
+	chunks := []Document{{Content: "a"}, {Content: "b"}, {Content: "c"}, {Content: "d"}}
+	got := mergeTailChunks(chunks, 2, "\n\n")
+
+	if len(got) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(got))
+	}
+	if got[1].Content != "b\n\nc\n\nd" {
+		t.Errorf("merged content = %q, want %q", got[1].Content, "b\n\nc\n\nd")
+	}
+}
+
+func TestChunkActivityStats(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "long", Content: strings.Join(numberedWords(26), " ")},
+		{ID: "short", Content: "fits in one chunk"},
+	}
+
+	tests := []struct {
+		name        string
+		opts        ChunkOptions
+		wantChunks  int
+		wantTokens  int
+		wantOverlap int
+		wantAvg     float64
+	}{
+		{
+			name:        "requested overlap",
+			opts:        ChunkOptions{MaxTokens: 10, Overlap: 2, Separator: "\n\n"},
+			wantChunks:  3,
+			wantTokens:  30,
+			wantOverlap: 4,
+			wantAvg:     2,
+		},
+		{
+			name:        "no overlap",
+			opts:        ChunkOptions{MaxTokens: 10, Separator: "\n\n"},
+			wantChunks:  3,
+			wantTokens:  26,
+			wantOverlap: 0,
+			wantAvg:     0,
+		},
+		{
+			name:        "trailing placement",
+			opts:        ChunkOptions{MaxTokens: 10, Overlap: 2, OverlapPlacement: OverlapTrailing, Separator: "\n\n"},
+			wantChunks:  3,
+			wantTokens:  30,
+			wantOverlap: 4,
+			wantAvg:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stats := mustChunk(t, ChunkInput{Documents: docs, Options: tt.opts}).Stats
+
+			if stats.SplitDocuments != 1 {
+				t.Errorf("SplitDocuments = %d, want 1", stats.SplitDocuments)
+			}
+			if stats.Chunks != tt.wantChunks {
+				t.Errorf("Chunks = %d, want %d", stats.Chunks, tt.wantChunks)
+			}
+			if stats.ChunkTokens != tt.wantTokens {
+				t.Errorf("ChunkTokens = %d, want %d", stats.ChunkTokens, tt.wantTokens)
+			}
+			if stats.OverlapTokens != tt.wantOverlap {
+				t.Errorf("OverlapTokens = %d, want %d", stats.OverlapTokens, tt.wantOverlap)
+			}
+			if stats.AvgOverlapTokens != tt.wantAvg {
+				t.Errorf("AvgOverlapTokens = %v, want %v", stats.AvgOverlapTokens, tt.wantAvg)
+			}
+			if want := float64(tt.wantOverlap) / float64(tt.wantTokens); stats.DuplicatedFraction != want {
+				t.Errorf("DuplicatedFraction = %v, want %v", stats.DuplicatedFraction, want)
+			}
+		})
+	}
+}
+
+func TestChunkOverlapPlacement(t *testing.T) {
+	t.Parallel()
+
+	words := numberedWords(22)
+	doc := Document{ID: "doc", Content: strings.Join(words, " "), Source: "test"}
+
+	tests := []struct {
+		name      string
+		placement OverlapPlacement
+		want      [][2]int
+	}{
+		{
+			name: "default is leading",
+			want: [][2]int{{0, 10}, {8, 18}, {16, 22}},
+		},
+		{
+			name:      "leading",
+			placement: OverlapLeading,
+			want:      [][2]int{{0, 10}, {8, 18}, {16, 22}},
+		},
+		{
+			name:      "trailing",
+			placement: OverlapTrailing,
+			want:      [][2]int{{0, 6}, {4, 14}, {12, 22}},
+		},
+		{
+			name:      "symmetric",
+			placement: OverlapSymmetric,
+			want:      [][2]int{{0, 8}, {6, 16}, {14, 22}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := ChunkOptions{MaxTokens: 10, Overlap: 2, Separator: "\n\n", OverlapPlacement: tt.placement}
+			chunks := chunkDocument(doc, opts)
+
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.want))
+			}
+
+			covered := make(map[string]int)
+			for i, chunk := range chunks {
+				want := strings.Join(words[tt.want[i][0]:tt.want[i][1]], " ")
+				if chunk.Content != want {
+					t.Errorf("chunk %d: Content = %q, want %q", i, chunk.Content, want)
+				}
+				for _, w := range strings.Fields(chunk.Content) {
+					covered[w]++
+				}
+			}
+
+			var duplicated int
+			for _, w := range words {
+				switch covered[w] {
+				case 0:
+					t.Errorf("token %q not covered by any chunk", w)
+				case 1:
+				default:
+					duplicated++
+				}
+			}
+			if wantDup := opts.Overlap * (len(tt.want) - 1); duplicated != wantDup {
+				t.Errorf("got %d duplicated tokens, want %d", duplicated, wantDup)
+			}
+		})
+	}
+}
+
+func TestChunkActivityWarnings(t *testing.T) {
+	t.Parallel()
+
+	long := Document{ID: "doc", Content: strings.Join(numberedWords(20), " "), Source: "test"}
+
+	tests := []struct {
+		name         string
+		opts         ChunkOptions
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{
+			name: "valid options",
+			opts: ChunkOptions{MaxTokens: 100, Overlap: 10, Separator: "\n\n"},
+		},
+		{
+			name:         "defaults substituted",
+			opts:         ChunkOptions{},
+			wantWarnings: []string{"max tokens not set; using default chunk options"},
+		},
+		{
+			name: "overlap clamped and separator missing",
+			opts: ChunkOptions{MaxTokens: 5, Overlap: 5, Separator: "\n\n"},
+			wantWarnings: []string{
+				"overlap 5 is not less than max tokens 5; chunks advance by one token",
+				`separator "\n\n" not found in 1 of 1 split documents`,
+			},
+		},
+		{
+			name:    "negative overlap",
+			opts:    ChunkOptions{MaxTokens: 5, Overlap: -1},
+			wantErr: true,
+		},
+		{
+			name:    "required separator missing",
+			opts:    ChunkOptions{MaxTokens: 5, Overlap: 1, Separator: "\n\n", RequireSeparator: true},
+			wantErr: true,
+		},
+		{
+			name: "required separator ignored when document fits",
+			opts: ChunkOptions{MaxTokens: 100, Overlap: 10, Separator: "\n\n", RequireSeparator: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := ChunkActivity(context.Background(), ChunkInput{Documents: []Document{long}, Options: tt.opts})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(out.Warnings) != len(tt.wantWarnings) {
+				t.Fatalf("got warnings %q, want %q", out.Warnings, tt.wantWarnings)
+			}
+			for i, want := range tt.wantWarnings {
+				if out.Warnings[i] != want {
+					t.Errorf("Warnings[%d] = %q, want %q", i, out.Warnings[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveChunkOptionsKeepsSetFields(t *testing.T) {
+	t.Parallel()
+
+	opts, _, err := resolveChunkOptions(ChunkOptions{Overlap: 20, Strategy: StrategyBalanced})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defaults := DefaultChunkOptions()
+	if opts.Overlap != 20 {
+		t.Errorf("Overlap = %d, want 20", opts.Overlap)
+	}
+	if opts.Strategy != StrategyBalanced {
+		t.Errorf("Strategy = %q, want %q", opts.Strategy, StrategyBalanced)
+	}
+	if opts.MaxTokens != defaults.MaxTokens || opts.Separator != defaults.Separator {
+		t.Errorf("MaxTokens, Separator = %d, %q, want defaults %d, %q",
+			opts.MaxTokens, opts.Separator, defaults.MaxTokens, defaults.Separator)
+	}
+}
+
+// mustChunk runs ChunkActivity and fails the test on error.
+func mustChunk(t *testing.T, input ChunkInput) ChunkOutput {
+	t.Helper()
+
+	out, err := ChunkActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("ChunkActivity: %v", err)
+	}
+	return out
+}
+
+func TestChunkBoundaries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		numTokens int
+		opts      ChunkOptions
+		want      [][2]int
+	}{
+		{
+			name:      "empty",
+			numTokens: 0,
+			opts:      ChunkOptions{MaxTokens: 10},
+		},
+		{
+			name:      "fits in one chunk",
+			numTokens: 10,
+			opts:      ChunkOptions{MaxTokens: 10, Overlap: 2},
+			want:      [][2]int{{0, 10}},
+		},
+		{
+			name:      "one token over",
+			numTokens: 11,
+			opts:      ChunkOptions{MaxTokens: 10, Overlap: 2},
+			want:      [][2]int{{0, 10}, {8, 11}},
+		},
+		{
+			name:      "exact stride multiple",
+			numTokens: 26,
+			opts:      ChunkOptions{MaxTokens: 10, Overlap: 2},
+			want:      [][2]int{{0, 10}, {8, 18}, {16, 26}},
+		},
+		{
+			name:      "no overlap",
+			numTokens: 25,
+			opts:      ChunkOptions{MaxTokens: 10},
+			want:      [][2]int{{0, 10}, {10, 20}, {20, 25}},
+		},
+		{
+			name:      "overlap at max tokens advances by one",
+			numTokens: 12,
+			opts:      ChunkOptions{MaxTokens: 10, Overlap: 10},
+			want:      [][2]int{{0, 10}, {1, 11}, {2, 12}},
+		},
+		{
+			name:      "aggressive overlap ends on new tokens",
+			numTokens: 12,
+			opts:      ChunkOptions{MaxTokens: 10, Overlap: 8},
+			want:      [][2]int{{0, 10}, {2, 12}},
+		},
+		{
+			name:      "aggressive trailing overlap ends on new tokens",
+			numTokens: 12,
+			opts:      ChunkOptions{MaxTokens: 10, Overlap: 8, OverlapPlacement: OverlapTrailing},
+			want:      [][2]int{{0, 10}, {2, 12}},
+		},
+		{
+			name:      "negative overlap does not skip tokens",
+			numTokens: 15,
+			opts:      ChunkOptions{MaxTokens: 10, Overlap: -5},
+			want:      [][2]int{{0, 10}, {10, 15}},
+		},
+		{
+			name:      "unset max tokens",
+			numTokens: 15,
+			opts:      ChunkOptions{},
+			want:      [][2]int{{0, 15}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := chunkBoundaries(tt.numTokens, tt.opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("range %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChunkBoundariesInvariants(t *testing.T) {
+	t.Parallel()
+
+	placements := []OverlapPlacement{OverlapLeading, OverlapTrailing, OverlapSymmetric, OverlapTapered}
+	for _, placement := range placements {
+		for n := 1; n <= 60; n++ {
+			for maxTokens := 1; maxTokens <= 12; maxTokens++ {
+				for overlap := 0; overlap <= maxTokens; overlap++ {
+					opts := ChunkOptions{MaxTokens: maxTokens, Overlap: overlap, OverlapPlacement: placement}
+					ranges := chunkBoundaries(n, opts)
+
+					if ranges[0][0] != 0 || ranges[len(ranges)-1][1] != n {
+						t.Fatalf("%+v n=%d: %v does not span [0, %d)", opts, n, ranges, n)
+					}
+					for i, r := range ranges {
+						if r[1] <= r[0] || r[1]-r[0] > maxTokens {
+							t.Fatalf("%+v n=%d: range %d %v has invalid size", opts, n, i, r)
+						}
+						if i > 0 && (r[0] > ranges[i-1][1] || r[1] <= ranges[i-1][1]) {
+							t.Fatalf("%+v n=%d: range %d %v leaves a gap or adds nothing after %v", opts, n, i, r, ranges[i-1])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestChunkBoundariesTapered(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		numTokens int
+		overlap   int
+		want      [][2]int
+	}{
+		{"fits", 8, 2, [][2]int{{0, 8}}},
+		{"two chunks", 15, 2, [][2]int{{0, 10}, {10, 15}}},
+		{"three chunks", 22, 2, [][2]int{{0, 10}, {10, 20}, {20, 22}}},
+		{"middle overlap", 40, 2, [][2]int{{0, 10}, {10, 20}, {18, 28}, {26, 36}, {36, 40}}},
+		{"full overlap", 14, 10, [][2]int{{0, 10}, {10, 14}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := ChunkOptions{MaxTokens: 10, Overlap: tt.overlap, OverlapPlacement: OverlapTapered}
+			got := chunkBoundaries(tt.numTokens, opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			// The first and last chunks share no tokens with their
+			// neighbors.
+			if n := len(got); n > 1 && (got[1][0] != got[0][1] || got[n-1][0] != got[n-2][1]) {
+				t.Errorf("boundary chunks overlap: %v", got)
+			}
+		})
+	}
+}
+
+func TestDropOverlapOnly(t *testing.T) {
+	t.Parallel()
+
+	got := dropOverlapOnly([][2]int{{0, 10}, {2, 12}, {4, 12}, {6, 12}})
+	want := [][2]int{{0, 10}, {2, 12}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// numberedWords returns n distinct words w0..w(n-1).
+func numberedWords(n int) []string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = "w" + itoa(i)
+	}
+	return words
+}
+
+func TestSplitParagraphs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		text      string
+		separator string
+		markers   []string
+		want      []string
+	}{
+		{
+			name:      "primary separator",
+			text:      "one\n\ntwo\nmore",
+			separator: "\n\n",
+			want:      []string{"one", "two\nmore"},
+		},
+		{
+			name:      "falls back to single newline",
+			text:      "one\ntwo\nthree",
+			separator: "\n\n",
+			want:      []string{"one", "two", "three"},
+		},
+		{
+			name:      "custom markers tried in order",
+			text:      "one; two | three",
+			separator: "\n\n",
+			markers:   []string{"\n", " | "},
+			want:      []string{"one; two", "three"},
+		},
+		{
+			name:      "fallback disabled",
+			text:      "one\ntwo",
+			separator: "\n\n",
+			markers:   []string{},
+			want:      []string{"one\ntwo"},
+		},
+		{
+			name:      "no marker matches",
+			text:      "one two",
+			separator: "\n\n",
+			want:      []string{"one two"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, _ := splitParagraphs(tt.text, tt.separator, tt.markers)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d segments %q, want %d %q", len(got), got, len(tt.want), tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("segment %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChunkSliceContent(t *testing.T) {
+	t.Parallel()
+
+	content := "alpha  beta\tgamma\n\ndelta epsilon\nzeta eta theta"
+	doc := Document{ID: "doc", Content: content, Source: "test"}
+	opts := ChunkOptions{MaxTokens: 4, Overlap: 1, Separator: "\n\n"}
+
+	joined := chunkDocument(doc, opts)
+	opts.SliceContent = true
+	sliced := chunkDocument(doc, opts)
+
+	if len(sliced) != len(joined) {
+		t.Fatalf("got %d sliced chunks, want %d", len(sliced), len(joined))
+	}
+
+	wantSliced := []string{"alpha  beta\tgamma\n\ndelta", "delta epsilon\nzeta eta", "eta theta"}
+	for i, chunk := range sliced {
+		if chunk.Content != wantSliced[i] {
+			t.Errorf("sliced chunk %d = %q, want %q", i, chunk.Content, wantSliced[i])
+		}
+		if got := strings.Join(strings.Fields(chunk.Content), " "); got != joined[i].Content {
+			t.Errorf("sliced chunk %d has tokens %q, want %q", i, got, joined[i].Content)
+		}
+	}
+}
+
+func TestTokenizeMatchesFields(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"",
+		"   ",
+		"one",
+		"  leading and trailing  ",
+		"para one\n\npara\ttwo\n\n\n\nthree",
+		"unicode\u00a0space and ünïcödé words",
+	}
+
+	for _, in := range inputs {
+		got := tokenize(in, "\n\n", nil)
+		want := strings.Fields(in)
+		if len(got) != len(want) {
+			t.Errorf("tokenize(%q) = %q, want %q", in, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("tokenize(%q)[%d] = %q, want %q", in, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestTokenizeEmptyMarker(t *testing.T) {
+	t.Parallel()
+
+	markers := []string{"\n", ""}
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "no whitespace splits runes", text: "東京都庁", want: []string{"東", "京", "都", "庁"}},
+		{name: "words keep word tokens", text: "one two", want: []string{"one", "two"}},
+		{name: "earlier marker wins", text: "東京\n都庁", want: []string{"東京", "都庁"}},
+		{name: "invalid bytes stay whole", text: "a\xffé", want: []string{"a", "\xff", "é"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tokenize(tt.text, "\n\n", markers)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	if got := tokenize("東京都庁", "\n\n", []string{"\n"}); len(got) != 1 {
+		t.Errorf("without an empty marker got %q, want a single token", got)
+	}
+}
+
+func TestChunkEmptyMarkerLargeInput(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("字", 200_000)
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{{ID: "doc", Content: content}},
+		Options:   ChunkOptions{MaxTokens: 1000, ParagraphMarkers: []string{""}},
+	})
+	if out.Count != 200 {
+		t.Fatalf("got %d chunks, want 200", out.Count)
+	}
+	if got := utf8.RuneCountInString(out.Documents[0].Content); got != 1000 {
+		t.Errorf("first chunk has %d characters, want 1000", got)
+	}
+}
+
+func BenchmarkChunkDocument(b *testing.B) {
+	sizes := []struct {
+		name       string
+		paragraphs int
+	}{
+		{name: "small", paragraphs: 10},
+		{name: "medium", paragraphs: 1000},
+		{name: "large", paragraphs: 50000},
+	}
+
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			doc := Document{ID: size.name, Content: strings.Repeat("lorem ipsum dolor sit amet\n\n", size.paragraphs), Source: "bench"}
+			opts := DefaultChunkOptions()
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(doc.Content)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_ = chunkDocument(doc, opts)
+			}
+		})
+	}
+}
+
+func BenchmarkChunkDocumentJoin(b *testing.B) {
+	benchmarkChunkLargeDocument(b, false)
+}
+
+func BenchmarkChunkDocumentSliceContent(b *testing.B) {
+	benchmarkChunkLargeDocument(b, true)
+}
+
+func benchmarkChunkLargeDocument(b *testing.B, slice bool) {
+	doc := Document{ID: "large", Content: strings.Repeat("lorem ipsum dolor sit amet\n\n", 50000), Source: "bench"}
+	opts := DefaultChunkOptions()
+	opts.SliceContent = slice
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(doc.Content)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = chunkDocument(doc, opts)
+	}
+}
+
 func TestMergeDocuments(t *testing.T) {
 	t.Parallel()
 
@@ -121,6 +1127,77 @@ func TestDefaultChunkOptions(t *testing.T) {
 	}
 }
 
+func TestChunkOptionsMerge(t *testing.T) {
+	t.Parallel()
+
+	base := ChunkOptions{MaxTokens: 512, Overlap: 50, Separator: "\n\n", SliceContent: true, ParagraphMarkers: []string{"\n"}}
+
+	tests := []struct {
+		name     string
+		override ChunkOptions
+		want     ChunkOptions
+	}{
+		{
+			name:     "zero override inherits",
+			override: ChunkOptions{},
+			want:     base,
+		},
+		{
+			name:     "non-zero fields win",
+			override: ChunkOptions{MaxTokens: 128, Strategy: StrategyBalanced},
+			want:     ChunkOptions{MaxTokens: 128, Overlap: 50, Separator: "\n\n", SliceContent: true, ParagraphMarkers: []string{"\n"}, Strategy: StrategyBalanced},
+		},
+		{
+			name:     "empty paragraph markers disable fallback",
+			override: ChunkOptions{ParagraphMarkers: []string{}},
+			want:     ChunkOptions{MaxTokens: 512, Overlap: 50, Separator: "\n\n", SliceContent: true, ParagraphMarkers: []string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := base.Merge(tt.override); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkOptionsMergeCoversAllFields(t *testing.T) {
+	t.Parallel()
+
+	var override ChunkOptions
+	v := reflect.ValueOf(&override).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString("x")
+		case reflect.Int:
+			field.SetInt(7)
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Slice:
+			field.Set(reflect.MakeSlice(field.Type(), 1, 1))
+		case reflect.Pointer:
+			field.Set(reflect.New(field.Type().Elem()))
+		case reflect.Struct:
+			if field.Type() != reflect.TypeOf(Selector{}) {
+				t.Fatalf("unhandled struct field %s", v.Type().Field(i).Name)
+			}
+			field.Set(reflect.ValueOf(Selector{Sources: []string{"x"}}))
+		default:
+			t.Fatalf("unhandled field %s of kind %s", v.Type().Field(i).Name, field.Kind())
+		}
+	}
+
+	if got := (ChunkOptions{}).Merge(override); !reflect.DeepEqual(got, override) {
+		t.Errorf("Merge() = %+v, want every field of %+v", got, override)
+	}
+}
+
 func TestEstimateTokens(t *testing.T) {
 	t.Parallel()
 
@@ -211,3 +1288,368 @@ func TestCopyMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestChunkDryRun(t *testing.T) {
+	t.Parallel()
+
+	input := ChunkInput{
+		Documents: []Document{{ID: "doc", Content: "one two three four five"}},
+		Options:   ChunkOptions{MaxTokens: 2, Separator: " "},
+	}
+	full := mustChunk(t, input)
+
+	input.Options.DryRun = true
+	dry := mustChunk(t, input)
+	if dry.Count != full.Count {
+		t.Errorf("dry run Count = %d, want %d", dry.Count, full.Count)
+	}
+	if dry.Documents == nil || len(dry.Documents) != 0 {
+		t.Errorf("dry run Documents = %v, want empty", dry.Documents)
+	}
+	if dry.Stats != full.Stats {
+		t.Errorf("dry run Stats = %+v, want %+v", dry.Stats, full.Stats)
+	}
+}
+
+func TestChunkMetadataOverrides(t *testing.T) {
+	t.Parallel()
+
+	const content = "one two three four five six"
+	docs := []Document{
+		{ID: "default", Content: content},
+		{ID: "small", Content: content, Metadata: map[string]string{MetadataMaxTokens: "2"}},
+		{ID: "bad", Content: content, Metadata: map[string]string{MetadataMaxTokens: "many"}},
+		{ID: "bad-strategy", Content: content, Metadata: map[string]string{MetadataMaxTokens: "2", MetadataStrategy: "nope"}},
+	}
+
+	out := mustChunk(t, ChunkInput{
+		Documents: docs,
+		Options:   ChunkOptions{MaxTokens: 3, Separator: " ", AllowMetadataOverrides: true},
+	})
+
+	counts := make(map[string]int)
+	for _, chunk := range out.Documents {
+		counts[chunk.ParentID]++
+	}
+	want := map[string]int{"default": 2, "small": 3, "bad": 2, "bad-strategy": 2}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("chunks per document = %v, want %v", counts, want)
+	}
+
+	var overrideWarnings int
+	for _, w := range out.Warnings {
+		if strings.Contains(w, "invalid chunk override") {
+			overrideWarnings++
+		}
+	}
+	if overrideWarnings != 2 {
+		t.Errorf("got %d override warnings, want 2: %q", overrideWarnings, out.Warnings)
+	}
+
+	ignored := mustChunk(t, ChunkInput{
+		Documents: docs[1:2],
+		Options:   ChunkOptions{MaxTokens: 3, Separator: " "},
+	})
+	if ignored.Count != 2 {
+		t.Errorf("without AllowMetadataOverrides got %d chunks, want 2", ignored.Count)
+	}
+}
+
+func TestChunkCRLFContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "windows", content: "first para one\r\n\r\nsecond para two\r\n\r\nthird para three"},
+		{name: "old mac", content: "first para one\r\rsecond para two\r\rthird para three"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := mustChunk(t, ChunkInput{
+				Documents: []Document{{ID: "doc", Content: tt.content}},
+				Options:   ChunkOptions{MaxTokens: 6, Separator: "\n\n", ParagraphMarkers: []string{}, Strategy: StrategyBalanced},
+			})
+
+			want := []string{"first para one", "second para two third para three"}
+			if out.Count != len(want) {
+				t.Fatalf("got %d chunks %q, want %d", out.Count, out.Documents, len(want))
+			}
+			for i := range want {
+				if out.Documents[i].Content != want[i] {
+					t.Errorf("chunk %d = %q, want %q", i, out.Documents[i].Content, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChunkCRLFUnsplitDocuments(t *testing.T) {
+	t.Parallel()
+
+	long := "first para one\r\n\r\nsecond para two\r\n\r\nthird para three"
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{
+			{ID: "short", Content: "a\r\nb"},
+			{ID: "skip", Content: long, Metadata: map[string]string{MetadataNoChunk: "true"}},
+			{ID: "long", Content: long},
+		},
+		Options: ChunkOptions{MaxTokens: 6, Separator: "\n\n", SkipMetadataKey: MetadataNoChunk, EmitParent: true},
+	})
+
+	// Documents returned whole keep their line endings; only chunks,
+	// whose text was split, are normalized.
+	if out.Documents[0].Content != "a\r\nb" {
+		t.Errorf("short document = %q, want %q", out.Documents[0].Content, "a\r\nb")
+	}
+	if out.Documents[1].Content != long {
+		t.Errorf("skipped document = %q, want %q", out.Documents[1].Content, long)
+	}
+	if out.Documents[2].Content != long {
+		t.Errorf("parent document = %q, want %q", out.Documents[2].Content, long)
+	}
+	if out.Count < 4 {
+		t.Fatalf("got %d documents, want long split into chunks", out.Count)
+	}
+	for _, chunk := range out.Documents[3:] {
+		if strings.Contains(chunk.Content, "\r") {
+			t.Errorf("chunk %s = %q, want normalized line endings", chunk.ID, chunk.Content)
+		}
+	}
+}
+
+func TestChunkCJKTokens(t *testing.T) {
+	t.Parallel()
+
+	content := "東京は日本の首都です。Tokyo is large。大阪も大きい都市です。"
+
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{{ID: "doc", Content: content}},
+		Options:   ChunkOptions{MaxTokens: 8, Separator: " ", CJKTokens: true},
+	})
+	if out.Count < 2 {
+		t.Fatalf("got %d chunks, want CJK content split into several", out.Count)
+	}
+
+	var rebuilt strings.Builder
+	for _, chunk := range out.Documents {
+		if n := len(chunkTokenLayoutSpans(chunk.Content)); n > 8 {
+			t.Errorf("chunk %q has %d tokens, want at most 8", chunk.Content, n)
+		}
+		rebuilt.WriteString(chunk.Content)
+	}
+	if got, want := strings.ReplaceAll(rebuilt.String(), " ", ""), strings.ReplaceAll(content, " ", ""); got != want {
+		t.Errorf("chunks %q lose content", out.Documents)
+	}
+
+	whole := mustChunk(t, ChunkInput{
+		Documents: []Document{{ID: "doc", Content: content}},
+		Options:   ChunkOptions{MaxTokens: 8, Separator: " "},
+	})
+	if whole.Count != 1 {
+		t.Errorf("without CJKTokens got %d chunks, want 1", whole.Count)
+	}
+}
+
+// chunkTokenLayoutSpans returns the CJK-aware token spans of text.
+func chunkTokenLayoutSpans(text string) [][2]int {
+	spans, _ := chunkTokenLayout(text, ChunkOptions{Separator: " ", CJKTokens: true})
+	return spans
+}
+
+func TestSplitCJKSpans(t *testing.T) {
+	t.Parallel()
+
+	text := "ab東京\n\n大cd"
+	spans, breaks := tokenLayout(text, "\n\n", nil)
+	gotSpans, gotBreaks := splitCJKSpans(text, spans, breaks)
+
+	var tokens []string
+	for _, s := range gotSpans {
+		tokens = append(tokens, text[s[0]:s[1]])
+	}
+	if want := []string{"ab", "東", "京", "大", "cd"}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("tokens = %q, want %q", tokens, want)
+	}
+	if want := []int{3}; !reflect.DeepEqual(gotBreaks, want) {
+		t.Errorf("breaks = %v, want %v", gotBreaks, want)
+	}
+}
+
+func TestChunkEmitParent(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "long", Content: "one two three four five", Metadata: map[string]string{"k": "v"}},
+		{ID: "short", Content: "one two"},
+	}
+	out := mustChunk(t, ChunkInput{Documents: docs, Options: ChunkOptions{MaxTokens: 2, Separator: " ", EmitParent: true}})
+
+	var ids []string
+	for _, doc := range out.Documents {
+		ids = append(ids, doc.ID)
+	}
+	want := []string{"long", "long#0", "long#1", "long#2", "short"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got IDs %q, want %q", ids, want)
+	}
+	if out.Count != len(want) {
+		t.Errorf("Count = %d, want %d", out.Count, len(want))
+	}
+
+	parent := out.Documents[0]
+	if parent.Content != docs[0].Content || parent.IsChunk() || parent.Metadata[MetadataIsParent] != "true" || parent.Metadata["k"] != "v" {
+		t.Errorf("got parent %+v, want the flagged original", parent)
+	}
+	for _, doc := range out.Documents[1:] {
+		if _, ok := doc.Metadata[MetadataIsParent]; ok {
+			t.Errorf("document %s is flagged as a parent", doc.ID)
+		}
+	}
+	if _, ok := docs[0].Metadata[MetadataIsParent]; ok {
+		t.Error("input metadata was modified")
+	}
+}
+
+func TestChunkOrder(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "a", Content: "a0 a1 a2"},
+		{ID: "b", Content: "b0"},
+		{ID: "c", Content: "c0 c1"},
+	}
+	tests := []struct {
+		name  string
+		order ChunkOrder
+		want  []string
+	}{
+		{"default", "", []string{"a#0", "a#1", "a#2", "b", "c#0", "c#1"}},
+		{"sequential", OrderSequential, []string{"a#0", "a#1", "a#2", "b", "c#0", "c#1"}},
+		{"interleaved", OrderInterleaved, []string{"a#0", "b", "c#0", "a#1", "c#1", "a#2"}},
+		{"reverse", OrderReverse, []string{"c#1", "c#0", "b", "a#2", "a#1", "a#0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := mustChunk(t, ChunkInput{Documents: docs, Options: ChunkOptions{MaxTokens: 1, Separator: " ", Order: tt.order}})
+			var ids []string
+			for _, doc := range out.Documents {
+				ids = append(ids, doc.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("got %q, want %q", ids, tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+		if _, err := ChunkActivity(context.Background(), ChunkInput{Documents: docs, Options: ChunkOptions{MaxTokens: 1, Order: "random"}}); err == nil {
+			t.Error("expected error for unknown order")
+		}
+	})
+}
+
+func TestChunkOrderInterleavedParents(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "a", Content: "a0 a1"}, {ID: "b", Content: "b0 b1"}}
+	out := mustChunk(t, ChunkInput{Documents: docs, Options: ChunkOptions{
+		MaxTokens: 1, Separator: " ", EmitParent: true, Order: OrderInterleaved,
+	}})
+
+	var ids []string
+	for _, doc := range out.Documents {
+		ids = append(ids, doc.ID)
+	}
+	if want := []string{"a", "b", "a#0", "b#0", "a#1", "b#1"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %q, want %q", ids, want)
+	}
+}
+
+func TestChunkMaxContentBytes(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "small", Content: "one two"},
+		{ID: "huge", Content: strings.Repeat("x ", 100)},
+	}
+
+	_, err := ChunkActivity(context.Background(), ChunkInput{Documents: docs, Options: ChunkOptions{MaxTokens: 10, MaxContentBytes: 50}})
+	if err == nil || !strings.Contains(err.Error(), "document huge content is 200 bytes, exceeding the limit of 50") {
+		t.Fatalf("err = %v, want an over-limit error", err)
+	}
+
+	out := mustChunk(t, ChunkInput{Documents: docs, Options: ChunkOptions{MaxTokens: 10, MaxContentBytes: 50, ContinueOnError: true}})
+	if out.Count != 1 || out.Documents[0].ID != "small" {
+		t.Errorf("got %d documents, want only small", out.Count)
+	}
+	var warned bool
+	for _, w := range out.Warnings {
+		warned = warned || strings.Contains(w, "document huge") && strings.Contains(w, "skipped")
+	}
+	if !warned {
+		t.Errorf("Warnings = %q, want a skip warning for huge", out.Warnings)
+	}
+
+	report := AnalyzeCorpus(docs, ChunkOptions{MaxTokens: 10, Separator: " ", MaxContentBytes: 50, ContinueOnError: true})
+	if report.Chunks != out.Count || report.Tokens.Total != 2 {
+		t.Errorf("AnalyzeCorpus got Chunks=%d Tokens.Total=%d, want %d and 2", report.Chunks, report.Tokens.Total, out.Count)
+	}
+}
+
+func TestChunkAnchoredAppend(t *testing.T) {
+	t.Parallel()
+
+	opts := ChunkOptions{MaxTokens: 10, Overlap: 2, Separator: "\n\n", Anchored: true}
+	words := numberedWords(40)
+	chunk := func(n int) []Document {
+		doc := Document{ID: "doc", Content: strings.Join(words[:n], " ")}
+		return mustChunk(t, ChunkInput{Documents: []Document{doc}, Options: opts}).Documents
+	}
+
+	// A document that fits in one chunk is still chunk 0.
+	short := chunk(6)
+	if len(short) != 1 || short[0].ID != "doc#0" || short[0].Content != strings.Join(words[:6], " ") {
+		t.Fatalf("short document = %+v, want a single doc#0 chunk", short)
+	}
+
+	before, after := chunk(21), chunk(40)
+	if len(before) != 3 || len(after) != 5 {
+		t.Fatalf("got %d and %d chunks, want 3 and 5", len(before), len(after))
+	}
+	for i, old := range before {
+		got := after[i]
+		if got.ID != old.ID || got.ChunkIndex != old.ChunkIndex {
+			t.Errorf("chunk %d ID %s changed to %s", i, old.ID, got.ID)
+		}
+		// The old last chunk grows; the others are unchanged.
+		if i < len(before)-1 && got.Content != old.Content {
+			t.Errorf("chunk %d content %q changed to %q", i, old.Content, got.Content)
+		}
+	}
+	if got, want := after[2].Content, strings.Join(words[16:26], " "); got != want {
+		t.Errorf("grown chunk = %q, want %q", got, want)
+	}
+}
+
+func TestChunkAnchoredValidation(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range []ChunkOptions{
+		{MaxTokens: 10, Anchored: true, Strategy: StrategyBalanced},
+		{MaxTokens: 10, Anchored: true, OverlapPlacement: OverlapSymmetric},
+		{MaxTokens: 10, Anchored: true, PreserveLists: true},
+	} {
+		if _, err := ChunkActivity(context.Background(), ChunkInput{Options: opts}); err == nil {
+			t.Errorf("options %+v: expected error", opts)
+		}
+	}
+}
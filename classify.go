@@ -0,0 +1,222 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// Metadata keys set by ClassifyActivity.
+const (
+	MetadataCategory           = "category"
+	MetadataCategoryConfidence = "category_confidence"
+)
+
+// CategoryUnknown is the label stored for classifications whose confidence
+// is below ClassifyOptions.Threshold.
+const CategoryUnknown = "unknown"
+
+// Classifier assigns a document a category label with a confidence in
+// [0, 1].
+type Classifier interface {
+	Classify(ctx context.Context, doc Document) (string, float32, error)
+}
+
+// Classification is a category label and its confidence.
+type Classification struct {
+	Label      string
+	Confidence float32
+}
+
+// BatchClassifier is a Classifier that can classify several documents in
+// one call. ClassifyActivity uses ClassifyBatch when available. It
+// returns one Classification per document, in order.
+type BatchClassifier interface {
+	Classifier
+	ClassifyBatch(ctx context.Context, docs []Document) ([]Classification, error)
+}
+
+var classifiers = struct {
+	mu          sync.RWMutex
+	classifiers map[string]Classifier
+}{
+	classifiers: make(map[string]Classifier),
+}
+
+// RegisterClassifier registers a classifier under name, replacing any
+// existing registration, so nodes can reference it by name. Classifiers
+// may be used concurrently. Register classifiers on every worker before
+// starting it.
+func RegisterClassifier(name string, c Classifier) {
+	classifiers.mu.Lock()
+	defer classifiers.mu.Unlock()
+	classifiers.classifiers[name] = c
+}
+
+// RegisteredClassifiers returns the names of all registered classifiers.
+func RegisteredClassifiers() []string {
+	classifiers.mu.RLock()
+	defer classifiers.mu.RUnlock()
+	return sortedKeys(classifiers.classifiers)
+}
+
+// lookupClassifier returns the classifier registered under name.
+func lookupClassifier(name string) (Classifier, error) {
+	classifiers.mu.RLock()
+	defer classifiers.mu.RUnlock()
+	c, ok := classifiers.classifiers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown classifier: %q", name)
+	}
+	return c, nil
+}
+
+// DefaultClassifyBatchSize is the number of documents passed to each
+// ClassifyBatch call when ClassifyOptions.BatchSize is zero.
+const DefaultClassifyBatchSize = 64
+
+// ClassifyOptions configures document classification.
+type ClassifyOptions struct {
+	// Threshold is the minimum confidence for a label to be kept;
+	// classifications below it are stored as CategoryUnknown.
+	// Default: 0 (keep every label)
+	Threshold float32
+
+	// BatchSize is the number of documents passed to each ClassifyBatch
+	// call of a BatchClassifier.
+	// Default: 64
+	BatchSize int
+
+	// Selector limits classification to the documents it matches; the
+	// others are passed through unlabeled.
+	Selector Selector
+}
+
+// ClassifyInput is the input for the Classify transformer.
+type ClassifyInput struct {
+	Documents []Document
+
+	// Classifier names a registered Classifier (see RegisterClassifier).
+	Classifier string
+	Options    ClassifyOptions
+}
+
+// ClassifyOutput is the output of the Classify transformer.
+type ClassifyOutput struct {
+	Documents []Document
+	Count     int
+
+	// Unknown is the number of documents labeled CategoryUnknown.
+	Unknown int
+}
+
+// ToDocuments implements DocumentSource for ClassifyOutput.
+func (o ClassifyOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// ClassifyActivity labels every document with the named classifier,
+// storing the label in Metadata["category"] and its confidence in
+// Metadata["category_confidence"].
+func ClassifyActivity(ctx context.Context, input ClassifyInput) (ClassifyOutput, error) {
+	classifier, err := lookupClassifier(input.Classifier)
+	if err != nil {
+		return ClassifyOutput{}, err
+	}
+
+	selected := make([]Document, 0, len(input.Documents))
+	for _, doc := range input.Documents {
+		if input.Options.Selector.Matches(doc) {
+			selected = append(selected, doc)
+		}
+	}
+
+	results, err := classifyDocuments(ctx, classifier, selected, input.Options.BatchSize)
+	if err != nil {
+		return ClassifyOutput{}, err
+	}
+
+	docs := make([]Document, len(input.Documents))
+	var unknown, next int
+	for i, doc := range input.Documents {
+		if !input.Options.Selector.Matches(doc) {
+			docs[i] = doc
+			continue
+		}
+		result := results[next]
+		next++
+
+		label := result.Label
+		if label == "" || result.Confidence < input.Options.Threshold {
+			label = CategoryUnknown
+		}
+		if label == CategoryUnknown {
+			unknown++
+		}
+
+		doc = doc.withMetadataCopy(MetadataCategory, label)
+		doc.Metadata[MetadataCategoryConfidence] = strconv.FormatFloat(float64(result.Confidence), 'f', -1, 32)
+		docs[i] = doc
+	}
+
+	return ClassifyOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Unknown:   unknown,
+	}, nil
+}
+
+// Classify creates a node that labels documents by category with the
+// classifier registered under classifier, e.g. to route them to
+// per-topic indexes downstream.
+//
+// Example:
+//
+//	transform.RegisterClassifier("topics", topicClassifier)
+//
+//	flow := core.NewFlow("route").
+//	    Then(fetchNode).
+//	    Then(transform.Classify("topics", transform.ClassifyOptions{Threshold: 0.6})).
+//	    Build()
+func Classify(classifier string, opts ClassifyOptions) *core.Node[ClassifyInput, ClassifyOutput] {
+	return core.NewNode("transform.Classify", ClassifyActivity, ClassifyInput{Classifier: classifier, Options: opts})
+}
+
+// classifyDocuments classifies docs, in batches of batchSize when the
+// classifier supports it.
+func classifyDocuments(ctx context.Context, classifier Classifier, docs []Document, batchSize int) ([]Classification, error) {
+	results := make([]Classification, 0, len(docs))
+
+	batch, ok := classifier.(BatchClassifier)
+	if !ok {
+		for _, doc := range docs {
+			label, confidence, err := classifier.Classify(ctx, doc)
+			if err != nil {
+				return nil, fmt.Errorf("classify document %s: %w", doc.ID, err)
+			}
+			results = append(results, Classification{Label: label, Confidence: confidence})
+		}
+		return results, nil
+	}
+
+	if batchSize <= 0 {
+		batchSize = DefaultClassifyBatchSize
+	}
+	for start := 0; start < len(docs); start += batchSize {
+		end := min(start+batchSize, len(docs))
+
+		classified, err := batch.ClassifyBatch(ctx, docs[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("classify documents %d-%d: %w", start, end-1, err)
+		}
+		if len(classified) != end-start {
+			return nil, fmt.Errorf("classifier returned %d results for %d documents", len(classified), end-start)
+		}
+		results = append(results, classified...)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,111 @@
+package transform
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// keywordClassifier labels documents mentioning "error" as incidents.
+type keywordClassifier struct{}
+
+func (keywordClassifier) Classify(ctx context.Context, doc Document) (string, float32, error) {
+	if strings.Contains(doc.Content, "error") {
+		return "incident", 0.9, nil
+	}
+	return "docs", 0.4, nil
+}
+
+// batchKeywordClassifier records the size of each batch it receives.
+type batchKeywordClassifier struct {
+	keywordClassifier
+	batches *[]int
+}
+
+func (c batchKeywordClassifier) ClassifyBatch(ctx context.Context, docs []Document) ([]Classification, error) {
+	*c.batches = append(*c.batches, len(docs))
+	results := make([]Classification, len(docs))
+	for i, doc := range docs {
+		label, confidence, _ := c.Classify(ctx, doc)
+		results[i] = Classification{Label: label, Confidence: confidence}
+	}
+	return results, nil
+}
+
+func TestClassifyActivity(t *testing.T) {
+	t.Parallel()
+
+	var batches []int
+	RegisterClassifier("test_keyword", keywordClassifier{})
+	RegisterClassifier("test_keyword_batch", batchKeywordClassifier{batches: &batches})
+
+	docs := []Document{
+		{ID: "1", Content: "disk error on node"},
+		{ID: "2", Content: "install guide", Metadata: map[string]string{"k": "v"}},
+		{ID: "3", Content: "timeout error"},
+	}
+
+	tests := []struct {
+		name       string
+		classifier string
+		opts       ClassifyOptions
+		want       []string
+	}{
+		{name: "no threshold", classifier: "test_keyword", want: []string{"incident", "docs", "incident"}},
+		{name: "threshold", classifier: "test_keyword", opts: ClassifyOptions{Threshold: 0.5}, want: []string{"incident", CategoryUnknown, "incident"}},
+		{name: "batch", classifier: "test_keyword_batch", opts: ClassifyOptions{BatchSize: 2}, want: []string{"incident", "docs", "incident"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := ClassifyActivity(context.Background(), ClassifyInput{Documents: docs, Classifier: tt.classifier, Options: tt.opts})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for i, want := range tt.want {
+				if got := out.Documents[i].Metadata[MetadataCategory]; got != want {
+					t.Errorf("doc %d category = %q, want %q", i, got, want)
+				}
+			}
+			if got := out.Documents[0].Metadata[MetadataCategoryConfidence]; got != "0.9" {
+				t.Errorf("confidence = %q, want %q", got, "0.9")
+			}
+		})
+	}
+
+	if len(batches) != 2 || batches[0] != 2 || batches[1] != 1 {
+		t.Errorf("batches = %v, want [2 1]", batches)
+	}
+	if len(docs[1].Metadata) != 1 {
+		t.Errorf("input metadata was mutated: %v", docs[1].Metadata)
+	}
+
+	if _, err := ClassifyActivity(context.Background(), ClassifyInput{Documents: docs, Classifier: "missing"}); err == nil {
+		t.Error("expected error for unknown classifier")
+	}
+}
+
+func TestClassifyActivitySelector(t *testing.T) {
+	t.Parallel()
+
+	RegisterClassifier("test_keyword_selector", keywordClassifier{})
+
+	out, err := ClassifyActivity(context.Background(), ClassifyInput{
+		Documents: []Document{
+			{ID: "log-1", Content: "disk error"},
+			{ID: "doc-1", Content: "disk error"},
+		},
+		Classifier: "test_keyword_selector",
+		Options:    ClassifyOptions{Selector: Selector{IDPrefixes: []string{"log-"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.Documents[0].Metadata[MetadataCategory]; got != "incident" {
+		t.Errorf("selected category = %q, want incident", got)
+	}
+	if _, ok := out.Documents[1].Metadata[MetadataCategory]; ok {
+		t.Errorf("unselected document was labeled: %v", out.Documents[1].Metadata)
+	}
+}
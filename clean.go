@@ -0,0 +1,172 @@
+package transform
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// MetadataRawContent holds a document's content before CleanActivity
+// changed it, for display.
+const MetadataRawContent = "raw_content"
+
+// CleanOptions selects the cleanups applied to document content before
+// embedding. Enabled cleanups run in field order: StripMarkdown,
+// StripURLs, CollapseWhitespace.
+type CleanOptions struct {
+	// StripMarkdown removes markdown syntax and keeps its text: heading,
+	// blockquote and list markers, emphasis, inline code and fence
+	// lines, images and links (keeping alt and link text), and
+	// horizontal rules.
+	StripMarkdown bool
+
+	// StripURLs removes http, https and www URLs.
+	StripURLs bool
+
+	// CollapseWhitespace collapses runs of spaces and tabs to one space,
+	// trims every line, and collapses blank lines to single paragraph
+	// breaks.
+	CollapseWhitespace bool
+
+	// Selector limits cleaning to the documents it matches.
+	Selector Selector
+}
+
+// CleanInput is the input for the Clean transformer.
+type CleanInput struct {
+	Documents []Document
+	Options   CleanOptions
+}
+
+// CleanOutput is the output of the Clean transformer.
+type CleanOutput struct {
+	Documents []Document
+	Count     int
+
+	// Cleaned is the number of documents whose content changed.
+	Cleaned int
+}
+
+// ToDocuments implements DocumentSource for CleanOutput.
+func (o CleanOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// CleanActivity applies the enabled cleanups to every document's content.
+// A document whose content changes keeps its original content in
+// Metadata["raw_content"]; use StripMetadata to drop it before storage.
+func CleanActivity(ctx context.Context, input CleanInput) (CleanOutput, error) {
+	docs := make([]Document, len(input.Documents))
+	var cleaned int
+	for i, doc := range input.Documents {
+		if input.Options.Selector.Matches(doc) {
+			if content := cleanContent(doc.Content, input.Options); content != doc.Content {
+				doc = doc.withMetadataCopy(MetadataRawContent, doc.Content)
+				doc.Content = content
+				cleaned++
+			}
+		}
+		docs[i] = doc
+	}
+
+	return CleanOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Cleaned:   cleaned,
+	}, nil
+}
+
+// Clean creates a node that cleans document content before embedding.
+// To clean chunks in the same node that creates them, chain the options
+// with Chain and FromTransformer.
+//
+// Example:
+//
+//	flow := core.NewFlow("kb").
+//	    Then(fetchNode).
+//	    Then(transform.Chunk(transform.ChunkOptions{MaxTokens: 512})).
+//	    Then(transform.Clean(transform.CleanOptions{StripMarkdown: true, StripURLs: true, CollapseWhitespace: true})).
+//	    Then(embedNode).
+//	    Build()
+func Clean(opts CleanOptions) *core.Node[CleanInput, CleanOutput] {
+	return core.NewNode("transform.Clean", CleanActivity, CleanInput{Options: opts})
+}
+
+// cleanContent applies the cleanups enabled in opts in order.
+func cleanContent(content string, opts CleanOptions) string {
+	if opts.StripMarkdown {
+		content = stripMarkdown(content)
+	}
+	if opts.StripURLs {
+		content = stripURLs(content)
+	}
+	if opts.CollapseWhitespace {
+		content = collapseWhitespace(content)
+	}
+	return content
+}
+
+var (
+	markdownImage   = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink    = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownCode    = regexp.MustCompile("`([^`]+)`")
+	markdownLine    = regexp.MustCompile(`^\s*(?:#{1,6}\s+|>\s?|[-*+]\s+|\d+[.)]\s+)`)
+	markdownRule    = regexp.MustCompile(`^\s*(?:[-*_]\s*){3,}$`)
+	urlPattern      = regexp.MustCompile(`(?:https?://|www\.)[^\s<>()\[\]]+`)
+	horizontalSpace = regexp.MustCompile(`[ \t]+`)
+)
+
+// markdownEmphasis matches emphasis spans, strongest delimiters first.
+var markdownEmphasis = []*regexp.Regexp{
+	regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`),
+	regexp.MustCompile(`__(\S(?:.*?\S)?)__`),
+	regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`),
+	regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`),
+}
+
+// stripMarkdown removes markdown syntax from content, keeping its text.
+func stripMarkdown(content string) string {
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if markdownRule.MatchString(line) || strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		line = markdownLine.ReplaceAllString(line, "")
+		line = markdownImage.ReplaceAllString(line, "$1")
+		line = markdownLink.ReplaceAllString(line, "$1")
+		line = markdownCode.ReplaceAllString(line, "$1")
+		for _, emphasis := range markdownEmphasis {
+			line = emphasis.ReplaceAllString(line, "$1")
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// stripURLs removes URLs from content.
+func stripURLs(content string) string {
+	return urlPattern.ReplaceAllString(content, "")
+}
+
+// collapseWhitespace collapses horizontal whitespace runs, trims lines,
+// and keeps at most one blank line between paragraphs.
+func collapseWhitespace(content string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(horizontalSpace.ReplaceAllString(line, " "))
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,87 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStripMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"heading", "## Setup guide", "Setup guide"},
+		{"emphasis", "a **bold** and *soft* ~~old~~ word", "a bold and soft old word"},
+		{"snake case", "set max_chunk_size here", "set max_chunk_size here"},
+		{"link", "see [the docs](https://example.com/docs) now", "see the docs now"},
+		{"image", "![diagram](img.png)", "diagram"},
+		{"code", "run `go test` first", "run go test first"},
+		{"fence", "```go\nx := 1\n```", "x := 1"},
+		{"list", "- one\n* two\n3. three", "one\ntwo\nthree"},
+		{"quote", "> quoted", "quoted"},
+		{"rule", "above\n---\nbelow", "above\nbelow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := stripMarkdown(tt.in); got != tt.want {
+				t.Errorf("stripMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripURLs(t *testing.T) {
+	t.Parallel()
+
+	got := stripURLs("docs at https://example.com/a?b=1 or www.example.org.")
+	if want := "docs at  or "; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCollapseWhitespace(t *testing.T) {
+	t.Parallel()
+
+	got := collapseWhitespace("  one \t two  \n\n\n\nthree   \n")
+	if want := "one two\n\nthree"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCleanActivity(t *testing.T) {
+	t.Parallel()
+
+	raw := "## Links\n\nSee [docs](https://example.com)  and   https://example.com/raw\n\n\n\nbye"
+	docs := []Document{
+		{ID: "a", Content: raw},
+		{ID: "b", Content: "already clean"},
+	}
+	out, err := CleanActivity(context.Background(), CleanInput{
+		Documents: docs,
+		Options:   CleanOptions{StripMarkdown: true, StripURLs: true, CollapseWhitespace: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "Links\n\nSee docs and\n\nbye"; out.Documents[0].Content != want {
+		t.Errorf("Content = %q, want %q", out.Documents[0].Content, want)
+	}
+	if out.Documents[0].Metadata[MetadataRawContent] != raw {
+		t.Errorf("raw content = %q, want the original", out.Documents[0].Metadata[MetadataRawContent])
+	}
+	if out.Documents[1].Metadata != nil {
+		t.Errorf("unchanged document got metadata %v", out.Documents[1].Metadata)
+	}
+	if out.Cleaned != 1 || out.Count != 2 {
+		t.Errorf("got Cleaned=%d Count=%d, want 1, 2", out.Cleaned, out.Count)
+	}
+	if docs[0].Content != raw || docs[0].Metadata != nil {
+		t.Error("input document was modified")
+	}
+}
@@ -0,0 +1,142 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// CompactOptions configures merging of undersized chunks.
+type CompactOptions struct {
+	// MaxTokens is the largest word-token count (see TokenCount) of a
+	// merged chunk. Adjacent chunks are merged while their combined
+	// count stays within it.
+	MaxTokens int
+
+	// Separator joins the content of merged chunks.
+	// Default: " "
+	Separator string
+}
+
+// CompactInput is the input for the Compact transformer.
+type CompactInput struct {
+	Documents []Document
+	Options   CompactOptions
+}
+
+// CompactOutput is the output of the Compact transformer.
+type CompactOutput struct {
+	Documents []Document
+	Count     int
+
+	// Compacted is the number of chunks merged into a neighbor.
+	Compacted int
+}
+
+// ToDocuments implements DocumentSource for CompactOutput.
+func (o CompactOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// CompactActivity merges undersized adjacent chunks of the same parent,
+// e.g. after a filter removed some chunks. The chunks of each parent are
+// taken in ChunkIndex order and merged greedily while the combined token
+// count stays within MaxTokens; chunks of different parents are never
+// merged. A merged chunk keeps the fields and metadata of its first
+// chunk, minus any token range, and repeats the overlap between the
+// merged chunks. When any chunk of a parent is merged, that parent's
+// chunks are renumbered from zero with IDs derived from the new
+// ChunkIndex. Documents that are not chunks are passed through, and each
+// parent's chunks are emitted where its first chunk appeared.
+func CompactActivity(ctx context.Context, input CompactInput) (CompactOutput, error) {
+	opts := input.Options
+	if opts.MaxTokens <= 0 {
+		return CompactOutput{}, fmt.Errorf("max tokens must be positive, got %d", opts.MaxTokens)
+	}
+	if opts.Separator == "" {
+		opts.Separator = " "
+	}
+
+	groups := make(map[string][]Document)
+	for _, doc := range input.Documents {
+		if doc.IsChunk() {
+			groups[doc.ParentID] = append(groups[doc.ParentID], doc)
+		}
+	}
+
+	docs := make([]Document, 0, len(input.Documents))
+	var compacted int
+	for _, doc := range input.Documents {
+		if !doc.IsChunk() {
+			docs = append(docs, doc)
+			continue
+		}
+		chunks, ok := groups[doc.ParentID]
+		if !ok {
+			continue
+		}
+		delete(groups, doc.ParentID)
+
+		merged := compactChunks(chunks, opts)
+		compacted += len(chunks) - len(merged)
+		docs = append(docs, merged...)
+	}
+
+	return CompactOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Compacted: compacted,
+	}, nil
+}
+
+// Compact creates a node that merges undersized adjacent chunks within
+// each parent document.
+//
+// Example:
+//
+//	flow := core.NewFlow("kb").
+//	    Then(chunkNode).
+//	    Then(transform.Filter(transform.FilterOptions{MaxAge: 30 * 24 * time.Hour})).
+//	    Then(transform.Compact(transform.CompactOptions{MaxTokens: 512})).
+//	    Then(embedNode).
+//	    Build()
+func Compact(opts CompactOptions) *core.Node[CompactInput, CompactOutput] {
+	return core.NewNode("transform.Compact", CompactActivity, CompactInput{Options: opts})
+}
+
+// compactChunks merges the chunks of one parent. The input is not
+// modified.
+func compactChunks(chunks []Document, opts CompactOptions) []Document {
+	chunks = append([]Document(nil), chunks...)
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+
+	out := make([]Document, 0, len(chunks))
+	tokens := make([]int, 0, len(chunks))
+	contents := make([][]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		n := TokenCount(chunk)
+		if last := len(out) - 1; last >= 0 && tokens[last]+n <= opts.MaxTokens {
+			tokens[last] += n
+			contents[last] = append(contents[last], chunk.Content)
+			continue
+		}
+		out = append(out, chunk)
+		tokens = append(tokens, n)
+		contents = append(contents, []string{chunk.Content})
+	}
+	if len(out) == len(chunks) {
+		return out
+	}
+
+	for i := range out {
+		if len(contents[i]) > 1 {
+			out[i].Content = strings.Join(contents[i], opts.Separator)
+			out[i] = withoutRanges(out[i])
+		}
+		out[i] = out[i].AsChunk(out[i].ParentID, i)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompactActivity(t *testing.T) {
+	t.Parallel()
+
+	chunk := func(parent string, index int, content string) Document {
+		return Document{Content: content, Metadata: map[string]string{MetadataTokenStart: "0", MetadataTokenEnd: "1"}}.AsChunk(parent, index)
+	}
+	input := CompactInput{
+		Documents: []Document{
+			chunk("a", 0, "one two"),
+			{ID: "standalone", Content: "x y z"},
+			// Out of order, and with a gap left by a filter.
+			chunk("a", 3, "five"),
+			chunk("a", 1, "three four"),
+			chunk("b", 0, "six seven eight"),
+			chunk("b", 1, "nine ten"),
+			chunk("c", 0, "eleven"),
+			chunk("c", 1, "twelve thirteen fourteen fifteen"),
+		},
+		Options: CompactOptions{MaxTokens: 4},
+	}
+
+	output, err := CompactActivity(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		id, content string
+		index       int
+	}{
+		{"a#0", "one two three four", 0},
+		{"a#1", "five", 1},
+		{"standalone", "x y z", 0},
+		{"b#0", "six seven eight", 0},
+		{"b#1", "nine ten", 1},
+		{"c#0", "eleven", 0},
+		{"c#1", "twelve thirteen fourteen fifteen", 1},
+	}
+	if len(output.Documents) != len(want) || output.Count != len(want) {
+		t.Fatalf("got %d documents (Count %d), want %d: %+v", len(output.Documents), output.Count, len(want), output.Documents)
+	}
+	for i, w := range want {
+		doc := output.Documents[i]
+		if doc.ID != w.id || doc.Content != w.content || doc.ChunkIndex != w.index {
+			t.Errorf("document %d = {%s %q %d}, want {%s %q %d}", i, doc.ID, doc.Content, doc.ChunkIndex, w.id, w.content, w.index)
+		}
+	}
+	if output.Compacted != 1 {
+		t.Errorf("Compacted = %d, want 1", output.Compacted)
+	}
+
+	if _, ok := output.Documents[0].Metadata[MetadataTokenStart]; ok {
+		t.Error("merged chunk kept its token range")
+	}
+	if _, ok := output.Documents[1].Metadata[MetadataTokenStart]; !ok {
+		t.Error("unmerged chunk lost its token range")
+	}
+	// Parent a was renumbered: "five" was chunk 3.
+	if input.Documents[2].ID != "a#3" || input.Documents[0].Content != "one two" {
+		t.Error("input documents were modified")
+	}
+	if _, ok := input.Documents[0].Metadata[MetadataTokenStart]; !ok {
+		t.Error("input metadata was modified")
+	}
+}
+
+func TestCompactActivityInvalidMaxTokens(t *testing.T) {
+	t.Parallel()
+
+	if _, err := CompactActivity(context.Background(), CompactInput{}); err == nil {
+		t.Error("expected error for zero MaxTokens")
+	}
+}
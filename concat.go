@@ -0,0 +1,154 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// ConcatOptions configures joining documents into one.
+type ConcatOptions struct {
+	// Separator is placed between the documents' content.
+	// Default: "\n\n"
+	Separator string
+
+	// TitleHeaders precedes each document's content with its Title as a
+	// markdown heading ("## Title"), so the sections stay identifiable
+	// and StrategyMarkdown can split them again.
+	TitleHeaders bool
+
+	// MaxTokens caps the estimated tokens (see EstimateTokens) of the
+	// joined content. Documents that would exceed it are omitted and
+	// counted in ConcatOutput.Omitted, as are all documents after them.
+	// Default: 0 (no cap)
+	MaxTokens int
+
+	// MetadataMerge resolves metadata keys present on several documents.
+	// Default: MergePolicyKeepFirst
+	MetadataMerge MergePolicy
+
+	// ID is the joined document's ID.
+	// Default: "" (the content hash)
+	ID string
+
+	// Title is the joined document's title.
+	Title string
+}
+
+// ConcatInput is the input for the Concat transformer.
+type ConcatInput struct {
+	Documents []Document
+	Options   ConcatOptions
+}
+
+// ConcatOutput is the output of the Concat transformer.
+type ConcatOutput struct {
+	// Documents holds the joined document, or nothing when no document
+	// was included.
+	Documents []Document
+	Count     int
+
+	// Included and Omitted are the numbers of input documents joined and
+	// left out by the MaxTokens cap.
+	Included int
+	Omitted  int
+}
+
+// ToDocuments implements DocumentSource for ConcatOutput.
+func (o ConcatOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// ConcatActivity joins the content of all documents, in order, into a
+// single document with their combined metadata. The joined document
+// keeps a Source shared by every included document and the latest
+// UpdatedAt.
+func ConcatActivity(ctx context.Context, input ConcatInput) (ConcatOutput, error) {
+	opts := input.Options
+	if opts.Separator == "" {
+		opts.Separator = "\n\n"
+	}
+	policy := opts.MetadataMerge
+	switch policy {
+	case "":
+		policy = MergePolicyKeepFirst
+	case MergePolicyNone, MergePolicyKeepFirst, MergePolicyKeepLast, MergePolicyConcat:
+	default:
+		return ConcatOutput{}, fmt.Errorf("unknown metadata merge policy: %q", policy)
+	}
+	if opts.MaxTokens < 0 {
+		return ConcatOutput{}, fmt.Errorf("max tokens must not be negative, got %d", opts.MaxTokens)
+	}
+
+	joined := Document{Title: opts.Title}
+	var content strings.Builder
+	var tokens, included int
+	for i, doc := range input.Documents {
+		part := doc.Content
+		if opts.TitleHeaders && doc.Title != "" {
+			part = "## " + doc.Title + "\n\n" + part
+		}
+		if i > 0 {
+			part = opts.Separator + part
+		}
+
+		n := EstimateTokens(part)
+		if opts.MaxTokens > 0 && tokens+n > opts.MaxTokens {
+			break
+		}
+		tokens += n
+		content.WriteString(part)
+
+		if included == 0 {
+			joined.Source = doc.Source
+			joined.Metadata = copyMetadata(doc.Metadata)
+		} else {
+			if joined.Source != doc.Source {
+				joined.Source = ""
+			}
+			if policy != MergePolicyNone && len(doc.Metadata) > 0 {
+				if joined.Metadata == nil {
+					joined.Metadata = make(map[string]string, len(doc.Metadata))
+				}
+				mergeMetadata(joined.Metadata, doc.Metadata, policy)
+			}
+		}
+		if doc.UpdatedAt.After(joined.UpdatedAt) {
+			joined.UpdatedAt = doc.UpdatedAt
+		}
+		included++
+	}
+
+	out := ConcatOutput{
+		Documents: []Document{},
+		Included:  included,
+		Omitted:   len(input.Documents) - included,
+	}
+	if included == 0 {
+		return out, nil
+	}
+
+	joined.Content = content.String()
+	joined.ID = opts.ID
+	if joined.ID == "" {
+		joined.ID = ContentHashAllocator{}.Allocate(joined)
+	}
+	out.Documents = append(out.Documents, joined)
+	out.Count = 1
+	return out, nil
+}
+
+// Concat creates a node that joins documents into a single document,
+// e.g. to pass a combined context to a model in one piece.
+//
+// Example:
+//
+//	flow := core.NewFlow("digest").
+//	    Then(fetchNode).
+//	    Then(transform.Concat(transform.ConcatOptions{TitleHeaders: true, MaxTokens: 8000})).
+//	    Build()
+func Concat(opts ConcatOptions) *core.Node[ConcatInput, ConcatOutput] {
+	return core.NewNode("transform.Concat", ConcatActivity, ConcatInput{Options: opts})
+}
@@ -0,0 +1,102 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConcatActivity(t *testing.T) {
+	t.Parallel()
+
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	docs := []Document{
+		{ID: "a", Title: "Intro", Content: "hello", Source: "wiki", Metadata: map[string]string{"lang": "en"}, UpdatedAt: older},
+		{ID: "b", Title: "Usage", Content: "world", Source: "wiki", Metadata: map[string]string{"lang": "de", "team": "ops"}, UpdatedAt: newer},
+	}
+
+	tests := []struct {
+		name         string
+		opts         ConcatOptions
+		wantContent  string
+		wantMetadata map[string]string
+	}{
+		{
+			name:         "default separator",
+			opts:         ConcatOptions{ID: "joined"},
+			wantContent:  "hello\n\nworld",
+			wantMetadata: map[string]string{"lang": "en", "team": "ops"},
+		},
+		{
+			name:         "title headers and concat metadata",
+			opts:         ConcatOptions{ID: "joined", Separator: "\n---\n", TitleHeaders: true, MetadataMerge: MergePolicyConcat},
+			wantContent:  "## Intro\n\nhello\n---\n## Usage\n\nworld",
+			wantMetadata: map[string]string{"lang": "en,de", "team": "ops"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := ConcatActivity(context.Background(), ConcatInput{Documents: docs, Options: tt.opts})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Count != 1 || out.Included != 2 {
+				t.Fatalf("got Count=%d Included=%d, want 1 and 2", out.Count, out.Included)
+			}
+
+			got := out.Documents[0]
+			if got.ID != "joined" || got.Content != tt.wantContent {
+				t.Errorf("got %q %q, want joined %q", got.ID, got.Content, tt.wantContent)
+			}
+			if !reflect.DeepEqual(got.Metadata, tt.wantMetadata) {
+				t.Errorf("Metadata = %v, want %v", got.Metadata, tt.wantMetadata)
+			}
+			if got.Source != "wiki" || !got.UpdatedAt.Equal(newer) {
+				t.Errorf("Source = %q UpdatedAt = %v, want wiki and %v", got.Source, got.UpdatedAt, newer)
+			}
+		})
+	}
+
+	if docs[0].Metadata["lang"] != "en" || len(docs[0].Metadata) != 1 {
+		t.Errorf("input metadata was mutated: %v", docs[0].Metadata)
+	}
+}
+
+func TestConcatActivityMaxTokens(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "a", Content: strings.Repeat("x", 40)},
+		{ID: "b", Content: strings.Repeat("y", 40)},
+		{ID: "c", Content: "z"},
+	}
+
+	out, err := ConcatActivity(context.Background(), ConcatInput{Documents: docs, Options: ConcatOptions{MaxTokens: 15}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Included != 1 || out.Omitted != 2 {
+		t.Errorf("got Included=%d Omitted=%d, want 1 and 2", out.Included, out.Omitted)
+	}
+	if got := out.Documents[0]; got.Content != docs[0].Content || got.ID != (ContentHashAllocator{}).Allocate(got) {
+		t.Errorf("joined document = %+v, want first document's content with a content hash ID", got)
+	}
+
+	out, err = ConcatActivity(context.Background(), ConcatInput{Documents: docs, Options: ConcatOptions{MaxTokens: 5}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Count != 0 || out.Documents == nil {
+		t.Errorf("got %d documents, want empty non-nil", out.Count)
+	}
+
+	if _, err := ConcatActivity(context.Background(), ConcatInput{Documents: docs, Options: ConcatOptions{MetadataMerge: "union"}}); err == nil {
+		t.Error("expected error for unknown metadata merge policy")
+	}
+}
@@ -0,0 +1,238 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// MetadataAliasIDs holds the comma-separated IDs that a canonicalized
+// document replaced.
+const MetadataAliasIDs = "alias_ids"
+
+// DedupOptions configures exact-content duplicate removal.
+type DedupOptions struct {
+	// CanonicalizeID rewrites each surviving document's ID to a hash of
+	// its content (see ContentHashAllocator), so the same content gets
+	// the same ID across runs even when providers assign fresh IDs. The
+	// replaced IDs, including those of the removed duplicates, are kept
+	// in Metadata["alias_ids"].
+	CanonicalizeID bool
+
+	// MetadataMerge unions the metadata of removed duplicates into the
+	// surviving document, resolving keys present on both by the policy.
+	// Default: MergePolicyNone
+	MetadataMerge MergePolicy
+
+	// DryRun reports the Count, Removed and MergedKeys deduplication
+	// would produce without returning Documents.
+	DryRun bool
+
+	// Report records every removed duplicate in DedupOutput.Report for
+	// auditing dedup decisions. It is off by default because the report
+	// grows with the number of duplicates.
+	Report bool
+}
+
+// DedupKey names what two documents matched on.
+type DedupKey string
+
+// DedupKeyContentHash is a match on the SHA-256 of the raw Content (see
+// ContentHashAllocator); documents differing only in whitespace or case
+// do not match.
+const DedupKeyContentHash DedupKey = "content_hash"
+
+// DedupRecord describes one duplicate removed by DedupActivity.
+type DedupRecord struct {
+	// ID is the removed document's ID.
+	ID string
+
+	// SurvivorID is the ID of the document it duplicates, after
+	// CanonicalizeID.
+	SurvivorID string
+
+	// Key is what the documents matched on and Value the matched value.
+	Key   DedupKey
+	Value string
+}
+
+// MergePolicy determines how duplicate metadata is combined.
+type MergePolicy string
+
+const (
+	// MergePolicyNone keeps only the survivor's metadata.
+	MergePolicyNone MergePolicy = "none"
+
+	// MergePolicyKeepFirst adds keys missing from the survivor and keeps
+	// the survivor's value for keys present on both.
+	MergePolicyKeepFirst MergePolicy = "keep_first"
+
+	// MergePolicyKeepLast adds missing keys and lets later duplicates
+	// overwrite existing values.
+	MergePolicyKeepLast MergePolicy = "keep_last"
+
+	// MergePolicyConcat adds missing keys and joins differing values with
+	// commas, skipping values already present.
+	MergePolicyConcat MergePolicy = "concat"
+)
+
+// DedupInput is the input for the Dedup transformer.
+type DedupInput struct {
+	Documents []Document
+	Options   DedupOptions
+}
+
+// DedupOutput is the output of the Dedup transformer.
+type DedupOutput struct {
+	Documents []Document
+	Count     int
+	Removed   int
+
+	// MergedKeys is the number of metadata keys added to or changed on
+	// survivors under DedupOptions.MetadataMerge.
+	MergedKeys int
+
+	// Report lists the removed duplicates in input order when
+	// DedupOptions.Report is set.
+	Report []DedupRecord
+}
+
+// ToDocuments implements DocumentSource for DedupOutput.
+func (o DedupOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// DedupActivity removes documents whose content is identical to an
+// earlier document's, keeping the first occurrence.
+func DedupActivity(ctx context.Context, input DedupInput) (DedupOutput, error) {
+	policy := input.Options.MetadataMerge
+	switch policy {
+	case MergePolicyNone, MergePolicyKeepFirst, MergePolicyKeepLast, MergePolicyConcat, "":
+	default:
+		return DedupOutput{}, fmt.Errorf("unknown metadata merge policy: %q", policy)
+	}
+
+	var hasher ContentHashAllocator
+	docs := make([]Document, 0, len(input.Documents))
+	seen := make(map[string]int, len(input.Documents))
+	aliases := make(map[int][]string)
+	merged := make(map[int]bool)
+	var mergedKeys int
+	var report []DedupRecord
+
+	log := debugLogger(ctx)
+	for _, doc := range input.Documents {
+		hash := hasher.Allocate(doc)
+		if i, ok := seen[hash]; ok {
+			if log != nil {
+				log.DebugContext(ctx, "transform: duplicate removed", "id", doc.ID, "survivor", docs[i].ID, "content_hash", hash)
+			}
+			if input.Options.Report {
+				report = append(report, DedupRecord{ID: doc.ID, SurvivorID: docs[i].ID, Key: DedupKeyContentHash, Value: hash})
+			}
+			if policy != MergePolicyNone && policy != "" && len(doc.Metadata) > 0 {
+				if !merged[i] {
+					docs[i].Metadata = copyMetadata(docs[i].Metadata)
+					if docs[i].Metadata == nil {
+						docs[i].Metadata = make(map[string]string, len(doc.Metadata))
+					}
+					merged[i] = true
+				}
+				mergedKeys += mergeMetadata(docs[i].Metadata, doc.Metadata, policy)
+			}
+			if input.Options.CanonicalizeID {
+				aliases[i] = appendAlias(aliases[i], doc.ID, docs[i].ID)
+			}
+			continue
+		}
+
+		seen[hash] = len(docs)
+		if input.Options.CanonicalizeID {
+			aliases[len(docs)] = appendAlias(nil, doc.ID, hash)
+			doc.ID = hash
+		}
+		docs = append(docs, doc)
+	}
+
+	for i, ids := range aliases {
+		if len(ids) > 0 {
+			docs[i] = docs[i].withMetadataCopy(MetadataAliasIDs, strings.Join(ids, ","))
+		}
+	}
+
+	count := len(docs)
+	if input.Options.DryRun {
+		docs = []Document{}
+	}
+
+	return DedupOutput{
+		Documents:  docs,
+		Count:      count,
+		Removed:    len(input.Documents) - count,
+		MergedKeys: mergedKeys,
+		Report:     report,
+	}, nil
+}
+
+// mergeMetadata merges src into dst under policy and returns the number
+// of keys added or changed.
+func mergeMetadata(dst, src map[string]string, policy MergePolicy) int {
+	var n int
+	for k, v := range src {
+		existing, ok := dst[k]
+		switch {
+		case !ok:
+			dst[k] = v
+		case existing == v || policy == MergePolicyKeepFirst:
+			continue
+		case policy == MergePolicyKeepLast:
+			dst[k] = v
+		case policy == MergePolicyConcat:
+			if containsValue(existing, v) {
+				continue
+			}
+			dst[k] = existing + "," + v
+		}
+		n++
+	}
+	return n
+}
+
+// containsValue reports whether the comma-separated list contains v.
+func containsValue(list, v string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// appendAlias appends id to ids unless it is empty, already present, or
+// the canonical ID itself.
+func appendAlias(ids []string, id, canonical string) []string {
+	if id == "" || id == canonical {
+		return ids
+	}
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// Dedup creates a node that removes documents with identical content.
+//
+// Example:
+//
+//	flow := core.NewFlow("sync").
+//	    Then(fetchNode).
+//	    Then(transform.Dedup(transform.DedupOptions{CanonicalizeID: true})).
+//	    Then(upsertNode).
+//	    Build()
+func Dedup(opts DedupOptions) *core.Node[DedupInput, DedupOutput] {
+	return core.NewNode("transform.Dedup", DedupActivity, DedupInput{Options: opts})
+}
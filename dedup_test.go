@@ -0,0 +1,151 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDedupActivity(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "run1-a", Content: "same text"},
+		{ID: "run1-b", Content: "other text"},
+		{ID: "run1-c", Content: "same text"},
+		{ID: "run1-a", Content: "same text"},
+	}
+	canonical := ContentHashAllocator{}.Allocate(docs[0])
+
+	tests := []struct {
+		name      string
+		opts      DedupOptions
+		wantIDs   []string
+		wantAlias string
+	}{
+		{
+			name:    "keeps first occurrence",
+			wantIDs: []string{"run1-a", "run1-b"},
+		},
+		{
+			name:      "canonicalizes ids",
+			opts:      DedupOptions{CanonicalizeID: true},
+			wantIDs:   []string{canonical, ContentHashAllocator{}.Allocate(docs[1])},
+			wantAlias: "run1-a,run1-c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := DedupActivity(context.Background(), DedupInput{Documents: docs, Options: tt.opts})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if out.Count != len(tt.wantIDs) || out.Removed != 2 {
+				t.Fatalf("Count = %d, Removed = %d, want %d and 2", out.Count, out.Removed, len(tt.wantIDs))
+			}
+			for i, id := range tt.wantIDs {
+				if out.Documents[i].ID != id {
+					t.Errorf("Documents[%d].ID = %q, want %q", i, out.Documents[i].ID, id)
+				}
+			}
+			if got := out.Documents[0].Metadata[MetadataAliasIDs]; got != tt.wantAlias {
+				t.Errorf("alias_ids = %q, want %q", got, tt.wantAlias)
+			}
+		})
+	}
+}
+
+func TestDedupActivityMetadataMerge(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "1", Content: "same", Metadata: map[string]string{"crawled_by": "a", "lang": "en"}},
+		{ID: "2", Content: "same", Metadata: map[string]string{"crawled_by": "b", "team": "ops"}},
+		{ID: "3", Content: "same", Metadata: map[string]string{"crawled_by": "a"}},
+	}
+
+	tests := []struct {
+		policy     MergePolicy
+		want       map[string]string
+		wantMerged int
+	}{
+		{policy: "", want: map[string]string{"crawled_by": "a", "lang": "en"}},
+		{policy: MergePolicyKeepFirst, want: map[string]string{"crawled_by": "a", "lang": "en", "team": "ops"}, wantMerged: 1},
+		{policy: MergePolicyKeepLast, want: map[string]string{"crawled_by": "a", "lang": "en", "team": "ops"}, wantMerged: 3},
+		{policy: MergePolicyConcat, want: map[string]string{"crawled_by": "a,b", "lang": "en", "team": "ops"}, wantMerged: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			t.Parallel()
+
+			out, err := DedupActivity(context.Background(), DedupInput{Documents: docs, Options: DedupOptions{MetadataMerge: tt.policy}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Count != 1 {
+				t.Fatalf("got %d documents, want 1", out.Count)
+			}
+			if got := out.Documents[0].Metadata; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Metadata = %v, want %v", got, tt.want)
+			}
+			if out.MergedKeys != tt.wantMerged {
+				t.Errorf("MergedKeys = %d, want %d", out.MergedKeys, tt.wantMerged)
+			}
+		})
+	}
+
+	if len(docs[0].Metadata) != 2 {
+		t.Errorf("input metadata was mutated: %v", docs[0].Metadata)
+	}
+	if _, err := DedupActivity(context.Background(), DedupInput{Documents: docs, Options: DedupOptions{MetadataMerge: "union"}}); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestDedupActivityDryRun(t *testing.T) {
+	t.Parallel()
+
+	out, err := DedupActivity(context.Background(), DedupInput{
+		Documents: []Document{{ID: "1", Content: "a"}, {ID: "2", Content: "a"}, {ID: "3", Content: "b"}},
+		Options:   DedupOptions{DryRun: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Count != 2 || out.Removed != 1 || len(out.Documents) != 0 {
+		t.Errorf("got Count=%d Removed=%d %d documents, want 2, 1 and none", out.Count, out.Removed, len(out.Documents))
+	}
+}
+
+func TestDedupActivityReport(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "1", Content: "a"}, {ID: "2", Content: "a"}, {ID: "3", Content: "b"}, {ID: "4", Content: "b"}}
+
+	out, err := DedupActivity(context.Background(), DedupInput{Documents: docs, Options: DedupOptions{Report: true, CanonicalizeID: true}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Report) != 2 {
+		t.Fatalf("got %d records, want 2", len(out.Report))
+	}
+	for i, want := range []string{"2", "4"} {
+		record := out.Report[i]
+		if record.ID != want || record.SurvivorID != out.Documents[i].ID || record.Key != DedupKeyContentHash || record.Value != out.Documents[i].ID {
+			t.Errorf("record %d = %+v, want removed %s matching survivor %s", i, record, want, out.Documents[i].ID)
+		}
+	}
+
+	out, err = DedupActivity(context.Background(), DedupInput{Documents: docs})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Report != nil {
+		t.Errorf("Report = %v, want nil without the option", out.Report)
+	}
+}
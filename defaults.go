@@ -0,0 +1,106 @@
+package transform
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// defaults holds package-level settings that may be changed at startup
+// while activities read them from worker goroutines.
+var defaults = struct {
+	mu               sync.RWMutex
+	paragraphMarkers []string
+	clock            Clock
+	logger           *slog.Logger
+}{
+	paragraphMarkers: []string{"\n"},
+	clock:            systemClock{},
+}
+
+// Clock supplies the current time to time-dependent transforms.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+// Now implements Clock.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// systemClock reads the wall clock.
+type systemClock struct{}
+
+// Now implements Clock.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock sets the clock used by time-dependent transforms such as
+// Filter's MaxAge, e.g. to a fixed time in tests. A nil clock restores
+// the system clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+
+	defaults.mu.Lock()
+	defaults.clock = c
+	defaults.mu.Unlock()
+}
+
+// now returns the current time from the configured clock.
+func now() time.Time {
+	defaults.mu.RLock()
+	c := defaults.clock
+	defaults.mu.RUnlock()
+	return c.Now()
+}
+
+// SetLogger sets the logger that receives debug-level events for
+// per-document decisions: documents dropped by Filter, the chunk count of
+// each document split into several chunks, and the survivor of each
+// duplicate removed by Dedup. Events are only built when the logger is
+// enabled for slog.LevelDebug. A nil logger, the default, disables
+// logging.
+func SetLogger(l *slog.Logger) {
+	defaults.mu.Lock()
+	defaults.logger = l
+	defaults.mu.Unlock()
+}
+
+// debugLogger returns the configured logger if it is enabled for debug
+// events, or nil. Callers check it once per activity so disabled logging
+// costs nothing per document.
+func debugLogger(ctx context.Context) *slog.Logger {
+	defaults.mu.RLock()
+	l := defaults.logger
+	defaults.mu.RUnlock()
+	if l == nil || !l.Enabled(ctx, slog.LevelDebug) {
+		return nil
+	}
+	return l
+}
+
+// SetDefaultSeparators sets the fallback paragraph markers used when
+// ChunkOptions.ParagraphMarkers is nil. It is safe to call concurrently
+// with running activities.
+func SetDefaultSeparators(markers ...string) {
+	cp := append([]string(nil), markers...)
+
+	defaults.mu.Lock()
+	defaults.paragraphMarkers = cp
+	defaults.mu.Unlock()
+}
+
+// DefaultParagraphMarkers returns the fallback paragraph markers used
+// when ChunkOptions.ParagraphMarkers is nil.
+func DefaultParagraphMarkers() []string {
+	defaults.mu.RLock()
+	defer defaults.mu.RUnlock()
+	return append([]string(nil), defaults.paragraphMarkers...)
+}
@@ -0,0 +1,133 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDefaultsConcurrentAccess exercises package defaults being changed
+// while activities read them. Run with -race to detect unsynchronized
+// access. It is not parallel because it mutates package state.
+func TestDefaultsConcurrentAccess(t *testing.T) {
+	original := DefaultParagraphMarkers()
+	t.Cleanup(func() { SetDefaultSeparators(original...) })
+
+	doc := Document{ID: "doc", Content: strings.Repeat("line of text\n", 50), Source: "test"}
+	input := ChunkInput{
+		Documents: []Document{doc},
+		Options:   ChunkOptions{MaxTokens: 20, Overlap: 2, Separator: "\n\n"},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := ChunkActivity(context.Background(), input); err != nil {
+					t.Errorf("ChunkActivity: %v", err)
+					return
+				}
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if (i+j)%2 == 0 {
+					SetDefaultSeparators("\n")
+				} else {
+					SetDefaultSeparators("\n", ". ")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSetDefaultSeparatorsCopies(t *testing.T) {
+	original := DefaultParagraphMarkers()
+	t.Cleanup(func() { SetDefaultSeparators(original...) })
+
+	markers := []string{"\n", ";"}
+	SetDefaultSeparators(markers...)
+	markers[1] = "mutated"
+
+	got := DefaultParagraphMarkers()
+	if len(got) != 2 || got[1] != ";" {
+		t.Errorf("DefaultParagraphMarkers() = %q, want [\"\\n\" \";\"]", got)
+	}
+
+	got[0] = "mutated"
+	if DefaultParagraphMarkers()[0] != "\n" {
+		t.Error("modifying returned markers affected the defaults")
+	}
+}
+
+// TestSetLogger is not parallel because it replaces the package logger.
+func TestSetLogger(t *testing.T) {
+	t.Cleanup(func() { SetLogger(nil) })
+	ctx := context.Background()
+
+	run := func(t *testing.T) {
+		t.Helper()
+		if _, err := FilterActivity(ctx, FilterInput{
+			Documents: []Document{{ID: "undated"}},
+			Options:   FilterOptions{MaxAge: time.Hour, DropUndated: true},
+		}); err != nil {
+			t.Fatalf("FilterActivity: %v", err)
+		}
+		if _, err := ChunkActivity(ctx, ChunkInput{
+			Documents: []Document{{ID: "short", Content: "one"}, {ID: "long", Content: "one two three"}},
+			Options:   ChunkOptions{MaxTokens: 2, Separator: " "},
+		}); err != nil {
+			t.Fatalf("ChunkActivity: %v", err)
+		}
+		if _, err := DedupActivity(ctx, DedupInput{
+			Documents: []Document{{ID: "first", Content: "same"}, {ID: "second", Content: "same"}},
+		}); err != nil {
+			t.Fatalf("DedupActivity: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	run(t)
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	want := []struct{ msg, id string }{
+		{"transform: document dropped", "undated"},
+		{"transform: document chunked", "long"},
+		{"transform: duplicate removed", "second"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d:\n%s", len(events), len(want), buf.String())
+	}
+	for i, w := range want {
+		if events[i]["msg"] != w.msg || events[i]["id"] != w.id {
+			t.Errorf("event %d = %v, want %q for %s", i, events[i], w.msg, w.id)
+		}
+	}
+	if events[1]["chunks"] != 2.0 || events[2]["survivor"] != "first" {
+		t.Errorf("got chunks %v and survivor %v, want 2 and first", events[1]["chunks"], events[2]["survivor"])
+	}
+
+	buf.Reset()
+	SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	run(t)
+	if buf.Len() != 0 {
+		t.Errorf("info-level logger got events:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,118 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// DeriveComputation names a built-in computation for Derive.
+type DeriveComputation string
+
+const (
+	// DeriveWordCount counts the whitespace-separated words of Content.
+	DeriveWordCount DeriveComputation = "word_count"
+
+	// DeriveCharCount counts the characters (runes) of Content.
+	DeriveCharCount DeriveComputation = "char_count"
+
+	// DeriveHasURL reports "true" if Content contains an http or https
+	// URL, and "false" otherwise.
+	DeriveHasURL DeriveComputation = "has_url"
+
+	// DeriveTitleLength counts the characters (runes) of Title.
+	DeriveTitleLength DeriveComputation = "title_length"
+)
+
+// DeriveRule stores the result of a computation in a metadata key.
+type DeriveRule struct {
+	Compute DeriveComputation
+
+	// Key is the metadata key to set.
+	// Default: the computation name, e.g. "word_count"
+	Key string
+}
+
+// DeriveInput is the input for the Derive transformer.
+type DeriveInput struct {
+	Documents []Document
+	Rules     []DeriveRule
+}
+
+// DeriveOutput is the output of the Derive transformer.
+type DeriveOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for DeriveOutput.
+func (o DeriveOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// DeriveActivity applies every rule to every document, overwriting any
+// existing value of the rule's key. The input metadata maps are not
+// modified.
+func DeriveActivity(ctx context.Context, input DeriveInput) (DeriveOutput, error) {
+	for _, rule := range input.Rules {
+		if _, ok := deriveFuncs[rule.Compute]; !ok {
+			return DeriveOutput{}, fmt.Errorf("unknown derive computation: %q", rule.Compute)
+		}
+	}
+
+	docs := make([]Document, len(input.Documents))
+	for i, doc := range input.Documents {
+		if len(input.Rules) > 0 {
+			doc.Metadata = copyMetadata(doc.Metadata)
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]string, len(input.Rules))
+			}
+			for _, rule := range input.Rules {
+				key := rule.Key
+				if key == "" {
+					key = string(rule.Compute)
+				}
+				doc.Metadata[key] = deriveFuncs[rule.Compute](doc)
+			}
+		}
+		docs[i] = doc
+	}
+
+	return DeriveOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// Derive creates a node that adds metadata computed from each document
+// with a fixed set of named computations, so rules stay serializable.
+//
+// Example:
+//
+//	transform.Derive([]transform.DeriveRule{
+//	    {Compute: transform.DeriveWordCount},
+//	    {Compute: transform.DeriveHasURL, Key: "links"},
+//	})
+func Derive(rules []DeriveRule) *core.Node[DeriveInput, DeriveOutput] {
+	return core.NewNode("transform.Derive", DeriveActivity, DeriveInput{Rules: rules})
+}
+
+// deriveFuncs implements the built-in computations.
+var deriveFuncs = map[DeriveComputation]func(Document) string{
+	DeriveWordCount: func(doc Document) string {
+		return itoa(len(strings.Fields(doc.Content)))
+	},
+	DeriveCharCount: func(doc Document) string {
+		return itoa(utf8.RuneCountInString(doc.Content))
+	},
+	DeriveHasURL: func(doc Document) string {
+		return strconv.FormatBool(strings.Contains(doc.Content, "http://") || strings.Contains(doc.Content, "https://"))
+	},
+	DeriveTitleLength: func(doc Document) string {
+		return itoa(utf8.RuneCountInString(doc.Title))
+	},
+}
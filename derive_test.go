@@ -0,0 +1,44 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDeriveActivity(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "1", Title: "Résumé", Content: "see https://example.com now", Metadata: map[string]string{"word_count": "stale"}},
+		{ID: "2", Content: "héllo"},
+	}
+	rules := []DeriveRule{
+		{Compute: DeriveWordCount},
+		{Compute: DeriveCharCount, Key: "chars"},
+		{Compute: DeriveHasURL},
+		{Compute: DeriveTitleLength},
+	}
+
+	out, err := DeriveActivity(context.Background(), DeriveInput{Documents: docs, Rules: rules})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []map[string]string{
+		{"word_count": "3", "chars": "27", "has_url": "true", "title_length": "6"},
+		{"word_count": "1", "chars": "5", "has_url": "false", "title_length": "0"},
+	}
+	for i, w := range want {
+		if got := out.Documents[i].Metadata; !reflect.DeepEqual(got, w) {
+			t.Errorf("doc %d metadata = %v, want %v", i, got, w)
+		}
+	}
+	if docs[0].Metadata["word_count"] != "stale" {
+		t.Error("input metadata was mutated")
+	}
+
+	if _, err := DeriveActivity(context.Background(), DeriveInput{Documents: docs, Rules: []DeriveRule{{Compute: "sentiment"}}}); err == nil {
+		t.Error("expected error for unknown computation")
+	}
+}
@@ -1,6 +1,11 @@
 package transform
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+)
 
 // Document is the standard schema for RAG pipelines.
 // All source providers should transform their data to this format.
@@ -11,11 +16,109 @@ type Document struct {
 	Source     string            `json:"source"`
 	URL        string            `json:"url,omitempty"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
+	Attributes Attributes        `json:"attributes,omitempty"`
 	ChunkIndex int               `json:"chunk_index,omitempty"`
 	ParentID   string            `json:"parent_id,omitempty"`
 	UpdatedAt  time.Time         `json:"updated_at"`
 }
 
+// Attributes holds typed document attributes, e.g. for numeric filters
+// that should not parse Metadata strings on every query. Values must be
+// JSON-serializable. They survive a StoreDocuments and LoadDocuments
+// round-trip with these types: whole numbers decode as int64, other
+// numbers as float64, and strings, bools and nulls as string, bool and
+// nil. Arrays and objects decode as []any and map[string]any with the
+// same rules applied to their elements. A Go int or float32 therefore
+// comes back as int64 or float64.
+type Attributes map[string]any
+
+// UnmarshalJSON implements json.Unmarshaler, keeping whole numbers as
+// int64 rather than float64.
+func (a *Attributes) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	for k, v := range m {
+		m[k] = decodeJSONNumbers(v)
+	}
+
+	*a = m
+	return nil
+}
+
+// clone returns a shallow copy of a.
+func (a Attributes) clone() Attributes {
+	if a == nil {
+		return nil
+	}
+	cp := make(Attributes, len(a))
+	for k, v := range a {
+		cp[k] = v
+	}
+	return cp
+}
+
+// deepClone returns a copy of a that shares no maps or slices with it.
+// Nested map[string]any and []any values, the shapes Attributes decode
+// to, are copied recursively; other values are copied as they are.
+func (a Attributes) deepClone() Attributes {
+	if a == nil {
+		return nil
+	}
+	cp := make(Attributes, len(a))
+	for k, v := range a {
+		cp[k] = cloneAttributeValue(v)
+	}
+	return cp
+}
+
+// cloneAttributeValue deep-copies the maps and slices in an attribute
+// value.
+func cloneAttributeValue(v any) any {
+	switch v := v.(type) {
+	case Attributes:
+		return v.deepClone()
+	case map[string]any:
+		return map[string]any(Attributes(v).deepClone())
+	case []any:
+		if v == nil {
+			return v
+		}
+		cp := make([]any, len(v))
+		for i, e := range v {
+			cp[i] = cloneAttributeValue(e)
+		}
+		return cp
+	}
+	return v
+}
+
+// decodeJSONNumbers replaces the json.Number values in v with int64 or
+// float64.
+func decodeJSONNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i, e := range v {
+			v[i] = decodeJSONNumbers(e)
+		}
+	case map[string]any:
+		for k, e := range v {
+			v[k] = decodeJSONNumbers(e)
+		}
+	}
+	return v
+}
+
 // DocumentSource is implemented by types that can produce Documents.
 type DocumentSource interface {
 	ToDocuments() []Document
@@ -27,6 +130,25 @@ type DocumentWithEmbedding struct {
 	Embedding []float32 `json:"embedding"`
 }
 
+// EmbeddedBatch is a collection of embedded documents that composes with
+// document transforms via DocumentSource.
+type EmbeddedBatch []DocumentWithEmbedding
+
+// ToDocuments implements DocumentSource for EmbeddedBatch.
+// The embeddings are dropped.
+func (b EmbeddedBatch) ToDocuments() []Document {
+	docs := make([]Document, len(b))
+	for i, item := range b {
+		docs[i] = item.Document
+	}
+	return docs
+}
+
+// ToEmbedded returns the embedded documents.
+func (b EmbeddedBatch) ToEmbedded() []DocumentWithEmbedding {
+	return b
+}
+
 // NewDocument creates a new Document with required fields.
 func NewDocument(id, content, source string) Document {
 	return Document{
@@ -34,7 +156,7 @@ func NewDocument(id, content, source string) Document {
 		Content:   content,
 		Source:    source,
 		Metadata:  make(map[string]string),
-		UpdatedAt: time.Now(),
+		UpdatedAt: now(),
 	}
 }
 
@@ -59,20 +181,45 @@ func (d Document) WithMetadata(key, value string) Document {
 	return d
 }
 
+// withMetadataCopy sets a metadata key on a copy of the metadata map, so
+// maps shared with other documents are never mutated.
+func (d Document) withMetadataCopy(key, value string) Document {
+	d.Metadata = copyMetadata(d.Metadata)
+	if d.Metadata == nil {
+		d.Metadata = make(map[string]string, 1)
+	}
+	d.Metadata[key] = value
+	return d
+}
+
 // WithUpdatedAt sets the document update time.
 func (d Document) WithUpdatedAt(t time.Time) Document {
 	d.UpdatedAt = t
 	return d
 }
 
+// ChunkIDSeparator separates the parent ID from the chunk index in chunk IDs.
+const ChunkIDSeparator = "#"
+
 // AsChunk marks this document as a chunk of a parent document.
 func (d Document) AsChunk(parentID string, index int) Document {
 	d.ParentID = parentID
 	d.ChunkIndex = index
-	d.ID = parentID + "#" + string(rune('0'+index))
+	d.ID = ChunkID(parentID, index, ChunkIDSeparator, 0)
 	return d
 }
 
+// ChunkID builds the ID of the chunk at index within parentID, e.g.
+// "doc#3". The index is written in decimal and left-padded with zeros
+// to at least pad digits.
+func ChunkID(parentID string, index int, sep string, pad int) string {
+	digits := itoa(index)
+	if index >= 0 && len(digits) < pad {
+		digits = strings.Repeat("0", pad-len(digits)) + digits
+	}
+	return parentID + sep + digits
+}
+
 // IsChunk returns true if this document is a chunk of a larger document.
 func (d Document) IsChunk() bool {
 	return d.ParentID != ""
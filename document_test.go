@@ -0,0 +1,61 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		parent string
+		index  int
+		sep    string
+		pad    int
+		want   string
+	}{
+		{name: "default", parent: "doc", index: 3, sep: ChunkIDSeparator, want: "doc#3"},
+		{name: "multi digit", parent: "doc", index: 12, sep: ChunkIDSeparator, want: "doc#12"},
+		{name: "padded", parent: "doc", index: 7, sep: "-", pad: 4, want: "doc-0007"},
+		{name: "pad shorter than index", parent: "doc", index: 12345, sep: "-", pad: 2, want: "doc-12345"},
+		{name: "unicode parent", parent: "документ", index: 10, sep: ChunkIDSeparator, want: "документ#10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ChunkID(tt.parent, tt.index, tt.sep, tt.pad); got != tt.want {
+				t.Errorf("ChunkID(%q, %d, %q, %d) = %q, want %q", tt.parent, tt.index, tt.sep, tt.pad, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkIDsValidAndUnique(t *testing.T) {
+	t.Parallel()
+
+	seen := make(map[string]int)
+	for i := 0; i <= 1000; i++ {
+		id := Document{}.AsChunk("doc", i).ID
+		suffix := strings.TrimPrefix(id, "doc"+ChunkIDSeparator)
+		if suffix != itoa(i) {
+			t.Fatalf("AsChunk(%d).ID = %q, want suffix %q", i, id, itoa(i))
+		}
+		for _, r := range suffix {
+			if r < '0' || r > '9' {
+				t.Fatalf("AsChunk(%d).ID = %q contains non-digit %q", i, id, r)
+			}
+		}
+		if prev, ok := seen[id]; ok {
+			t.Fatalf("AsChunk(%d).ID = %q duplicates index %d", i, id, prev)
+		}
+		seen[id] = i
+
+		if chunk := newChunk(Document{ID: "doc"}, i, ""); chunk.ID != id {
+			t.Fatalf("newChunk(%d).ID = %q, want %q", i, chunk.ID, id)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// Embedder computes vector embeddings for a batch of texts. It returns
+// one vector per text, in order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+var embedders = struct {
+	mu        sync.RWMutex
+	embedders map[string]Embedder
+}{
+	embedders: make(map[string]Embedder),
+}
+
+// RegisterEmbedder registers an embedder under name, replacing any existing
+// registration, so nodes can reference it by name. Embedders may be used
+// concurrently. Register embedders on every worker before starting it.
+func RegisterEmbedder(name string, e Embedder) {
+	embedders.mu.Lock()
+	defer embedders.mu.Unlock()
+	embedders.embedders[name] = e
+}
+
+// RegisteredEmbedders returns the names of all registered embedders.
+func RegisteredEmbedders() []string {
+	embedders.mu.RLock()
+	defer embedders.mu.RUnlock()
+	return sortedKeys(embedders.embedders)
+}
+
+// lookupEmbedder returns the embedder registered under name.
+func lookupEmbedder(name string) (Embedder, error) {
+	embedders.mu.RLock()
+	defer embedders.mu.RUnlock()
+	e, ok := embedders.embedders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown embedder: %q", name)
+	}
+	return e, nil
+}
+
+// DefaultEmbedBatchSize is the number of documents embedded per call when
+// EmbedOptions.BatchSize is zero.
+const DefaultEmbedBatchSize = 64
+
+// DefaultEmbedBackoff is the delay before the first retry when
+// EmbedOptions.InitialBackoff is zero.
+const DefaultEmbedBackoff = 500 * time.Millisecond
+
+// ErrRateLimited marks an embedding error as retriable, e.g. an HTTP 429
+// from the provider. Embedders wrap it with fmt.Errorf("...: %w", ...).
+// Errors with a Temporary() bool method reporting true are retried too.
+var ErrRateLimited = errors.New("rate limited")
+
+// EmbedOptions configures document embedding.
+type EmbedOptions struct {
+	// Embedder names a registered Embedder (see RegisterEmbedder).
+	Embedder string
+
+	// BatchSize is the number of documents passed to each Embed call.
+	// Default: 64
+	BatchSize int
+
+	// MaxRetries is the number of times a batch is retried after a
+	// retriable error (see ErrRateLimited), with exponential backoff and
+	// jitter starting at InitialBackoff.
+	// Default: 0 (no retries)
+	MaxRetries int
+
+	// InitialBackoff is the delay before the first retry. Each further
+	// retry doubles it.
+	// Default: 500ms
+	InitialBackoff time.Duration
+
+	// MaxConcurrency bounds the number of Embed calls in flight.
+	// Default: 1
+	MaxConcurrency int
+
+	// ContinueOnError keeps embedding the remaining batches when a batch
+	// fails after its retries. The failed documents are left out of the
+	// result and reported by ID.
+	ContinueOnError bool
+
+	// Dimension is the expected embedding dimension. The embedded
+	// documents are validated with ValidateDimensions before they are
+	// stored, so a misconfigured embedder fails here rather than at the
+	// vector store.
+	// Default: 0 (the dimension of the first embedding)
+	Dimension int
+}
+
+// EmbedError reports documents that could not be embedded.
+type EmbedError struct {
+	// IDs lists the unembedded documents, in input order.
+	IDs []string
+
+	// Err is the error of the first failed batch.
+	Err error
+}
+
+// Error implements error.
+func (e *EmbedError) Error() string {
+	return fmt.Sprintf("%d documents not embedded (%s): %v", len(e.IDs), strings.Join(e.IDs, ", "), e.Err)
+}
+
+// Unwrap returns the error of the first failed batch.
+func (e *EmbedError) Unwrap() error {
+	return e.Err
+}
+
+// EmbedRefInput is the input for EmbedRefActivity.
+type EmbedRefInput struct {
+	SourceRef core.DataRef
+	Options   EmbedOptions
+}
+
+// EmbedRefOutput is the output of EmbedRefActivity. Ref holds a slice of
+// DocumentWithEmbedding; see LoadEmbeddedDocuments.
+type EmbedRefOutput struct {
+	Ref   core.DataRef
+	Count int
+
+	// Failed lists the documents left unembedded under
+	// EmbedOptions.ContinueOnError.
+	Failed []string
+}
+
+// EmbedRefActivity loads documents from SourceRef, embeds their content,
+// and stores the embedded documents as a new DataRef. Under
+// ContinueOnError the activity succeeds with the embedded documents and
+// reports the rest in Failed, so a workflow can decide whether to retry
+// them; otherwise any failed batch fails the activity. A canceled or
+// expired ctx always fails the activity.
+func EmbedRefActivity(ctx context.Context, input EmbedRefInput) (EmbedRefOutput, error) {
+	embedder, err := lookupEmbedder(input.Options.Embedder)
+	if err != nil {
+		return EmbedRefOutput{}, err
+	}
+
+	docs, err := LoadDocuments(ctx, input.SourceRef)
+	if err != nil {
+		return EmbedRefOutput{}, err
+	}
+
+	embedded, err := embedDocuments(ctx, embedder, docs, input.Options)
+	var embedErr *EmbedError
+	if err != nil && !(input.Options.ContinueOnError && errors.As(err, &embedErr)) {
+		return EmbedRefOutput{}, err
+	}
+
+	ref, err := storeEmbeddedDocuments(ctx, embedded, input.Options.Dimension)
+	if err != nil {
+		return EmbedRefOutput{}, err
+	}
+
+	out := EmbedRefOutput{
+		Ref:   ref,
+		Count: len(embedded),
+	}
+	if embedErr != nil {
+		out.Failed = embedErr.IDs
+	}
+	return out, nil
+}
+
+// EmbedRef creates a node that embeds the documents in a DataRef.
+//
+// Example:
+//
+//	transform.EmbedRef(transform.EmbedRefInput{
+//	    SourceRef: core.OutputRef("chunks"),
+//	    Options:   transform.EmbedOptions{Embedder: "ollama"},
+//	})
+func EmbedRef(input EmbedRefInput) *core.Node[EmbedRefInput, EmbedRefOutput] {
+	return core.NewNode("transform.EmbedRef", EmbedRefActivity, input)
+}
+
+// embedDocuments embeds docs in batches with up to opts.MaxConcurrency
+// batches in flight, retrying retriable errors. Under ContinueOnError it
+// returns the embedded documents along with an *EmbedError listing the
+// rest; otherwise the first failure cancels the remaining batches. No
+// batch is dispatched once ctx is done, and a batch that failed because
+// ctx was canceled or expired fails the call with ctx's error even under
+// ContinueOnError.
+func embedDocuments(ctx context.Context, embedder Embedder, docs []Document, opts EmbedOptions) ([]DocumentWithEmbedding, error) {
+	parent := ctx
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultEmbedBatchSize
+	}
+	concurrency := max(opts.MaxConcurrency, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	numBatches := (len(docs) + batchSize - 1) / batchSize
+	vectors := make([][][]float32, numBatches)
+	errs := make([]error, numBatches)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for b := 0; b < numBatches; b++ {
+		start := b * batchSize
+		end := min(start+batchSize, len(docs))
+
+		// Once ctx is done, by the caller or by a failed batch, the
+		// remaining batches are not dispatched and fail with its error.
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			for ; b < numBatches; b++ {
+				start := b * batchSize
+				errs[b] = fmt.Errorf("embed documents %d-%d: %w", start, min(start+batchSize, len(docs))-1, err)
+			}
+			break
+		}
+
+		texts := make([]string, end-start)
+		for i, doc := range docs[start:end] {
+			texts[i] = doc.Content
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			v, err := embedWithRetry(ctx, embedder, texts, opts)
+			if err == nil && len(v) != len(texts) {
+				err = fmt.Errorf("embedder returned %d vectors for %d documents", len(v), len(texts))
+			}
+			if err != nil {
+				errs[b] = fmt.Errorf("embed documents %d-%d: %w", start, end-1, err)
+				if !opts.ContinueOnError {
+					cancel()
+				}
+				return
+			}
+			vectors[b] = v
+		}()
+	}
+	wg.Wait()
+
+	embedded := make([]DocumentWithEmbedding, 0, len(docs))
+	var embedErr *EmbedError
+	for b := range vectors {
+		start := b * batchSize
+		end := min(start+batchSize, len(docs))
+
+		if errs[b] != nil {
+			if !opts.ContinueOnError {
+				return nil, firstEmbedError(errs)
+			}
+			if parent.Err() != nil && (errors.Is(errs[b], context.Canceled) || errors.Is(errs[b], context.DeadlineExceeded)) {
+				return nil, parent.Err()
+			}
+			if embedErr == nil {
+				embedErr = &EmbedError{Err: errs[b]}
+			}
+			for _, doc := range docs[start:end] {
+				embedErr.IDs = append(embedErr.IDs, doc.ID)
+			}
+			continue
+		}
+		for i, doc := range docs[start:end] {
+			embedded = append(embedded, DocumentWithEmbedding{Document: doc, Embedding: vectors[b][i]})
+		}
+	}
+
+	if embedErr != nil {
+		return embedded, embedErr
+	}
+	return embedded, nil
+}
+
+// firstEmbedError returns the first batch error that is not a
+// cancellation caused by another batch failing.
+func firstEmbedError(errs []error) error {
+	var canceled error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+		if canceled == nil {
+			canceled = err
+		}
+	}
+	return canceled
+}
+
+// embedWithRetry calls Embed, retrying retriable errors up to
+// opts.MaxRetries times with exponential backoff and jitter.
+func embedWithRetry(ctx context.Context, embedder Embedder, texts []string, opts EmbedOptions) ([][]float32, error) {
+	backoff := opts.InitialBackoff
+	if backoff <= 0 {
+		backoff = DefaultEmbedBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		vectors, err := embedder.Embed(ctx, texts)
+		if err == nil || attempt >= opts.MaxRetries || !retriable(err) {
+			return vectors, err
+		}
+
+		// Sleep between half and all of the backoff so concurrent
+		// batches do not retry in lockstep.
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// retriable reports whether err is worth retrying.
+func retriable(err error) bool {
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// StoreEmbeddedDocuments stores embedded documents and returns a DataRef
+// with their Count and the storage layer's Checksum, like StoreDocuments
+// does for documents. All embeddings must be non-empty and share a
+// dimension (see ValidateDimensions).
+func StoreEmbeddedDocuments(ctx context.Context, docs []DocumentWithEmbedding) (core.DataRef, error) {
+	return storeEmbeddedDocuments(ctx, docs, 0)
+}
+
+// storeEmbeddedDocuments is StoreEmbeddedDocuments with the embeddings
+// validated against dimension want, or against the first embedding's
+// dimension when want is zero.
+func storeEmbeddedDocuments(ctx context.Context, docs []DocumentWithEmbedding, want int) (core.DataRef, error) {
+	if _, err := ValidateDimensions(docs, want); err != nil {
+		return core.DataRef{}, err
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return core.DataRef{}, fmt.Errorf("get storage: %w", err)
+	}
+
+	ref, err := storage.StoreJSON(ctx, SchemaEmbeddedDocuments, docs)
+	if err != nil {
+		return core.DataRef{}, fmt.Errorf("store embedded documents: %w", err)
+	}
+
+	ref.Count = len(docs)
+	return ref, nil
+}
+
+// LoadEmbeddedDocuments loads embedded documents from a DataRef written by
+// StoreEmbeddedDocuments; refs of any other schema are rejected.
+func LoadEmbeddedDocuments(ctx context.Context, ref core.DataRef) ([]DocumentWithEmbedding, error) {
+	if ref.Schema != SchemaEmbeddedDocuments {
+		return nil, fmt.Errorf("schema mismatch: expected %s, got %s", SchemaEmbeddedDocuments, ref.Schema)
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return nil, fmt.Errorf("get storage: %w", err)
+	}
+
+	var docs []DocumentWithEmbedding
+	if err := storage.LoadJSON(ctx, ref, &docs); err != nil {
+		return nil, fmt.Errorf("load embedded documents: %w", err)
+	}
+	if docs == nil {
+		docs = []DocumentWithEmbedding{}
+	}
+
+	return docs, nil
+}
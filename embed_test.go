@@ -0,0 +1,263 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyEmbedder rate-limits the first failures calls, rejects batches
+// containing "bad", and records the peak number of concurrent calls.
+type flakyEmbedder struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	inFlight int
+	peak     int
+}
+
+func (e *flakyEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.calls++
+	e.inFlight++
+	e.peak = max(e.peak, e.inFlight)
+	limited := e.failures > 0
+	if limited {
+		e.failures--
+	}
+	e.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	e.mu.Lock()
+	e.inFlight--
+	e.mu.Unlock()
+
+	if limited {
+		return nil, fmt.Errorf("status 429: %w", ErrRateLimited)
+	}
+	for _, text := range texts {
+		if strings.Contains(text, "bad") {
+			return nil, errors.New("invalid input")
+		}
+	}
+	return lengthEmbedder{}.Embed(ctx, texts)
+}
+
+func TestEmbedDocumentsRetries(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "1", Content: "a"}, {ID: "2", Content: "bb"}}
+	opts := EmbedOptions{BatchSize: 1, MaxRetries: 3, InitialBackoff: time.Millisecond}
+
+	e := &flakyEmbedder{failures: 2}
+	embedded, err := embedDocuments(context.Background(), e, docs, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(embedded) != 2 || embedded[1].Document.ID != "2" {
+		t.Errorf("embedded %+v, want both documents in order", embedded)
+	}
+
+	opts.MaxRetries = 1
+	e = &flakyEmbedder{failures: 10}
+	if _, err := embedDocuments(context.Background(), e, docs[:1], opts); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited after retries", err)
+	}
+	if e.calls != 2 {
+		t.Errorf("Embed called %d times, want 2", e.calls)
+	}
+}
+
+// cancelingEmbedder cancels its context on the first call and counts
+// calls.
+type cancelingEmbedder struct {
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	calls  int
+}
+
+func (e *cancelingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	e.cancel()
+	return lengthEmbedder{}.Embed(ctx, texts)
+}
+
+func TestEmbedDocumentsStopsOnCancel(t *testing.T) {
+	t.Parallel()
+
+	docs := make([]Document, 5)
+	for i := range docs {
+		docs[i] = Document{ID: itoa(i), Content: "text"}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := &cancelingEmbedder{cancel: cancel}
+
+	_, err := embedDocuments(ctx, e, docs, EmbedOptions{BatchSize: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if e.calls != 1 {
+		t.Errorf("Embed called %d times, want 1", e.calls)
+	}
+}
+
+func TestEmbedRefActivityFailsOnCancel(t *testing.T) {
+	t.Parallel()
+
+	docs := make([]Document, 5)
+	for i := range docs {
+		docs[i] = Document{ID: itoa(i), Content: "text"}
+	}
+	ref, err := StoreDocuments(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	// Under ContinueOnError, batches left undispatched by a caller
+	// cancellation must not be reported as per-document failures.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	RegisterEmbedder("test_canceling", &cancelingEmbedder{cancel: cancel})
+
+	out, err := EmbedRefActivity(ctx, EmbedRefInput{
+		SourceRef: ref,
+		Options:   EmbedOptions{Embedder: "test_canceling", BatchSize: 1, ContinueOnError: true},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if out.Failed != nil {
+		t.Errorf("Failed = %v, want none", out.Failed)
+	}
+}
+
+func TestEmbedDocumentsContinueOnError(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "1", Content: "ok"},
+		{ID: "2", Content: "bad"},
+		{ID: "3", Content: "ok"},
+		{ID: "4", Content: "bad"},
+		{ID: "5", Content: "ok"},
+	}
+
+	e := &flakyEmbedder{}
+	opts := EmbedOptions{BatchSize: 1, MaxConcurrency: 2, ContinueOnError: true}
+	embedded, err := embedDocuments(context.Background(), e, docs, opts)
+
+	var embedErr *EmbedError
+	if !errors.As(err, &embedErr) {
+		t.Fatalf("err = %v, want *EmbedError", err)
+	}
+	if got := strings.Join(embedErr.IDs, ","); got != "2,4" {
+		t.Errorf("failed IDs = %q, want %q", got, "2,4")
+	}
+	if len(embedded) != 3 {
+		t.Errorf("embedded %d documents, want 3", len(embedded))
+	}
+	if e.peak > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", e.peak)
+	}
+
+	opts.ContinueOnError = false
+	if _, err := embedDocuments(context.Background(), &flakyEmbedder{}, docs, opts); err == nil || errors.As(err, &embedErr) {
+		t.Errorf("err = %v, want a plain batch error", err)
+	}
+}
+
+func TestEmbedRefActivityDimension(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	RegisterEmbedder("test_dimension", lengthEmbedder{})
+
+	ref, err := StoreDocuments(ctx, []Document{{ID: "1", Content: "a"}, {ID: "2", Content: "bb"}})
+	if err != nil {
+		t.Fatalf("StoreDocuments: %v", err)
+	}
+
+	out, err := EmbedRefActivity(ctx, EmbedRefInput{SourceRef: ref, Options: EmbedOptions{Embedder: "test_dimension", Dimension: 1}})
+	if err != nil || out.Count != 2 {
+		t.Fatalf("got %+v, %v; want 2 documents embedded", out, err)
+	}
+
+	_, err = EmbedRefActivity(ctx, EmbedRefInput{SourceRef: ref, Options: EmbedOptions{Embedder: "test_dimension", Dimension: 3}})
+	var dimErr *DimensionError
+	if !errors.As(err, &dimErr) {
+		t.Fatalf("err = %v, want *DimensionError", err)
+	}
+	if got := strings.Join(dimErr.IDs, ","); got != "1,2" || dimErr.Want != 3 {
+		t.Errorf("got Want=%d IDs %q, want 3 and %q", dimErr.Want, got, "1,2")
+	}
+}
+
+func TestStoreEmbeddedDocumentsMixedDimensions(t *testing.T) {
+	t.Parallel()
+
+	docs := []DocumentWithEmbedding{
+		{Document: Document{ID: "a"}, Embedding: []float32{1, 2}},
+		{Document: Document{ID: "b"}, Embedding: []float32{1, 2, 3}},
+		{Document: Document{ID: "c"}},
+	}
+	_, err := StoreEmbeddedDocuments(context.Background(), docs)
+
+	var dimErr *DimensionError
+	if !errors.As(err, &dimErr) {
+		t.Fatalf("err = %v, want *DimensionError", err)
+	}
+	if got := strings.Join(dimErr.IDs, ","); got != "b,c" || dimErr.Want != 2 {
+		t.Errorf("got Want=%d IDs %q, want 2 and %q", dimErr.Want, got, "b,c")
+	}
+}
+
+func TestStoreEmbeddedDocumentsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := []DocumentWithEmbedding{
+		{Document: Document{ID: "a", Content: "alpha"}, Embedding: []float32{1, 0}},
+		{Document: Document{ID: "b", Content: "beta"}, Embedding: []float32{0, 1}},
+	}
+
+	ref, err := StoreEmbeddedDocuments(ctx, docs)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if ref.Schema != SchemaEmbeddedDocuments || ref.Count != len(docs) || ref.Checksum == "" {
+		t.Errorf("ref = %+v, want schema %s, count %d and a checksum", ref, SchemaEmbeddedDocuments, len(docs))
+	}
+
+	loaded, err := LoadEmbeddedDocuments(ctx, ref)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, docs) {
+		t.Errorf("loaded %+v, want %+v", loaded, docs)
+	}
+
+	again, err := StoreEmbeddedDocuments(ctx, docs)
+	if err != nil {
+		t.Fatalf("store again: %v", err)
+	}
+	if again.Checksum != ref.Checksum {
+		t.Errorf("checksum %q changed to %q for the same documents", ref.Checksum, again.Checksum)
+	}
+
+	docsRef, err := StoreDocuments(ctx, []Document{docs[0].Document})
+	if err != nil {
+		t.Fatalf("store documents: %v", err)
+	}
+	if _, err := LoadEmbeddedDocuments(ctx, docsRef); err == nil || !strings.Contains(err.Error(), "schema mismatch") {
+		t.Errorf("err = %v, want schema mismatch", err)
+	}
+}
@@ -0,0 +1,200 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// EmbeddingDedupOptions configures near-duplicate removal by embedding.
+type EmbeddingDedupOptions struct {
+	// Threshold is the cosine similarity above which a document is
+	// considered a near-duplicate of an already kept document.
+	Threshold float32
+
+	// Approximate compares each document only against kept documents that
+	// share a locality-sensitive hash bucket, avoiding O(n²) comparisons
+	// at the cost of missing some near-duplicates.
+	Approximate bool
+
+	// HashBits is the number of random hyperplanes per LSH table.
+	// Default: 12
+	HashBits int
+
+	// HashTables is the number of independent LSH tables. More tables
+	// find more near-duplicates at higher cost.
+	// Default: 4
+	HashTables int
+
+	// Seed seeds the random hyperplanes so results are reproducible.
+	Seed int64
+}
+
+// DedupByEmbeddingInput is the input for the DedupByEmbedding transformer.
+type DedupByEmbeddingInput struct {
+	Documents []DocumentWithEmbedding
+	Options   EmbeddingDedupOptions
+}
+
+// DedupByEmbeddingOutput is the output of the DedupByEmbedding transformer.
+type DedupByEmbeddingOutput struct {
+	Documents []DocumentWithEmbedding
+	Count     int
+	Removed   int
+}
+
+// ToDocuments implements DocumentSource for DedupByEmbeddingOutput.
+func (o DedupByEmbeddingOutput) ToDocuments() []Document {
+	return EmbeddedBatch(o.Documents).ToDocuments()
+}
+
+// DedupByEmbeddingActivity removes documents whose embedding's cosine
+// similarity to an already kept document exceeds the threshold. Documents
+// are considered in order, so the first of a group of near-duplicates is
+// kept.
+func DedupByEmbeddingActivity(ctx context.Context, input DedupByEmbeddingInput) (DedupByEmbeddingOutput, error) {
+	opts := input.Options
+	if opts.HashBits == 0 {
+		opts.HashBits = 12
+	}
+	if opts.HashTables == 0 {
+		opts.HashTables = 4
+	}
+	if opts.HashBits < 0 || opts.HashBits > 64 {
+		return DedupByEmbeddingOutput{}, fmt.Errorf("hash bits must not be negative or exceed 64, got %d", opts.HashBits)
+	}
+	if opts.HashTables < 0 {
+		return DedupByEmbeddingOutput{}, fmt.Errorf("hash tables must not be negative, got %d", opts.HashTables)
+	}
+
+	items := input.Documents
+	kept := make([]DocumentWithEmbedding, 0, len(items))
+	if len(items) == 0 {
+		return DedupByEmbeddingOutput{Documents: kept}, nil
+	}
+
+	dim := len(items[0].Embedding)
+	for _, item := range items {
+		if len(item.Embedding) != dim {
+			return DedupByEmbeddingOutput{}, fmt.Errorf("document %s: embedding dimension %d, want %d",
+				item.Document.ID, len(item.Embedding), dim)
+		}
+	}
+
+	var index *lshIndex
+	if opts.Approximate {
+		index = newLSHIndex(dim, opts.HashBits, opts.HashTables, opts.Seed)
+	}
+
+	for _, item := range items {
+		candidates := kept
+		var keys []uint64
+		if index != nil {
+			keys = index.keys(item.Embedding)
+			candidates = index.candidates(keys, kept)
+		}
+
+		duplicate := false
+		for _, other := range candidates {
+			if cosine(item.Embedding, other.Embedding) > opts.Threshold {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		if index != nil {
+			index.add(keys, len(kept))
+		}
+		kept = append(kept, item)
+	}
+
+	return DedupByEmbeddingOutput{
+		Documents: kept,
+		Count:     len(kept),
+		Removed:   len(items) - len(kept),
+	}, nil
+}
+
+// DedupByEmbedding creates a node that removes near-duplicate documents
+// whose embeddings have cosine similarity above threshold.
+func DedupByEmbedding(threshold float32) *core.Node[DedupByEmbeddingInput, DedupByEmbeddingOutput] {
+	return DedupByEmbeddingWith(EmbeddingDedupOptions{Threshold: threshold})
+}
+
+// DedupByEmbeddingWith creates an embedding dedup node configured with opts.
+func DedupByEmbeddingWith(opts EmbeddingDedupOptions) *core.Node[DedupByEmbeddingInput, DedupByEmbeddingOutput] {
+	return core.NewNode("transform.DedupByEmbedding", DedupByEmbeddingActivity, DedupByEmbeddingInput{Options: opts})
+}
+
+// lshIndex buckets vectors by random-hyperplane signatures for
+// approximate cosine similarity search.
+type lshIndex struct {
+	planes  [][][]float32
+	buckets []map[uint64][]int
+}
+
+func newLSHIndex(dim, bits, tables int, seed int64) *lshIndex {
+	rng := rand.New(rand.NewSource(seed))
+	idx := &lshIndex{
+		planes:  make([][][]float32, tables),
+		buckets: make([]map[uint64][]int, tables),
+	}
+	for t := range idx.planes {
+		idx.planes[t] = make([][]float32, bits)
+		for b := range idx.planes[t] {
+			plane := make([]float32, dim)
+			for d := range plane {
+				plane[d] = float32(rng.NormFloat64())
+			}
+			idx.planes[t][b] = plane
+		}
+		idx.buckets[t] = make(map[uint64][]int)
+	}
+	return idx
+}
+
+// keys returns v's bucket key in each table.
+func (idx *lshIndex) keys(v []float32) []uint64 {
+	keys := make([]uint64, len(idx.planes))
+	for t, planes := range idx.planes {
+		var key uint64
+		for b, plane := range planes {
+			var dot float32
+			for d := range plane {
+				dot += plane[d] * v[d]
+			}
+			if dot >= 0 {
+				key |= 1 << uint(b)
+			}
+		}
+		keys[t] = key
+	}
+	return keys
+}
+
+// candidates returns the kept items sharing a bucket with keys.
+func (idx *lshIndex) candidates(keys []uint64, kept []DocumentWithEmbedding) []DocumentWithEmbedding {
+	seen := make(map[int]bool)
+	var out []DocumentWithEmbedding
+	for t, key := range keys {
+		for _, i := range idx.buckets[t][key] {
+			if !seen[i] {
+				seen[i] = true
+				out = append(out, kept[i])
+			}
+		}
+	}
+	return out
+}
+
+// add records the kept item at position i under keys.
+func (idx *lshIndex) add(keys []uint64, i int) {
+	for t, key := range keys {
+		idx.buckets[t][key] = append(idx.buckets[t][key], i)
+	}
+}
@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDedupByEmbeddingActivity(t *testing.T) {
+	t.Parallel()
+
+	items := []DocumentWithEmbedding{
+		{Document: Document{ID: "a"}, Embedding: []float32{1, 0, 0}},
+		{Document: Document{ID: "a-near"}, Embedding: []float32{0.99, 0.05, 0}},
+		{Document: Document{ID: "b"}, Embedding: []float32{0, 1, 0}},
+		{Document: Document{ID: "a-scaled"}, Embedding: []float32{3, 0, 0}},
+		{Document: Document{ID: "c"}, Embedding: []float32{0, 0, 1}},
+	}
+
+	for _, approximate := range []bool{false, true} {
+		out, err := DedupByEmbeddingActivity(context.Background(), DedupByEmbeddingInput{
+			Documents: items,
+			Options:   EmbeddingDedupOptions{Threshold: 0.95, Approximate: approximate, HashTables: 8},
+		})
+		if err != nil {
+			t.Fatalf("approximate=%v: unexpected error: %v", approximate, err)
+		}
+
+		wantIDs := []string{"a", "b", "c"}
+		if out.Removed != 2 || len(out.Documents) != len(wantIDs) {
+			t.Fatalf("approximate=%v: kept %d, removed %d; want 3 kept, 2 removed", approximate, len(out.Documents), out.Removed)
+		}
+		for i, id := range wantIDs {
+			if out.Documents[i].Document.ID != id {
+				t.Errorf("approximate=%v: kept[%d] = %q, want %q", approximate, i, out.Documents[i].Document.ID, id)
+			}
+		}
+	}
+}
+
+func TestDedupByEmbeddingDimensionMismatch(t *testing.T) {
+	t.Parallel()
+
+	_, err := DedupByEmbeddingActivity(context.Background(), DedupByEmbeddingInput{
+		Documents: []DocumentWithEmbedding{
+			{Document: Document{ID: "a"}, Embedding: []float32{1, 0}},
+			{Document: Document{ID: "b"}, Embedding: []float32{1, 0, 0}},
+		},
+		Options: EmbeddingDedupOptions{Threshold: 0.9},
+	})
+	if err == nil {
+		t.Fatal("expected dimension mismatch error, got nil")
+	}
+}
+
+func TestDedupByEmbeddingInvalidLSHOptions(t *testing.T) {
+	t.Parallel()
+
+	items := []DocumentWithEmbedding{{Document: Document{ID: "a"}, Embedding: []float32{1, 0}}}
+	for _, opts := range []EmbeddingDedupOptions{
+		{Approximate: true, HashBits: -1},
+		{Approximate: true, HashBits: 65},
+		{Approximate: true, HashTables: -1},
+	} {
+		input := DedupByEmbeddingInput{Documents: items, Options: opts}
+		if _, err := DedupByEmbeddingActivity(context.Background(), input); err == nil {
+			t.Errorf("options %+v: expected error", opts)
+		}
+	}
+}
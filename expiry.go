@@ -0,0 +1,75 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// MetadataExpiresAt is the metadata key holding a document's RFC 3339
+// expiry time, checked by PurgeExpired.
+const MetadataExpiresAt = "expires_at"
+
+// PurgeExpiredInput is the input for the PurgeExpired transformer.
+type PurgeExpiredInput struct {
+	Documents []Document
+}
+
+// PurgeExpiredOutput is the output of the PurgeExpired transformer.
+type PurgeExpiredOutput struct {
+	Documents []Document
+	Count     int
+	Purged    int
+}
+
+// ToDocuments implements DocumentSource for PurgeExpiredOutput.
+func (o PurgeExpiredOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// PurgeExpiredActivity drops documents whose Metadata["expires_at"] is at
+// or before the current time of the package clock (see SetClock).
+// Documents without the key are kept. A value that is not RFC 3339 is an
+// error rather than being kept silently.
+func PurgeExpiredActivity(ctx context.Context, input PurgeExpiredInput) (PurgeExpiredOutput, error) {
+	current := now()
+	docs := make([]Document, 0, len(input.Documents))
+
+	for _, doc := range input.Documents {
+		value, ok := doc.Metadata[MetadataExpiresAt]
+		if !ok {
+			docs = append(docs, doc)
+			continue
+		}
+
+		expiresAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return PurgeExpiredOutput{}, fmt.Errorf("document %s: parse %s: %w", doc.ID, MetadataExpiresAt, err)
+		}
+		if current.Before(expiresAt) {
+			docs = append(docs, doc)
+		}
+	}
+
+	return PurgeExpiredOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Purged:    len(input.Documents) - len(docs),
+	}, nil
+}
+
+// PurgeExpired creates a node that drops expired documents, e.g. before
+// indexing to honor retention policies.
+//
+// Example:
+//
+//	flow := core.NewFlow("ingest").
+//	    Then(fetchNode).
+//	    Then(transform.PurgeExpired()).
+//	    Then(embedNode).
+//	    Build()
+func PurgeExpired() *core.Node[PurgeExpiredInput, PurgeExpiredOutput] {
+	return core.NewNode("transform.PurgeExpired", PurgeExpiredActivity, PurgeExpiredInput{})
+}
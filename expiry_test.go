@@ -0,0 +1,39 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestPurgeExpiredActivity is not parallel because it replaces the
+// package clock.
+func TestPurgeExpiredActivity(t *testing.T) {
+	fixed := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return fixed }))
+	t.Cleanup(func() { SetClock(nil) })
+
+	docs := []Document{
+		{ID: "past", Metadata: map[string]string{MetadataExpiresAt: "2024-05-31T23:59:59Z"}},
+		{ID: "now", Metadata: map[string]string{MetadataExpiresAt: "2024-06-01T02:00:00+02:00"}},
+		{ID: "future", Metadata: map[string]string{MetadataExpiresAt: "2024-06-01T00:00:01Z"}},
+		{ID: "forever"},
+	}
+
+	out, err := PurgeExpiredActivity(context.Background(), PurgeExpiredInput{Documents: docs})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.Purged != 2 || out.Count != 2 {
+		t.Errorf("Purged, Count = %d, %d, want 2, 2", out.Purged, out.Count)
+	}
+	if out.Documents[0].ID != "future" || out.Documents[1].ID != "forever" {
+		t.Errorf("kept %+v, want future and forever", out.Documents)
+	}
+
+	bad := []Document{{ID: "bad", Metadata: map[string]string{MetadataExpiresAt: "tomorrow"}}}
+	if _, err := PurgeExpiredActivity(context.Background(), PurgeExpiredInput{Documents: bad}); err == nil {
+		t.Error("expected error for invalid expiry")
+	}
+}
@@ -0,0 +1,105 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// MetadataExplodedFrom records the ID of the document a unit was split
+// from by ExplodeActivity.
+const MetadataExplodedFrom = "exploded_from"
+
+// ExplodeOptions configures splitting documents into logical units.
+type ExplodeOptions struct {
+	// KeepEmpty keeps units that are empty after trimming whitespace.
+	// Without it they are dropped and do not take an index.
+	KeepEmpty bool
+
+	// Selector limits exploding to the documents it matches; the others
+	// are passed through unchanged.
+	Selector Selector
+}
+
+// ExplodeInput is the input for the Explode transformer.
+type ExplodeInput struct {
+	Documents []Document
+
+	// Delimiter separates the units of a document's content.
+	Delimiter string
+	Options   ExplodeOptions
+}
+
+// ExplodeOutput is the output of the Explode transformer.
+type ExplodeOutput struct {
+	Documents []Document
+	Count     int
+
+	// Exploded is the number of input documents split into units.
+	Exploded int
+}
+
+// ToDocuments implements DocumentSource for ExplodeOutput.
+func (o ExplodeOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// ExplodeActivity splits each document's content on the delimiter into
+// one document per unit. Units are trimmed, never overlapped, and named
+// like chunks ("parent#0", "parent#1", ...); they inherit the parent's
+// fields and metadata, with the parent's ID in Metadata["exploded_from"].
+// Units are documents in their own right rather than chunks, so Chunk
+// splits oversized units further. A document without the delimiter is
+// passed through unchanged.
+func ExplodeActivity(ctx context.Context, input ExplodeInput) (ExplodeOutput, error) {
+	if input.Delimiter == "" {
+		return ExplodeOutput{}, errors.New("explode delimiter must not be empty")
+	}
+
+	docs := make([]Document, 0, len(input.Documents))
+	var exploded int
+	for _, doc := range input.Documents {
+		if !input.Options.Selector.Matches(doc) || !strings.Contains(doc.Content, input.Delimiter) {
+			docs = append(docs, doc)
+			continue
+		}
+
+		exploded++
+		var index int
+		for _, unit := range strings.Split(doc.Content, input.Delimiter) {
+			unit = strings.TrimSpace(unit)
+			if unit == "" && !input.Options.KeepEmpty {
+				continue
+			}
+			child := doc.withMetadataCopy(MetadataExplodedFrom, doc.ID)
+			child.ID = ChunkID(doc.ID, index, ChunkIDSeparator, 0)
+			child.Content = unit
+			child.Attributes = doc.Attributes.clone()
+			docs = append(docs, child)
+			index++
+		}
+	}
+
+	return ExplodeOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Exploded:  exploded,
+	}, nil
+}
+
+// Explode creates a node that splits documents holding many records,
+// such as an email thread or a chat log, into one document per record.
+// Unlike chunking, every unit is kept whole regardless of its size.
+//
+// Example:
+//
+//	flow := core.NewFlow("mail").
+//	    Then(fetchThreadsNode).
+//	    Then(transform.Explode("\n-----Original Message-----\n", transform.ExplodeOptions{})).
+//	    Then(transform.Chunk(transform.ChunkOptions{MaxTokens: 512})).
+//	    Build()
+func Explode(delimiter string, opts ExplodeOptions) *core.Node[ExplodeInput, ExplodeOutput] {
+	return core.NewNode("transform.Explode", ExplodeActivity, ExplodeInput{Delimiter: delimiter, Options: opts})
+}
@@ -0,0 +1,75 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestExplodeActivity(t *testing.T) {
+	t.Parallel()
+
+	thread := Document{
+		ID:       "thread",
+		Content:  "hi there\n---\n\n---\nthanks, see you\n---\n",
+		Source:   "mail",
+		Metadata: map[string]string{"subject": "lunch"},
+	}
+	tests := []struct {
+		name string
+		opts ExplodeOptions
+		want []string
+	}{
+		{"drop empty", ExplodeOptions{}, []string{"hi there", "thanks, see you"}},
+		{"keep empty", ExplodeOptions{KeepEmpty: true}, []string{"hi there", "", "thanks, see you", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := ExplodeActivity(context.Background(), ExplodeInput{
+				Documents: []Document{thread, {ID: "single", Content: "no delimiter"}},
+				Delimiter: "\n---\n",
+				Options:   tt.opts,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Exploded != 1 || out.Count != len(tt.want)+1 {
+				t.Fatalf("got Exploded=%d Count=%d, want 1, %d", out.Exploded, out.Count, len(tt.want)+1)
+			}
+
+			var contents []string
+			for i, doc := range out.Documents[:len(tt.want)] {
+				contents = append(contents, doc.Content)
+				if want := ChunkID("thread", i, ChunkIDSeparator, 0); doc.ID != want {
+					t.Errorf("unit %d ID = %q, want %q", i, doc.ID, want)
+				}
+				if doc.Source != "mail" || doc.Metadata["subject"] != "lunch" || doc.Metadata[MetadataExplodedFrom] != "thread" {
+					t.Errorf("unit %d did not inherit the parent: %+v", i, doc)
+				}
+				if doc.IsChunk() {
+					t.Errorf("unit %d is a chunk", i)
+				}
+			}
+			if !reflect.DeepEqual(contents, tt.want) {
+				t.Errorf("got units %q, want %q", contents, tt.want)
+			}
+			if last := out.Documents[len(out.Documents)-1]; last.ID != "single" {
+				t.Errorf("got last document %q, want single passed through", last.ID)
+			}
+			if _, ok := thread.Metadata[MetadataExplodedFrom]; ok {
+				t.Error("input metadata was modified")
+			}
+		})
+	}
+}
+
+func TestExplodeActivityEmptyDelimiter(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ExplodeActivity(context.Background(), ExplodeInput{}); err == nil {
+		t.Error("expected error for empty delimiter")
+	}
+}
@@ -0,0 +1,206 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ExportSchema maps Document fields to the JSON field names used on export.
+// An empty name uses the Document's own JSON tag; "-" omits the field.
+type ExportSchema struct {
+	ID         string
+	Content    string
+	Title      string
+	Source     string
+	URL        string
+	Metadata   string
+	Attributes string
+	ChunkIndex string
+	ParentID   string
+	UpdatedAt  string
+
+	// ExcludeMetadata lists metadata keys omitted from the exported
+	// metadata, with the same prefix matching as StripMetadata.
+	ExcludeMetadata []string
+}
+
+// DefaultExportSchema returns the mapping matching Document's JSON tags.
+func DefaultExportSchema() ExportSchema {
+	return ExportSchema{
+		ID:         "id",
+		Content:    "content",
+		Title:      "title",
+		Source:     "source",
+		URL:        "url",
+		Metadata:   "metadata",
+		Attributes: "attributes",
+		ChunkIndex: "chunk_index",
+		ParentID:   "parent_id",
+		UpdatedAt:  "updated_at",
+	}
+}
+
+// exportField is a single field written by ToJSONWith. Omit is set for
+// omitempty fields that are empty.
+type exportField struct {
+	name     string
+	fallback string
+	value    any
+	omit     bool
+}
+
+// ToJSONWith marshals the document using the field names in schema.
+// Fields tagged omitempty on Document are still omitted when empty.
+func (d Document) ToJSONWith(schema ExportSchema) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.writeJSONWith(&buf, schema); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ToJSONWith marshals docs as a JSON array using the field names in schema.
+func ToJSONWith(docs []Document, schema ExportSchema) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, doc := range docs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := doc.writeJSONWith(&buf, schema); err != nil {
+			return nil, fmt.Errorf("export document %s: %w", doc.ID, err)
+		}
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+func (d Document) writeJSONWith(buf *bytes.Buffer, schema ExportSchema) error {
+	if len(schema.ExcludeMetadata) > 0 && hasMatchingKey(d.Metadata, schema.ExcludeMetadata) {
+		d = stripMetadata([]Document{d}, schema.ExcludeMetadata)[0]
+	}
+
+	fields := []exportField{
+		{name: schema.ID, fallback: "id", value: d.ID},
+		{name: schema.Content, fallback: "content", value: d.Content},
+		{name: schema.Title, fallback: "title", value: d.Title, omit: d.Title == ""},
+		{name: schema.Source, fallback: "source", value: d.Source},
+		{name: schema.URL, fallback: "url", value: d.URL, omit: d.URL == ""},
+		{name: schema.Metadata, fallback: "metadata", value: d.Metadata, omit: len(d.Metadata) == 0},
+		{name: schema.Attributes, fallback: "attributes", value: d.Attributes, omit: len(d.Attributes) == 0},
+		{name: schema.ChunkIndex, fallback: "chunk_index", value: d.ChunkIndex, omit: d.ChunkIndex == 0},
+		{name: schema.ParentID, fallback: "parent_id", value: d.ParentID, omit: d.ParentID == ""},
+		{name: schema.UpdatedAt, fallback: "updated_at", value: d.UpdatedAt},
+	}
+
+	buf.WriteByte('{')
+	first := true
+	for _, f := range fields {
+		name := f.name
+		if name == "" {
+			name = f.fallback
+		}
+		if name == "-" || f.omit {
+			continue
+		}
+
+		key, err := json.Marshal(name)
+		if err != nil {
+			return fmt.Errorf("marshal field name %q: %w", name, err)
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return fmt.Errorf("marshal field %q: %w", name, err)
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// DefaultCSVColumns are the columns ExportCSV writes when none are given.
+var DefaultCSVColumns = []string{"id", "content", "title", "source", "url", "chunk_index", "parent_id", "updated_at"}
+
+// ExportCSV writes docs to w as CSV with a header row, for review in a
+// spreadsheet. Each column names a Document field by its JSON name, or a
+// metadata key as "metadata.<key>"; missing metadata keys export as empty
+// cells. Nil columns use DefaultCSVColumns. Values containing commas,
+// quotes or newlines are quoted.
+func ExportCSV(w io.Writer, docs []Document, columns []string) error {
+	if columns == nil {
+		columns = DefaultCSVColumns
+	}
+
+	getters := make([]func(Document) string, len(columns))
+	for i, column := range columns {
+		get, err := csvColumn(column)
+		if err != nil {
+			return err
+		}
+		getters[i] = get
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	row := make([]string, len(columns))
+	for _, doc := range docs {
+		for i, get := range getters {
+			row[i] = get(doc)
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("write document %s: %w", doc.ID, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvColumn returns the accessor for a CSV column name.
+func csvColumn(column string) (func(Document) string, error) {
+	if key, ok := strings.CutPrefix(column, "metadata."); ok {
+		return func(d Document) string { return d.Metadata[key] }, nil
+	}
+
+	switch column {
+	case "id":
+		return func(d Document) string { return d.ID }, nil
+	case "content":
+		return func(d Document) string { return d.Content }, nil
+	case "title":
+		return func(d Document) string { return d.Title }, nil
+	case "source":
+		return func(d Document) string { return d.Source }, nil
+	case "url":
+		return func(d Document) string { return d.URL }, nil
+	case "chunk_index":
+		return func(d Document) string { return itoa(d.ChunkIndex) }, nil
+	case "parent_id":
+		return func(d Document) string { return d.ParentID }, nil
+	case "updated_at":
+		return func(d Document) string {
+			if d.UpdatedAt.IsZero() {
+				return ""
+			}
+			return d.UpdatedAt.Format(time.RFC3339)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown CSV column: %q", column)
+	}
+}
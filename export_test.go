@@ -0,0 +1,87 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestToJSONWithDefaultSchemaMatchesMarshal(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "1", Content: "hello <world>", Source: "test", UpdatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{ID: "2#1", Content: "chunk", Title: "T", Source: "test", URL: "https://example.com",
+			Metadata: map[string]string{"b": "2", "a": "1"}, ChunkIndex: 1, ParentID: "2"},
+	}
+
+	want, err := json.Marshal(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, schema := range []ExportSchema{DefaultExportSchema(), {}} {
+		got, err := ToJSONWith(docs, schema)
+		if err != nil {
+			t.Fatalf("ToJSONWith: %v", err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("ToJSONWith(%+v) =\n%s\nwant\n%s", schema, got, want)
+		}
+	}
+}
+
+func TestToJSONWithRenamedFields(t *testing.T) {
+	t.Parallel()
+
+	doc := Document{ID: "1", Content: "body", Source: "test", URL: "https://example.com"}
+	schema := ExportSchema{ID: "doc_id", Content: "text", URL: "-"}
+
+	data, err := doc.ToJSONWith(schema)
+	if err != nil {
+		t.Fatalf("ToJSONWith: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if got["doc_id"] != "1" {
+		t.Errorf("doc_id = %v, want %q", got["doc_id"], "1")
+	}
+	if got["text"] != "body" {
+		t.Errorf("text = %v, want %q", got["text"], "body")
+	}
+	for _, key := range []string{"id", "content", "url"} {
+		if _, exists := got[key]; exists {
+			t.Errorf("unexpected field %q in %s", key, data)
+		}
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "a#1", Content: "one, two\n\"three\"", ParentID: "a", ChunkIndex: 1, Metadata: map[string]string{"lang": "en"}},
+		{ID: "b", Content: "plain", UpdatedAt: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportCSV(&buf, docs, []string{"id", "chunk_index", "content", "metadata.lang", "updated_at"}); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+
+	want := "id,chunk_index,content,metadata.lang,updated_at\n" +
+		"a#1,1,\"one, two\n\"\"three\"\"\",en,\n" +
+		"b,0,plain,,2024-05-01T08:00:00Z\n"
+	if got := buf.String(); got != want {
+		t.Errorf("ExportCSV wrote %q, want %q", got, want)
+	}
+
+	if err := ExportCSV(&buf, docs, []string{"body"}); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
@@ -0,0 +1,103 @@
+package transform
+
+import (
+	"context"
+	"time"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// FilterOptions configures which documents the Filter transformer keeps.
+// A zero value keeps every document.
+type FilterOptions struct {
+	// UpdatedAfter drops documents updated at or before this time.
+	UpdatedAfter time.Time
+
+	// MaxAge drops documents whose UpdatedAt is older than MaxAge,
+	// measured when the activity runs (see SetClock).
+	MaxAge time.Duration
+
+	// DropUndated drops documents with a zero UpdatedAt when a time
+	// criterion is set. By default they are kept.
+	DropUndated bool
+
+	// DryRun reports the Count and Dropped the filter would produce
+	// without returning Documents.
+	DryRun bool
+}
+
+// FilterInput is the input for the Filter transformer.
+type FilterInput struct {
+	Documents []Document
+	Options   FilterOptions
+}
+
+// FilterOutput is the output of the Filter transformer.
+type FilterOutput struct {
+	Documents []Document
+	Count     int
+	Dropped   int
+}
+
+// ToDocuments implements DocumentSource for FilterOutput.
+func (o FilterOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// FilterActivity keeps the documents matching the filter options.
+func FilterActivity(ctx context.Context, input FilterInput) (FilterOutput, error) {
+	opts := input.Options
+
+	cutoff := opts.UpdatedAfter
+	if opts.MaxAge > 0 {
+		if maxAgeCutoff := now().Add(-opts.MaxAge); maxAgeCutoff.After(cutoff) {
+			cutoff = maxAgeCutoff
+		}
+	}
+
+	log := debugLogger(ctx)
+	docs := make([]Document, 0, len(input.Documents))
+	for _, doc := range input.Documents {
+		if !cutoff.IsZero() {
+			if doc.UpdatedAt.IsZero() {
+				if opts.DropUndated {
+					if log != nil {
+						log.DebugContext(ctx, "transform: document dropped", "id", doc.ID, "reason", "undated")
+					}
+					continue
+				}
+			} else if !doc.UpdatedAt.After(cutoff) {
+				if log != nil {
+					log.DebugContext(ctx, "transform: document dropped", "id", doc.ID, "reason", "stale",
+						"updated_at", doc.UpdatedAt, "cutoff", cutoff)
+				}
+				continue
+			}
+		}
+		docs = append(docs, doc)
+	}
+
+	count := len(docs)
+	if opts.DryRun {
+		docs = []Document{}
+	}
+
+	return FilterOutput{
+		Documents: docs,
+		Count:     count,
+		Dropped:   len(input.Documents) - count,
+	}, nil
+}
+
+// Filter creates a node that drops documents not matching opts.
+//
+// Example:
+//
+//	flow := core.NewFlow("reindex").
+//	    Then(fetchNode).
+//	    Then(transform.Filter(transform.FilterOptions{MaxAge: 7 * 24 * time.Hour})).
+//	    Then(embedNode).
+//	    Build()
+func Filter(opts FilterOptions) *core.Node[FilterInput, FilterOutput] {
+	return core.NewNode("transform.Filter", FilterActivity, FilterInput{Options: opts})
+}
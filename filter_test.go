@@ -0,0 +1,116 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFilterActivityFreshness(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	docs := []Document{
+		{ID: "fresh", UpdatedAt: now.Add(-time.Hour)},
+		{ID: "stale", UpdatedAt: now.Add(-10 * 24 * time.Hour)},
+		{ID: "undated"},
+	}
+
+	tests := []struct {
+		name    string
+		opts    FilterOptions
+		wantIDs []string
+	}{
+		{
+			name:    "no criteria keeps everything",
+			wantIDs: []string{"fresh", "stale", "undated"},
+		},
+		{
+			name:    "max age",
+			opts:    FilterOptions{MaxAge: 7 * 24 * time.Hour},
+			wantIDs: []string{"fresh", "undated"},
+		},
+		{
+			name:    "max age drops undated",
+			opts:    FilterOptions{MaxAge: 7 * 24 * time.Hour, DropUndated: true},
+			wantIDs: []string{"fresh"},
+		},
+		{
+			name:    "updated after",
+			opts:    FilterOptions{UpdatedAfter: now.Add(-2 * time.Hour)},
+			wantIDs: []string{"fresh", "undated"},
+		},
+		{
+			name:    "stricter criterion wins",
+			opts:    FilterOptions{UpdatedAfter: now.Add(-20 * 24 * time.Hour), MaxAge: time.Minute},
+			wantIDs: []string{"undated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := FilterActivity(context.Background(), FilterInput{Documents: docs, Options: tt.opts})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(out.Documents) != len(tt.wantIDs) {
+				t.Fatalf("got %d documents, want %d", len(out.Documents), len(tt.wantIDs))
+			}
+			for i, id := range tt.wantIDs {
+				if out.Documents[i].ID != id {
+					t.Errorf("Documents[%d].ID = %q, want %q", i, out.Documents[i].ID, id)
+				}
+			}
+			if out.Dropped != len(docs)-len(tt.wantIDs) {
+				t.Errorf("Dropped = %d, want %d", out.Dropped, len(docs)-len(tt.wantIDs))
+			}
+		})
+	}
+}
+
+// TestFilterActivityClock is not parallel because it replaces the
+// package clock.
+func TestFilterActivityClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return fixed }))
+	t.Cleanup(func() { SetClock(nil) })
+
+	docs := []Document{
+		{ID: "inside", UpdatedAt: fixed.Add(-59 * time.Minute)},
+		{ID: "outside", UpdatedAt: fixed.Add(-61 * time.Minute)},
+	}
+
+	out, err := FilterActivity(context.Background(), FilterInput{Documents: docs, Options: FilterOptions{MaxAge: time.Hour}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.Count != 1 || out.Documents[0].ID != "inside" {
+		t.Errorf("got %+v, want only the document inside the window", out.Documents)
+	}
+	if got := NewDocument("id", "content", "src").UpdatedAt; !got.Equal(fixed) {
+		t.Errorf("NewDocument UpdatedAt = %v, want %v", got, fixed)
+	}
+}
+
+func TestFilterActivityDryRun(t *testing.T) {
+	t.Parallel()
+
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	out, err := FilterActivity(context.Background(), FilterInput{
+		Documents: []Document{
+			{ID: "old", UpdatedAt: cutoff.Add(-time.Hour)},
+			{ID: "new", UpdatedAt: cutoff.Add(time.Hour)},
+		},
+		Options: FilterOptions{UpdatedAfter: cutoff, DryRun: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Count != 1 || out.Dropped != 1 || len(out.Documents) != 0 {
+		t.Errorf("got Count=%d Dropped=%d %d documents, want 1, 1 and none", out.Count, out.Dropped, len(out.Documents))
+	}
+}
@@ -0,0 +1,114 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// FingerprintIndexVersion is the current version of the FingerprintIndex
+// format.
+const FingerprintIndexVersion = 1
+
+// FingerprintIndex maps document IDs to content hashes (see
+// ContentHashAllocator) for constant-time membership checks against a
+// stored document set, e.g. to find the new and changed documents of a
+// fresh fetch without rescanning the existing ref for each one. It
+// encodes to JSON, so it can be stored and reused across runs (see
+// StoreFingerprintIndex). The zero value is an empty index.
+type FingerprintIndex struct {
+	Version int               `json:"version"`
+	Hashes  map[string]string `json:"hashes"`
+}
+
+// NewFingerprintIndex builds a FingerprintIndex of docs. When IDs repeat,
+// the last document wins.
+func NewFingerprintIndex(docs []Document) FingerprintIndex {
+	idx := FingerprintIndex{Version: FingerprintIndexVersion, Hashes: make(map[string]string, len(docs))}
+	for _, doc := range docs {
+		idx.Add(doc)
+	}
+	return idx
+}
+
+// BuildFingerprintIndex loads the documents at ref and indexes them.
+func BuildFingerprintIndex(ctx context.Context, ref core.DataRef) (FingerprintIndex, error) {
+	docs, err := LoadDocuments(ctx, ref)
+	if err != nil {
+		return FingerprintIndex{}, err
+	}
+	return NewFingerprintIndex(docs), nil
+}
+
+// Add records doc in the index, replacing any entry with its ID.
+func (idx *FingerprintIndex) Add(doc Document) {
+	if idx.Hashes == nil {
+		idx.Version = FingerprintIndexVersion
+		idx.Hashes = make(map[string]string)
+	}
+	idx.Hashes[doc.ID] = ContentHashAllocator{}.Allocate(doc)
+}
+
+// Contains reports whether the index holds doc's ID with the same
+// content. A document whose content changed is not contained.
+func (idx FingerprintIndex) Contains(doc Document) bool {
+	hash, ok := idx.Hashes[doc.ID]
+	return ok && hash == ContentHashAllocator{}.Allocate(doc)
+}
+
+// Has reports whether the index holds id, regardless of content.
+func (idx FingerprintIndex) Has(id string) bool {
+	_, ok := idx.Hashes[id]
+	return ok
+}
+
+// Len returns the number of indexed documents.
+func (idx FingerprintIndex) Len() int {
+	return len(idx.Hashes)
+}
+
+// StoreFingerprintIndex stores idx for reuse by later runs.
+func StoreFingerprintIndex(ctx context.Context, idx FingerprintIndex) (core.DataRef, error) {
+	storage, err := core.GetStorage()
+	if err != nil {
+		return core.DataRef{}, fmt.Errorf("get storage: %w", err)
+	}
+
+	if idx.Version == 0 {
+		idx.Version = FingerprintIndexVersion
+	}
+	ref, err := storage.StoreJSON(ctx, SchemaFingerprintIndex, idx)
+	if err != nil {
+		return core.DataRef{}, fmt.Errorf("store fingerprint index: %w", err)
+	}
+
+	ref.Count = idx.Len()
+	return ref, nil
+}
+
+// LoadFingerprintIndex loads a FingerprintIndex from a DataRef.
+func LoadFingerprintIndex(ctx context.Context, ref core.DataRef) (FingerprintIndex, error) {
+	if ref.Schema != SchemaFingerprintIndex {
+		return FingerprintIndex{}, fmt.Errorf("schema mismatch: expected %s, got %s", SchemaFingerprintIndex, ref.Schema)
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return FingerprintIndex{}, fmt.Errorf("get storage: %w", err)
+	}
+
+	var idx FingerprintIndex
+	if err := storage.LoadJSON(ctx, ref, &idx); err != nil {
+		return FingerprintIndex{}, fmt.Errorf("load fingerprint index: %w", err)
+	}
+
+	if idx.Version > FingerprintIndexVersion {
+		return FingerprintIndex{}, fmt.Errorf("unsupported fingerprint index version %d (max %d)", idx.Version, FingerprintIndexVersion)
+	}
+	if idx.Hashes == nil {
+		idx.Hashes = make(map[string]string)
+	}
+
+	return idx, nil
+}
@@ -0,0 +1,80 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestFingerprintIndex(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ref, err := StoreDocuments(ctx, []Document{
+		{ID: "a", Content: "alpha"},
+		{ID: "b", Content: "beta"},
+	})
+	if err != nil {
+		t.Fatalf("StoreDocuments: %v", err)
+	}
+
+	idx, err := BuildFingerprintIndex(ctx, ref)
+	if err != nil {
+		t.Fatalf("BuildFingerprintIndex: %v", err)
+	}
+	if idx.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", idx.Len())
+	}
+
+	tests := []struct {
+		doc      Document
+		contains bool
+		has      bool
+	}{
+		{Document{ID: "a", Content: "alpha"}, true, true},
+		{Document{ID: "b", Content: "beta changed"}, false, true},
+		{Document{ID: "c", Content: "alpha"}, false, false},
+	}
+	for _, tt := range tests {
+		if got := idx.Contains(tt.doc); got != tt.contains {
+			t.Errorf("Contains(%s %q) = %v, want %v", tt.doc.ID, tt.doc.Content, got, tt.contains)
+		}
+		if got := idx.Has(tt.doc.ID); got != tt.has {
+			t.Errorf("Has(%s) = %v, want %v", tt.doc.ID, got, tt.has)
+		}
+	}
+
+	idxRef, err := StoreFingerprintIndex(ctx, idx)
+	if err != nil {
+		t.Fatalf("StoreFingerprintIndex: %v", err)
+	}
+	if idxRef.Count != 2 {
+		t.Errorf("ref Count = %d, want 2", idxRef.Count)
+	}
+	loaded, err := LoadFingerprintIndex(ctx, idxRef)
+	if err != nil {
+		t.Fatalf("LoadFingerprintIndex: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, idx) {
+		t.Errorf("loaded %+v, want %+v", loaded, idx)
+	}
+
+	if _, err := LoadFingerprintIndex(ctx, ref); err == nil {
+		t.Error("expected schema mismatch loading a documents ref")
+	}
+}
+
+func TestFingerprintIndexZeroValue(t *testing.T) {
+	t.Parallel()
+
+	var idx FingerprintIndex
+	doc := Document{ID: "a", Content: "alpha"}
+	if idx.Contains(doc) {
+		t.Error("empty index contains a document")
+	}
+
+	idx.Add(doc)
+	if !idx.Contains(doc) || idx.Version != FingerprintIndexVersion {
+		t.Errorf("got %+v after Add, want doc indexed", idx)
+	}
+}
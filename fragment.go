@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"strings"
+	"unicode"
+)
+
+// linkFragments sets the URL fragment of each chunk from template,
+// expanding "{index}" and "{offset}" (see ChunkOptions.URLFragmentTemplate).
+func linkFragments(chunks []Document, parent, template string) {
+	template = strings.TrimPrefix(template, "#")
+	offset := 0
+	for i := range chunks {
+		if chunks[i].URL == "" {
+			continue
+		}
+		if strings.Contains(template, "{offset}") {
+			offset = chunkOffset(parent, chunks[i].Content, offset)
+		}
+
+		fragment := strings.NewReplacer(
+			"{index}", itoa(chunks[i].ChunkIndex),
+			"{offset}", itoa(offset),
+		).Replace(template)
+
+		url, _, _ := strings.Cut(chunks[i].URL, "#")
+		chunks[i].URL = url + "#" + fragment
+	}
+}
+
+// chunkOffset returns the byte offset in parent where content starts,
+// searching from the offset of the previous chunk. Chunks whose
+// whitespace was rejoined are located by their leading words; if those
+// are not found, the previous offset is kept.
+func chunkOffset(parent, content string, from int) int {
+	if i := strings.Index(parent[from:], content); i >= 0 {
+		return from + i
+	}
+
+	words := strings.Fields(content)
+	if len(words) == 0 {
+		return from
+	}
+	words = words[:min(len(words), 8)]
+	for at := from; at < len(parent); {
+		i := strings.Index(parent[at:], words[0])
+		if i < 0 {
+			break
+		}
+		at += i
+		if hasLeadingWords(parent[at:], words) {
+			return at
+		}
+		at += len(words[0])
+	}
+	return from
+}
+
+// hasLeadingWords reports whether text starts with words separated by
+// whitespace.
+func hasLeadingWords(text string, words []string) bool {
+	for i, word := range words {
+		if i > 0 {
+			trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+			if len(trimmed) == len(text) {
+				return false
+			}
+			text = trimmed
+		}
+		if !strings.HasPrefix(text, word) {
+			return false
+		}
+		text = text[len(word):]
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package transform
+
+import (
+	"context"
+	"strings"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// ParseFrontmatterInput is the input for the ParseFrontmatter transformer.
+type ParseFrontmatterInput struct {
+	Documents []Document
+}
+
+// ParseFrontmatterOutput is the output of the ParseFrontmatter transformer.
+type ParseFrontmatterOutput struct {
+	Documents []Document
+	Count     int
+	Parsed    int
+}
+
+// ToDocuments implements DocumentSource for ParseFrontmatterOutput.
+func (o ParseFrontmatterOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// ParseFrontmatterActivity extracts a leading YAML (---) or TOML (+++)
+// frontmatter block from each document, promotes its top-level keys into
+// Metadata, and removes the block from Content. Keys already present in
+// Metadata are not overwritten. Documents with missing or malformed
+// frontmatter are left untouched.
+func ParseFrontmatterActivity(ctx context.Context, input ParseFrontmatterInput) (ParseFrontmatterOutput, error) {
+	docs := make([]Document, 0, len(input.Documents))
+	var parsed int
+
+	for _, doc := range input.Documents {
+		fields, body, ok := parseFrontmatter(doc.Content)
+		if !ok {
+			docs = append(docs, doc)
+			continue
+		}
+
+		doc.Metadata = copyMetadata(doc.Metadata)
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]string, len(fields))
+		}
+		for k, v := range fields {
+			if _, exists := doc.Metadata[k]; !exists {
+				doc.Metadata[k] = v
+			}
+		}
+		doc.Content = body
+
+		docs = append(docs, doc)
+		parsed++
+	}
+
+	return ParseFrontmatterOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Parsed:    parsed,
+	}, nil
+}
+
+// ParseFrontmatter creates a node that strips frontmatter into metadata.
+// Use it before chunking so frontmatter does not consume the first
+// chunk's token budget.
+//
+// Example:
+//
+//	flow := core.NewFlow("docs").
+//	    Then(fetchNode).
+//	    Then(transform.ParseFrontmatter()).
+//	    Then(transform.Chunk(transform.DefaultChunkOptions())).
+//	    Build()
+func ParseFrontmatter() *core.Node[ParseFrontmatterInput, ParseFrontmatterOutput] {
+	return core.NewNode("transform.ParseFrontmatter", ParseFrontmatterActivity, ParseFrontmatterInput{})
+}
+
+// parseFrontmatter splits content into frontmatter fields and body.
+// It returns ok=false when content has no well-formed frontmatter block.
+func parseFrontmatter(content string) (map[string]string, string, bool) {
+	text := strings.TrimPrefix(content, "\ufeff")
+
+	var fence, assign string
+	switch {
+	case strings.HasPrefix(text, "---\n"), strings.HasPrefix(text, "---\r\n"):
+		fence, assign = "---", ":"
+	case strings.HasPrefix(text, "+++\n"), strings.HasPrefix(text, "+++\r\n"):
+		fence, assign = "+++", "="
+	default:
+		return nil, content, false
+	}
+
+	rest := text[strings.IndexByte(text, '\n')+1:]
+	fields := make(map[string]string)
+
+	for rest != "" {
+		line := rest
+		next := ""
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			line, next = rest[:i], rest[i+1:]
+		}
+		line = strings.TrimSuffix(line, "\r")
+		rest = next
+
+		if line == fence || (fence == "---" && line == "...") {
+			return fields, strings.TrimLeft(rest, "\r\n"), true
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Nested values and list items are not promoted.
+		if line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+
+		key, value, found := strings.Cut(trimmed, assign)
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, content, false
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		fields[unquote(key)] = unquote(value)
+	}
+
+	return nil, content, false
+}
+
+// unquote removes matching single or double quotes around s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
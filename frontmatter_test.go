@@ -0,0 +1,88 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		content    string
+		wantOK     bool
+		wantBody   string
+		wantFields map[string]string
+	}{
+		{
+			name:     "yaml",
+			content:  "---\ntitle: \"Runbook\"\nowner: sre\ntags:\n  - ops\n---\n\nBody text.",
+			wantOK:   true,
+			wantBody: "Body text.",
+			wantFields: map[string]string{
+				"title": "Runbook",
+				"owner": "sre",
+			},
+		},
+		{
+			name:     "toml",
+			content:  "+++\ntitle = 'Guide'\ndraft = false\n+++\nBody.",
+			wantOK:   true,
+			wantBody: "Body.",
+			wantFields: map[string]string{
+				"title": "Guide",
+				"draft": "false",
+			},
+		},
+		{
+			name:     "crlf",
+			content:  "---\r\ntitle: Win\r\n---\r\nBody.",
+			wantOK:   true,
+			wantBody: "Body.",
+			wantFields: map[string]string{
+				"title": "Win",
+			},
+		},
+		{
+			name:    "no frontmatter",
+			content: "# Heading\n\nBody.",
+		},
+		{
+			name:    "unterminated",
+			content: "---\ntitle: Oops\n\nBody.",
+		},
+		{
+			name:    "malformed line",
+			content: "---\nnot a key value\n---\nBody.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fields, body, ok := parseFrontmatter(tt.content)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if body != tt.content {
+					t.Errorf("body = %q, want content unchanged", body)
+				}
+				return
+			}
+
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if len(fields) != len(tt.wantFields) {
+				t.Errorf("got %d fields, want %d: %v", len(fields), len(tt.wantFields), fields)
+			}
+			for k, v := range tt.wantFields {
+				if fields[k] != v {
+					t.Errorf("fields[%q] = %q, want %q", k, fields[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package transform
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// HTMLToTextOptions configures HTML-to-text conversion.
+type HTMLToTextOptions struct {
+	// Sections emits each <h1>–<h6> heading as a markdown ATX heading
+	// ("## Title") on its own paragraph, so StrategyMarkdown chunks the
+	// text by HTML section with the heading as each chunk's Title.
+	// Without it headings become plain paragraphs.
+	Sections bool
+
+	// Selector limits conversion to the documents it matches, e.g. one
+	// HTML source in a mixed batch.
+	Selector Selector
+}
+
+// HTMLToTextInput is the input for the HTMLToText transformer.
+type HTMLToTextInput struct {
+	Documents []Document
+	Options   HTMLToTextOptions
+}
+
+// HTMLToTextOutput is the output of the HTMLToText transformer.
+type HTMLToTextOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for HTMLToTextOutput.
+func (o HTMLToTextOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// HTMLToTextActivity strips tags from each document's HTML content,
+// dropping <head>, <script>, <style> and comments, unescaping entities and
+// breaking paragraphs at block elements.
+func HTMLToTextActivity(ctx context.Context, input HTMLToTextInput) (HTMLToTextOutput, error) {
+	docs := make([]Document, len(input.Documents))
+	for i, doc := range input.Documents {
+		if input.Options.Selector.Matches(doc) {
+			doc.Content = htmlToText(doc.Content, input.Options)
+		}
+		docs[i] = doc
+	}
+
+	return HTMLToTextOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// HTMLToText creates a node that converts HTML content to plain text.
+//
+// Example:
+//
+//	flow := core.NewFlow("kb").
+//	    Then(fetchNode).
+//	    Then(transform.HTMLToText(transform.HTMLToTextOptions{Sections: true})).
+//	    Then(transform.Chunk(transform.ChunkOptions{Strategy: transform.StrategyMarkdown, MaxTokens: 512})).
+//	    Build()
+func HTMLToText(opts HTMLToTextOptions) *core.Node[HTMLToTextInput, HTMLToTextOutput] {
+	return core.NewNode("transform.HTMLToText", HTMLToTextActivity, HTMLToTextInput{Options: opts})
+}
+
+// htmlSkipTags are elements whose content is not document text.
+var htmlSkipTags = map[string]bool{
+	"head": true, "noscript": true, "script": true, "style": true, "template": true,
+}
+
+// htmlBlockTags are elements that start a new line of text.
+var htmlBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"br": true, "dd": true, "div": true, "dl": true, "dt": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"td": true, "th": true, "tr": true, "ul": true,
+}
+
+// htmlToText converts HTML to text with one block per line. With
+// opts.Sections, headings are rendered as ATX heading paragraphs.
+func htmlToText(content string, opts HTMLToTextOptions) string {
+	var out, text strings.Builder
+	heading := 0
+
+	flushText := func() {
+		if text.Len() == 0 {
+			return
+		}
+		// Source line breaks are insignificant whitespace in HTML.
+		out.WriteString(htmlSpace.Replace(html.UnescapeString(text.String())))
+		text.Reset()
+	}
+	newline := func(n int) {
+		flushText()
+		out.WriteString(strings.Repeat("\n", n))
+	}
+
+	for len(content) > 0 {
+		lt := strings.IndexByte(content, '<')
+		if lt < 0 {
+			text.WriteString(content)
+			break
+		}
+		text.WriteString(content[:lt])
+		content = content[lt:]
+
+		if strings.HasPrefix(content, "<!--") {
+			end := strings.Index(content, "-->")
+			if end < 0 {
+				break
+			}
+			content = content[end+len("-->"):]
+			continue
+		}
+
+		gt := strings.IndexByte(content, '>')
+		if gt < 0 {
+			text.WriteString(content)
+			break
+		}
+		name, closing := htmlTagName(content[1:gt])
+		content = content[gt+1:]
+
+		switch {
+		case !closing && htmlSkipTags[name]:
+			end := strings.Index(strings.ToLower(content), "</"+name)
+			if end < 0 {
+				content = ""
+				continue
+			}
+			content = content[end:]
+		case htmlHeadingLevel(name) > 0:
+			if !opts.Sections {
+				newline(1)
+				continue
+			}
+			if closing {
+				heading = 0
+				newline(2)
+				continue
+			}
+			heading = htmlHeadingLevel(name)
+			newline(2)
+			out.WriteString(strings.Repeat("#", heading) + " ")
+		case htmlBlockTags[name]:
+			if heading == 0 {
+				newline(1)
+			} else {
+				text.WriteByte(' ')
+			}
+		}
+	}
+	flushText()
+
+	return normalizeHTMLText(out.String())
+}
+
+// htmlSpace maps HTML whitespace to spaces.
+var htmlSpace = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// htmlTagName returns the lower-cased element name of a tag body (the
+// text between '<' and '>') and whether it is a closing tag.
+func htmlTagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+	end := strings.IndexAny(tag, " \t\r\n/")
+	if end >= 0 {
+		tag = tag[:end]
+	}
+	return strings.ToLower(tag), closing
+}
+
+// htmlHeadingLevel returns N for an "hN" element name, or 0.
+func htmlHeadingLevel(name string) int {
+	if len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6' {
+		return int(name[1] - '0')
+	}
+	return 0
+}
+
+// normalizeHTMLText collapses whitespace within lines, drops blank lines
+// except single paragraph breaks, and trims the result.
+func normalizeHTMLText(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
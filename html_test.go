@@ -0,0 +1,84 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHTMLToText(t *testing.T) {
+	t.Parallel()
+
+	const page = `<html><head><title>KB</title><style>p { color: red; }</style></head>
+<body>
+<!-- nav -->
+<p>Welcome &amp; hello.</p>
+<h1>Install</h1>
+<p>Run   the
+installer.</p>
+<h2 class="sub">On <em>Linux</em></h2>
+<ul><li>Download</li><li>Unpack</li></ul>
+<script>alert("x")</script>
+</body></html>`
+
+	tests := []struct {
+		name string
+		opts HTMLToTextOptions
+		want string
+	}{
+		{
+			name: "plain",
+			want: "Welcome & hello.\n\nInstall\n\nRun the installer.\n\nOn Linux\n\nDownload\n\nUnpack",
+		},
+		{
+			name: "sections",
+			opts: HTMLToTextOptions{Sections: true},
+			want: "Welcome & hello.\n\n# Install\n\nRun the installer.\n\n## On Linux\n\nDownload\n\nUnpack",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := htmlToText(page, tt.opts); got != tt.want {
+				t.Errorf("htmlToText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToTextSectionChunks(t *testing.T) {
+	t.Parallel()
+
+	const page = `<h1>Guide</h1><p>one two three four five six</p><h2>Setup</h2><p>seven eight nine ten eleven twelve</p>`
+
+	out, err := HTMLToTextActivity(context.Background(), HTMLToTextInput{
+		Documents: []Document{{ID: "kb", Content: page}},
+		Options:   HTMLToTextOptions{Sections: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	chunks := chunkDocument(out.Documents[0], ChunkOptions{
+		Strategy:      StrategyMarkdown,
+		MaxTokens:     5,
+		Separator:     " ",
+		HeadingPrefix: true,
+	})
+
+	want := []struct{ title, content string }{
+		{"Guide", "# Guide one two three"},
+		{"Guide", "# Guide\nfour five six"},
+		{"Setup", "## Setup seven eight nine"},
+		{"Setup", "## Setup\nten eleven twelve"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks %q, want %d", len(chunks), chunks, len(want))
+	}
+	for i, w := range want {
+		if chunks[i].Title != w.title || chunks[i].Content != w.content {
+			t.Errorf("chunk %d = (%q, %q), want (%q, %q)", i, chunks[i].Title, chunks[i].Content, w.title, w.content)
+		}
+	}
+}
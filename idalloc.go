@@ -0,0 +1,161 @@
+package transform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/resolute-sh/resolute/core"
+)
+
+// IDAllocator assigns IDs to documents.
+type IDAllocator interface {
+	Allocate(doc Document) string
+}
+
+// Built-in ID allocator names.
+const (
+	IDAllocatorContentHash      = "content_hash"
+	IDAllocatorUUID             = "uuid"
+	IDAllocatorPrefixedSequence = "prefixed_sequence"
+)
+
+// ContentHashAllocator derives IDs from the SHA-256 of the content, so
+// identical content always gets the same ID.
+type ContentHashAllocator struct{}
+
+// Allocate implements IDAllocator.
+func (ContentHashAllocator) Allocate(doc Document) string {
+	sum := sha256.Sum256([]byte(doc.Content))
+	return hex.EncodeToString(sum[:])
+}
+
+// UUIDAllocator assigns a random UUID to every document.
+type UUIDAllocator struct{}
+
+// Allocate implements IDAllocator.
+func (UUIDAllocator) Allocate(Document) string {
+	return uuid.NewString()
+}
+
+// PrefixedSequenceAllocator assigns IDs of the form "<source>-<n>",
+// numbering the documents of each source from zero in allocation order.
+// It is safe for concurrent use.
+type PrefixedSequenceAllocator struct {
+	mu   sync.Mutex
+	next map[string]int
+}
+
+// NewPrefixedSequenceAllocator creates a PrefixedSequenceAllocator with
+// every sequence starting at zero.
+func NewPrefixedSequenceAllocator() *PrefixedSequenceAllocator {
+	return &PrefixedSequenceAllocator{next: make(map[string]int)}
+}
+
+// Allocate implements IDAllocator.
+func (a *PrefixedSequenceAllocator) Allocate(doc Document) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.next == nil {
+		a.next = make(map[string]int)
+	}
+	n := a.next[doc.Source]
+	a.next[doc.Source] = n + 1
+	return doc.Source + "-" + itoa(n)
+}
+
+var idAllocators = struct {
+	mu        sync.RWMutex
+	factories map[string]func() IDAllocator
+}{
+	factories: map[string]func() IDAllocator{
+		IDAllocatorContentHash:      func() IDAllocator { return ContentHashAllocator{} },
+		IDAllocatorUUID:             func() IDAllocator { return UUIDAllocator{} },
+		IDAllocatorPrefixedSequence: func() IDAllocator { return NewPrefixedSequenceAllocator() },
+	},
+}
+
+// RegisterIDAllocator registers an allocator factory under name,
+// replacing any existing registration. A fresh allocator is created for
+// every activity run, so stateful allocators start over each time.
+// Register custom allocators on every worker before starting it.
+func RegisterIDAllocator(name string, factory func() IDAllocator) {
+	idAllocators.mu.Lock()
+	defer idAllocators.mu.Unlock()
+	idAllocators.factories[name] = factory
+}
+
+// RegisteredIDAllocators returns the names of all registered allocators.
+func RegisteredIDAllocators() []string {
+	idAllocators.mu.RLock()
+	defer idAllocators.mu.RUnlock()
+	return sortedKeys(idAllocators.factories)
+}
+
+// newIDAllocator creates the allocator registered under name.
+func newIDAllocator(name string) (IDAllocator, error) {
+	idAllocators.mu.RLock()
+	factory, ok := idAllocators.factories[name]
+	idAllocators.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown id allocator: %q", name)
+	}
+	return factory(), nil
+}
+
+// GenerateIDsInput is the input for the GenerateIDs transformer.
+type GenerateIDsInput struct {
+	Documents []Document
+
+	// Allocator names a registered IDAllocator.
+	Allocator string
+}
+
+// GenerateIDsOutput is the output of the GenerateIDs transformer.
+type GenerateIDsOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for GenerateIDsOutput.
+func (o GenerateIDsOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// GenerateIDsActivity replaces every document's ID with one from the
+// named allocator.
+func GenerateIDsActivity(ctx context.Context, input GenerateIDsInput) (GenerateIDsOutput, error) {
+	alloc, err := newIDAllocator(input.Allocator)
+	if err != nil {
+		return GenerateIDsOutput{}, err
+	}
+
+	docs := make([]Document, len(input.Documents))
+	for i, doc := range input.Documents {
+		doc.ID = alloc.Allocate(doc)
+		docs[i] = doc
+	}
+
+	return GenerateIDsOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// GenerateIDs creates a node that assigns document IDs with the allocator
+// registered under allocator. Allocators are referenced by name so the
+// node input stays serializable; see RegisterIDAllocator.
+//
+// Example:
+//
+//	flow := core.NewFlow("ingest").
+//	    Then(fetchNode).
+//	    Then(transform.GenerateIDs(transform.IDAllocatorContentHash)).
+//	    Build()
+func GenerateIDs(allocator string) *core.Node[GenerateIDsInput, GenerateIDsOutput] {
+	return core.NewNode("transform.GenerateIDs", GenerateIDsActivity, GenerateIDsInput{Allocator: allocator})
+}
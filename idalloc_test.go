@@ -0,0 +1,111 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGenerateIDsActivity(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "x", Content: "same", Source: "jira"},
+		{ID: "y", Content: "same", Source: "jira"},
+		{ID: "z", Content: "other", Source: "confluence"},
+	}
+
+	tests := []struct {
+		name      string
+		allocator string
+		wantErr   bool
+		check     func(t *testing.T, ids []string)
+	}{
+		{
+			name:      "content hash",
+			allocator: IDAllocatorContentHash,
+			check: func(t *testing.T, ids []string) {
+				if ids[0] != ids[1] {
+					t.Errorf("identical content got IDs %q and %q", ids[0], ids[1])
+				}
+				if ids[0] == ids[2] {
+					t.Errorf("different content got the same ID %q", ids[0])
+				}
+			},
+		},
+		{
+			name:      "uuid",
+			allocator: IDAllocatorUUID,
+			check: func(t *testing.T, ids []string) {
+				if ids[0] == ids[1] || len(ids[0]) != 36 {
+					t.Errorf("got IDs %q, want distinct UUIDs", ids)
+				}
+			},
+		},
+		{
+			name:      "prefixed sequence",
+			allocator: IDAllocatorPrefixedSequence,
+			check: func(t *testing.T, ids []string) {
+				want := []string{"jira-0", "jira-1", "confluence-0"}
+				for i := range want {
+					if ids[i] != want[i] {
+						t.Errorf("ids[%d] = %q, want %q", i, ids[i], want[i])
+					}
+				}
+			},
+		},
+		{
+			name:      "unknown",
+			allocator: "nope",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := GenerateIDsActivity(context.Background(), GenerateIDsInput{Documents: docs, Allocator: tt.allocator})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ids := make([]string, len(out.Documents))
+			for i, doc := range out.Documents {
+				ids[i] = doc.ID
+			}
+			tt.check(t, ids)
+
+			if docs[0].ID != "x" {
+				t.Errorf("input document ID was modified: %q", docs[0].ID)
+			}
+		})
+	}
+}
+
+func TestChunkIDAllocator(t *testing.T) {
+	t.Parallel()
+
+	doc := Document{ID: "orig", Content: "one two three four five six", Source: "jira"}
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{doc},
+		Options:   ChunkOptions{MaxTokens: 3, Separator: "\n\n", IDAllocator: IDAllocatorPrefixedSequence},
+	})
+
+	if out.Count != 2 {
+		t.Fatalf("got %d chunks, want 2", out.Count)
+	}
+	for i, chunk := range out.Documents {
+		if chunk.ParentID != "jira-0" {
+			t.Errorf("chunk %d ParentID = %q, want %q", i, chunk.ParentID, "jira-0")
+		}
+		if want := ChunkID("jira-0", i, ChunkIDSeparator, 0); chunk.ID != want {
+			t.Errorf("chunk %d ID = %q, want %q", i, chunk.ID, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package transform
+
+import (
+	"context"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// IdentityInput is the input for the Identity transformer.
+type IdentityInput struct {
+	Documents []Document
+}
+
+// IdentityOutput is the output of the Identity transformer.
+type IdentityOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for IdentityOutput.
+func (o IdentityOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// IdentityActivity returns its input documents unchanged.
+func IdentityActivity(ctx context.Context, input IdentityInput) (IdentityOutput, error) {
+	docs := input.Documents
+	if docs == nil {
+		docs = []Document{}
+	}
+
+	return IdentityOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// Identity creates a node that passes documents through unchanged. It is
+// useful as a placeholder branch in parallel flows and for comparing a
+// flow with and without a transform.
+//
+// Example:
+//
+//	flow := core.NewFlow("compare").
+//	    Then(fetchNode).
+//	    ThenParallel("variants", transform.Identity(), chunkNode).
+//	    Build()
+func Identity() *core.Node[IdentityInput, IdentityOutput] {
+	return core.NewNode("transform.Identity", IdentityActivity, IdentityInput{})
+}
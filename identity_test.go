@@ -0,0 +1,41 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIdentityActivity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		docs []Document
+	}{
+		{name: "nil input", docs: nil},
+		{name: "documents", docs: []Document{{ID: "a", Content: "one"}, {ID: "b", Content: "two"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := IdentityActivity(context.Background(), IdentityInput{Documents: tt.docs})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if out.Documents == nil {
+				t.Fatal("Documents is nil, want non-nil slice")
+			}
+			if out.Count != len(tt.docs) {
+				t.Errorf("Count = %d, want %d", out.Count, len(tt.docs))
+			}
+			for i, doc := range tt.docs {
+				if out.Documents[i].ID != doc.ID || out.Documents[i].Content != doc.Content {
+					t.Errorf("Documents[%d] = %+v, want %+v", i, out.Documents[i], doc)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// MetadataKeywords is the metadata key holding extracted keywords.
+const MetadataKeywords = "keywords"
+
+// KeywordOptions configures keyword extraction.
+type KeywordOptions struct {
+	// TopN is the maximum number of keywords kept per document.
+	// Default: 10
+	TopN int
+
+	// Stopwords are terms never selected as keywords. Matching is
+	// case-insensitive.
+	// Default: DefaultStopwords
+	Stopwords []string
+
+	// MinTermLength is the minimum length in runes of a keyword.
+	// Default: 3
+	MinTermLength int
+}
+
+// DefaultStopwords is a small list of common English function words.
+var DefaultStopwords = []string{
+	"a", "about", "after", "all", "also", "an", "and", "any", "are", "as", "at",
+	"be", "been", "but", "by", "can", "could", "did", "do", "does", "for", "from",
+	"had", "has", "have", "he", "her", "his", "how", "if", "in", "into", "is", "it",
+	"its", "may", "more", "most", "no", "not", "of", "on", "or", "other", "our",
+	"she", "should", "so", "some", "such", "than", "that", "the", "their", "them",
+	"then", "there", "these", "they", "this", "those", "to", "was", "we", "were",
+	"what", "when", "where", "which", "who", "will", "with", "would", "you", "your",
+}
+
+// DefaultKeywordOptions returns sensible defaults for keyword extraction.
+func DefaultKeywordOptions() KeywordOptions {
+	return KeywordOptions{
+		TopN:          10,
+		Stopwords:     DefaultStopwords,
+		MinTermLength: 3,
+	}
+}
+
+// ExtractKeywordsInput is the input for the ExtractKeywords transformer.
+type ExtractKeywordsInput struct {
+	Documents []Document
+	Options   KeywordOptions
+}
+
+// ExtractKeywordsOutput is the output of the ExtractKeywords transformer.
+type ExtractKeywordsOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for ExtractKeywordsOutput.
+func (o ExtractKeywordsOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// ExtractKeywordsActivity tags each document with its top TF-IDF keywords,
+// computed across the whole batch, in Metadata["keywords"] as a
+// comma-separated list ordered by descending score.
+func ExtractKeywordsActivity(ctx context.Context, input ExtractKeywordsInput) (ExtractKeywordsOutput, error) {
+	opts := input.Options
+	if opts.TopN < 0 {
+		return ExtractKeywordsOutput{}, fmt.Errorf("top n must not be negative, got %d", opts.TopN)
+	}
+	if opts.MinTermLength < 0 {
+		return ExtractKeywordsOutput{}, fmt.Errorf("min term length must not be negative, got %d", opts.MinTermLength)
+	}
+
+	defaults := DefaultKeywordOptions()
+	if opts.TopN == 0 {
+		opts.TopN = defaults.TopN
+	}
+	if opts.Stopwords == nil {
+		opts.Stopwords = defaults.Stopwords
+	}
+	if opts.MinTermLength == 0 {
+		opts.MinTermLength = defaults.MinTermLength
+	}
+
+	stop := make(map[string]bool, len(opts.Stopwords))
+	for _, w := range opts.Stopwords {
+		stop[strings.ToLower(w)] = true
+	}
+
+	termCounts := make([]map[string]int, len(input.Documents))
+	docFreq := make(map[string]int)
+	for i, doc := range input.Documents {
+		counts := make(map[string]int)
+		for _, term := range keywordTerms(doc.Content) {
+			if stop[term] || len([]rune(term)) < opts.MinTermLength {
+				continue
+			}
+			counts[term]++
+		}
+		for term := range counts {
+			docFreq[term]++
+		}
+		termCounts[i] = counts
+	}
+
+	n := float64(len(input.Documents))
+	docs := make([]Document, 0, len(input.Documents))
+	for i, doc := range input.Documents {
+		keywords := topKeywords(termCounts[i], docFreq, n, opts.TopN)
+		if len(keywords) > 0 {
+			doc = doc.withMetadataCopy(MetadataKeywords, strings.Join(keywords, ","))
+		}
+		docs = append(docs, doc)
+	}
+
+	return ExtractKeywordsOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// ExtractKeywords creates a node that tags documents with TF-IDF keywords
+// for keyword or hybrid retrieval.
+//
+// Example:
+//
+//	flow := core.NewFlow("hybrid-index").
+//	    Then(fetchNode).
+//	    Then(transform.ExtractKeywords(transform.KeywordOptions{TopN: 5})).
+//	    Then(indexNode).
+//	    Build()
+func ExtractKeywords(opts KeywordOptions) *core.Node[ExtractKeywordsInput, ExtractKeywordsOutput] {
+	return core.NewNode("transform.ExtractKeywords", ExtractKeywordsActivity, ExtractKeywordsInput{Options: opts})
+}
+
+// keywordTerms splits text into lowercase runs of letters and digits.
+func keywordTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// topKeywords returns up to n terms with the highest TF-IDF scores.
+// Ties are broken alphabetically so results are deterministic.
+func topKeywords(counts map[string]int, docFreq map[string]int, numDocs float64, n int) []string {
+	var total int
+	for _, c := range counts {
+		total += c
+	}
+
+	type scored struct {
+		term  string
+		score float64
+	}
+	terms := make([]scored, 0, len(counts))
+	for term, c := range counts {
+		tf := float64(c) / float64(total)
+		idf := math.Log((numDocs+1)/(float64(docFreq[term])+1)) + 1
+		terms = append(terms, scored{term: term, score: tf * idf})
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].score != terms[j].score {
+			return terms[i].score > terms[j].score
+		}
+		return terms[i].term < terms[j].term
+	})
+
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	keywords := make([]string, len(terms))
+	for i, t := range terms {
+		keywords[i] = t.term
+	}
+	return keywords
+}
@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExtractKeywordsActivity(t *testing.T) {
+	t.Parallel()
+
+	shared := map[string]string{"team": "sre"}
+	input := ExtractKeywordsInput{
+		Documents: []Document{
+			{ID: "1", Content: "Kubernetes pods restart when the kubernetes node drains.", Metadata: shared},
+			{ID: "2", Content: "The database failover restarts the primary database node."},
+			{ID: "3", Content: "The and of to"},
+		},
+		Options: KeywordOptions{TopN: 2},
+	}
+
+	out, err := ExtractKeywordsActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := out.Documents[0].Metadata[MetadataKeywords]; got != "kubernetes,drains" {
+		t.Errorf("doc 1 keywords = %q, want %q", got, "kubernetes,drains")
+	}
+	if got := out.Documents[1].Metadata[MetadataKeywords]; got != "database,failover" {
+		t.Errorf("doc 2 keywords = %q, want %q", got, "database,failover")
+	}
+	if _, exists := out.Documents[2].Metadata[MetadataKeywords]; exists {
+		t.Error("stopword-only document should have no keywords")
+	}
+	if _, exists := shared[MetadataKeywords]; exists {
+		t.Error("input metadata map was mutated")
+	}
+}
+
+func TestExtractKeywordsInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "1", Content: "kubernetes deployment rollout"}}
+	tests := []struct {
+		name string
+		opts KeywordOptions
+		want string
+	}{
+		{"negative top n", KeywordOptions{TopN: -1}, "top n must not be negative, got -1"},
+		{"negative min term length", KeywordOptions{MinTermLength: -2}, "min term length must not be negative, got -2"},
+	}
+	for _, tt := range tests {
+		_, err := ExtractKeywordsActivity(context.Background(), ExtractKeywordsInput{Documents: docs, Options: tt.opts})
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
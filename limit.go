@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"context"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// LimitInput is the input for the Limit transformer.
+type LimitInput struct {
+	Documents []Document
+
+	// N is the number of documents to keep. Zero or a negative value
+	// means no limit.
+	N int
+}
+
+// LimitOutput is the output of the Limit transformer.
+type LimitOutput struct {
+	Documents []Document
+	Count     int
+
+	// Dropped is the number of documents past the limit.
+	Dropped int
+}
+
+// ToDocuments implements DocumentSource for LimitOutput.
+func (o LimitOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// LimitActivity keeps the first N documents, in order.
+func LimitActivity(ctx context.Context, input LimitInput) (LimitOutput, error) {
+	docs := input.Documents
+	if docs == nil {
+		docs = []Document{}
+	}
+
+	var dropped int
+	if input.N > 0 && len(docs) > input.N {
+		dropped = len(docs) - input.N
+		docs = docs[:input.N:input.N]
+	}
+
+	return LimitOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Dropped:   dropped,
+	}, nil
+}
+
+// Limit creates a node that truncates the documents to the first n,
+// e.g. for a smoke-test run. A value of n <= 0 keeps every document.
+//
+// Example:
+//
+//	flow := core.NewFlow("smoke").
+//	    Then(fetchNode).
+//	    Then(transform.Limit(100)).
+//	    Then(chunkNode).
+//	    Build()
+func Limit(n int) *core.Node[LimitInput, LimitOutput] {
+	return core.NewNode("transform.Limit", LimitActivity, LimitInput{N: n})
+}
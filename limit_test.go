@@ -0,0 +1,42 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLimitActivity(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	tests := []struct {
+		n       int
+		want    []string
+		dropped int
+	}{
+		{n: 2, want: []string{"1", "2"}, dropped: 1},
+		{n: 3, want: []string{"1", "2", "3"}},
+		{n: 5, want: []string{"1", "2", "3"}},
+		{n: 0, want: []string{"1", "2", "3"}},
+		{n: -1, want: []string{"1", "2", "3"}},
+	}
+	for _, tt := range tests {
+		out, err := LimitActivity(context.Background(), LimitInput{Documents: docs, N: tt.n})
+		if err != nil {
+			t.Fatalf("n=%d: %v", tt.n, err)
+		}
+		if out.Count != len(tt.want) || out.Dropped != tt.dropped {
+			t.Errorf("n=%d: Count=%d Dropped=%d, want %d and %d", tt.n, out.Count, out.Dropped, len(tt.want), tt.dropped)
+		}
+		for i, id := range tt.want {
+			if out.Documents[i].ID != id {
+				t.Errorf("n=%d: document %d = %s, want %s", tt.n, i, out.Documents[i].ID, id)
+			}
+		}
+	}
+
+	out, _ := LimitActivity(context.Background(), LimitInput{N: 1})
+	if out.Documents == nil {
+		t.Error("Documents is nil for empty input")
+	}
+}
@@ -0,0 +1,88 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// ManifestVersion is the current version of the Manifest format.
+const ManifestVersion = 1
+
+// Manifest is a small summary of a stored document set.
+// It is stored alongside the documents so a ref can be inspected
+// without loading the full payload.
+type Manifest struct {
+	Version     int            `json:"version"`
+	Schema      string         `json:"schema"`
+	Documents   core.DataRef   `json:"documents"`
+	Count       int            `json:"count"`
+	Sources     map[string]int `json:"sources"`
+	TotalTokens int            `json:"total_tokens"`
+	Checksum    string         `json:"checksum"`
+}
+
+// NewManifest builds a Manifest describing docs stored at ref.
+func NewManifest(ref core.DataRef, docs []Document) Manifest {
+	m := Manifest{
+		Version:   ManifestVersion,
+		Schema:    ref.Schema,
+		Documents: ref,
+		Count:     len(docs),
+		Sources:   make(map[string]int),
+		Checksum:  ref.Checksum,
+	}
+
+	for _, doc := range docs {
+		m.Sources[doc.Source]++
+		m.TotalTokens += EstimateTokens(doc.Content)
+	}
+
+	return m
+}
+
+// StoreDocumentsWithManifest stores docs and a Manifest describing them.
+// It returns the documents ref and the sibling manifest ref.
+func StoreDocumentsWithManifest(ctx context.Context, docs []Document) (core.DataRef, core.DataRef, error) {
+	ref, err := StoreDocuments(ctx, docs)
+	if err != nil {
+		return core.DataRef{}, core.DataRef{}, err
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return core.DataRef{}, core.DataRef{}, fmt.Errorf("get storage: %w", err)
+	}
+
+	manifestRef, err := storage.StoreJSON(ctx, SchemaManifest, NewManifest(ref, docs))
+	if err != nil {
+		return core.DataRef{}, core.DataRef{}, fmt.Errorf("store manifest: %w", err)
+	}
+
+	manifestRef.Count = len(docs)
+	return ref, manifestRef, nil
+}
+
+// LoadManifest loads a Manifest from a DataRef.
+func LoadManifest(ctx context.Context, ref core.DataRef) (Manifest, error) {
+	if ref.Schema != SchemaManifest {
+		return Manifest{}, fmt.Errorf("schema mismatch: expected %s, got %s", SchemaManifest, ref.Schema)
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return Manifest{}, fmt.Errorf("get storage: %w", err)
+	}
+
+	var m Manifest
+	if err := storage.LoadJSON(ctx, ref, &m); err != nil {
+		return Manifest{}, fmt.Errorf("load manifest: %w", err)
+	}
+
+	if m.Version > ManifestVersion {
+		return Manifest{}, fmt.Errorf("unsupported manifest version %d (max %d)", m.Version, ManifestVersion)
+	}
+
+	return m, nil
+}
@@ -2,6 +2,10 @@ package transform
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
 
 	"github.com/resolute-sh/resolute/core"
 )
@@ -9,12 +13,80 @@ import (
 // MergeInput is the input for the Merge transformer.
 type MergeInput struct {
 	Sources []DocumentSource
+	Options MergeOptions
+}
+
+// MergeOptions configures merge behavior.
+type MergeOptions struct {
+	// SourceQuota caps the number of documents kept per Document.Source.
+	// Sources without an entry are not limited.
+	SourceQuota map[string]int
+
+	// SourceShare caps each listed source at a fraction, in [0, 1], of
+	// all merged documents before quotas apply, rounded down: a share of
+	// 0.25 over 1000 documents keeps at most 250 of that source. A source
+	// with both a quota and a share is capped at the smaller of the two.
+	SourceShare map[string]float64
+
+	// SampleStrategy selects which documents are kept when a source
+	// exceeds its quota.
+	// Default: SampleHead
+	SampleStrategy SampleStrategy
+
+	// Seed seeds SampleRandom so repeated runs keep the same documents.
+	Seed int64
+
+	// LabelSources stamps each document with the index of the input it
+	// came from in Metadata["merge_source_index"], and with its entry in
+	// SourceLabels, if any, in Metadata["merge_source_label"].
+	LabelSources bool
+
+	// SourceLabels names the inputs, in the order of MergeInput.Sources.
+	SourceLabels []string
+
+	// CountSourceTokens reports the tokens each Document.Source
+	// contributed after quotas in MergeOutput.TokensBySource. It is off
+	// by default because it tokenizes every document.
+	CountSourceTokens bool
+
+	// Tokenizer names the registered tokenizer used by CountSourceTokens.
+	// Default: the default tokenizer (see SetDefaultTokenizer)
+	Tokenizer string
+}
+
+// Metadata keys stamped by MergeActivity when LabelSources is set.
+const (
+	MetadataMergeSourceIndex = "merge_source_index"
+	MetadataMergeSourceLabel = "merge_source_label"
+)
+
+// SampleStrategy determines which documents survive a quota.
+type SampleStrategy string
+
+const (
+	// SampleHead keeps the first documents of each source.
+	SampleHead SampleStrategy = "head"
+
+	// SampleRandom keeps a seeded random sample of each source,
+	// preserving the original document order.
+	SampleRandom SampleStrategy = "random"
+)
+
+// MergeSourceStats reports how many documents of a source were kept and
+// dropped by quotas.
+type MergeSourceStats struct {
+	Kept    int
+	Dropped int
 }
 
 // MergeOutput is the output of the Merge transformer.
 type MergeOutput struct {
 	Documents []Document
 	Count     int
+	Sources   map[string]MergeSourceStats
+
+	// TokensBySource is set when MergeOptions.CountSourceTokens is.
+	TokensBySource map[string]int
 }
 
 // ToDocuments implements DocumentSource for MergeOutput.
@@ -26,16 +98,129 @@ func (o MergeOutput) ToDocuments() []Document {
 func MergeActivity(ctx context.Context, input MergeInput) (MergeOutput, error) {
 	var docs []Document
 
-	for _, source := range input.Sources {
-		docs = append(docs, source.ToDocuments()...)
+	var tokenizer Tokenizer
+	if input.Options.CountSourceTokens {
+		t, ok := lookupTokenizer(input.Options.Tokenizer)
+		if !ok {
+			return MergeOutput{}, fmt.Errorf("unknown tokenizer: %q", input.Options.Tokenizer)
+		}
+		tokenizer = t
+	}
+
+	for i, source := range input.Sources {
+		if !input.Options.LabelSources {
+			docs = append(docs, source.ToDocuments()...)
+			continue
+		}
+		for _, doc := range source.ToDocuments() {
+			docs = append(docs, labelMergeSource(doc, i, input.Options.SourceLabels))
+		}
+	}
+
+	docs, stats, err := applySourceQuota(docs, input.Options)
+	if err != nil {
+		return MergeOutput{}, err
+	}
+
+	var tokens map[string]int
+	if tokenizer != nil {
+		tokens = make(map[string]int, len(stats))
+		for _, doc := range docs {
+			tokens[doc.Source] += tokenizer.CountTokens(doc.Content)
+		}
 	}
 
 	return MergeOutput{
-		Documents: docs,
-		Count:     len(docs),
+		Documents:      docs,
+		Count:          len(docs),
+		Sources:        stats,
+		TokensBySource: tokens,
 	}, nil
 }
 
+// labelMergeSource stamps doc with the index and label of its input.
+func labelMergeSource(doc Document, index int, labels []string) Document {
+	doc = doc.withMetadataCopy(MetadataMergeSourceIndex, itoa(index))
+	if index < len(labels) && labels[index] != "" {
+		doc.Metadata[MetadataMergeSourceLabel] = labels[index]
+	}
+	return doc
+}
+
+// applySourceQuota drops documents beyond each source's quota or share
+// and reports per-source kept and dropped counts.
+func applySourceQuota(docs []Document, opts MergeOptions) ([]Document, map[string]MergeSourceStats, error) {
+	stats := make(map[string]MergeSourceStats)
+	bySource := make(map[string][]int)
+	for i, doc := range docs {
+		bySource[doc.Source] = append(bySource[doc.Source], i)
+	}
+
+	for _, source := range sortedKeys(opts.SourceShare) {
+		if share := opts.SourceShare[source]; !(share >= 0 && share <= 1) {
+			return nil, nil, fmt.Errorf("share %v for source %q is not between 0 and 1", share, source)
+		}
+	}
+
+	keep := make([]bool, len(docs))
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	for _, source := range sortedKeys(bySource) {
+		indices := bySource[source]
+		quota, limited := opts.SourceQuota[source]
+		if share, ok := opts.SourceShare[source]; ok {
+			// The epsilon keeps shares such as 0.29 of 100 from rounding
+			// down to 28 through floating-point error.
+			n := int(math.Floor(share*float64(len(docs)) + 1e-9))
+			if !limited || n < quota {
+				quota, limited = n, true
+			}
+		}
+		if !limited || quota >= len(indices) {
+			for _, i := range indices {
+				keep[i] = true
+			}
+			stats[source] = MergeSourceStats{Kept: len(indices)}
+			continue
+		}
+		if quota < 0 {
+			return nil, nil, fmt.Errorf("negative quota %d for source %q", quota, source)
+		}
+
+		switch opts.SampleStrategy {
+		case SampleHead, "":
+		case SampleRandom:
+			rng.Shuffle(len(indices), func(a, b int) { indices[a], indices[b] = indices[b], indices[a] })
+		default:
+			return nil, nil, fmt.Errorf("unknown sample strategy: %q", opts.SampleStrategy)
+		}
+
+		for _, i := range indices[:quota] {
+			keep[i] = true
+		}
+		stats[source] = MergeSourceStats{Kept: quota, Dropped: len(indices) - quota}
+	}
+
+	kept := make([]Document, 0, len(docs))
+	for i, doc := range docs {
+		if keep[i] {
+			kept = append(kept, doc)
+		}
+	}
+
+	return kept, stats, nil
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Merge creates a node that combines multiple DocumentSource outputs.
 // This is typically used after ThenParallel to merge results from multiple sources.
 //
@@ -50,6 +235,18 @@ func Merge() *core.Node[MergeInput, MergeOutput] {
 	return core.NewNode("transform.Merge", MergeActivity, MergeInput{})
 }
 
+// MergeWith creates a merge node configured with opts.
+//
+// Example:
+//
+//	transform.MergeWith(transform.MergeOptions{
+//	    SourceQuota:    map[string]int{"jira": 1000},
+//	    SampleStrategy: transform.SampleRandom,
+//	})
+func MergeWith(opts MergeOptions) *core.Node[MergeInput, MergeOutput] {
+	return core.NewNode("transform.Merge", MergeActivity, MergeInput{Options: opts})
+}
+
 // MergeDocuments is a utility function to merge document slices directly.
 func MergeDocuments(sources ...[]Document) []Document {
 	var total int
@@ -65,6 +262,25 @@ func MergeDocuments(sources ...[]Document) []Document {
 	return docs
 }
 
+// MergeDocumentsTo writes documents from each source to w in order.
+// Unlike MergeDocuments it never allocates the merged slice, so it scales
+// to very large inputs when w streams to storage. It returns the number
+// of documents written. The caller closes w. Wrap w in a DedupWriter to
+// drop duplicates with bounded memory.
+func MergeDocumentsTo(ctx context.Context, w DocumentWriter, sources ...[]Document) (int, error) {
+	var n int
+	for _, s := range sources {
+		for _, doc := range s {
+			if err := w.Write(ctx, doc); err != nil {
+				return n, fmt.Errorf("write document %s: %w", doc.ID, err)
+			}
+			n++
+		}
+	}
+
+	return n, nil
+}
+
 // MergeSources merges DocumentSource implementations into a single document list.
 func MergeSources(sources ...DocumentSource) []Document {
 	var total int
@@ -92,8 +308,12 @@ type MergeRefsOutput struct {
 }
 
 // MergeRefsActivity merges documents from multiple DataRefs into a single DataRef.
+// The merged payload is rebuilt from the input refs on every attempt and
+// depends only on their contents, so a retried activity stores a ref
+// with the same Checksum and Count rather than accumulating documents;
+// only the backend-assigned StorageKey and CreatedAt differ.
 func MergeRefsActivity(ctx context.Context, input MergeRefsInput) (MergeRefsOutput, error) {
-	var allDocs []Document
+	allDocs := []Document{}
 
 	for _, ref := range input.Refs {
 		docs, err := LoadDocuments(ctx, ref)
@@ -0,0 +1,520 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+func TestMergeActivitySourceQuota(t *testing.T) {
+	t.Parallel()
+
+	batch := DocumentBatch{Documents: []Document{
+		{ID: "j1", Source: "jira"},
+		{ID: "c1", Source: "confluence"},
+		{ID: "j2", Source: "jira"},
+		{ID: "j3", Source: "jira"},
+		{ID: "c2", Source: "confluence"},
+	}}
+
+	tests := []struct {
+		name     string
+		opts     MergeOptions
+		wantIDs  []string
+		wantJira MergeSourceStats
+	}{
+		{
+			name:     "no quota",
+			wantIDs:  []string{"j1", "c1", "j2", "j3", "c2"},
+			wantJira: MergeSourceStats{Kept: 3},
+		},
+		{
+			name:     "head",
+			opts:     MergeOptions{SourceQuota: map[string]int{"jira": 1}},
+			wantIDs:  []string{"j1", "c1", "c2"},
+			wantJira: MergeSourceStats{Kept: 1, Dropped: 2},
+		},
+		{
+			name:     "quota above count",
+			opts:     MergeOptions{SourceQuota: map[string]int{"jira": 10}},
+			wantIDs:  []string{"j1", "c1", "j2", "j3", "c2"},
+			wantJira: MergeSourceStats{Kept: 3},
+		},
+		{
+			name:     "share",
+			opts:     MergeOptions{SourceShare: map[string]float64{"jira": 0.4}},
+			wantIDs:  []string{"j1", "c1", "j2", "c2"},
+			wantJira: MergeSourceStats{Kept: 2, Dropped: 1},
+		},
+		{
+			name:     "quota below share",
+			opts:     MergeOptions{SourceQuota: map[string]int{"jira": 1}, SourceShare: map[string]float64{"jira": 0.8}},
+			wantIDs:  []string{"j1", "c1", "c2"},
+			wantJira: MergeSourceStats{Kept: 1, Dropped: 2},
+		},
+		{
+			name:     "share below quota",
+			opts:     MergeOptions{SourceQuota: map[string]int{"jira": 10}, SourceShare: map[string]float64{"jira": 0.2}},
+			wantIDs:  []string{"j1", "c1", "c2"},
+			wantJira: MergeSourceStats{Kept: 1, Dropped: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := MergeActivity(context.Background(), MergeInput{Sources: []DocumentSource{batch}, Options: tt.opts})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(out.Documents) != len(tt.wantIDs) {
+				t.Fatalf("got %d documents, want %d", len(out.Documents), len(tt.wantIDs))
+			}
+			for i, id := range tt.wantIDs {
+				if out.Documents[i].ID != id {
+					t.Errorf("Documents[%d].ID = %q, want %q", i, out.Documents[i].ID, id)
+				}
+			}
+			if out.Sources["jira"] != tt.wantJira {
+				t.Errorf("Sources[jira] = %+v, want %+v", out.Sources["jira"], tt.wantJira)
+			}
+		})
+	}
+}
+
+func TestMergeActivityInvalidSourceShare(t *testing.T) {
+	t.Parallel()
+
+	for _, share := range []float64{-0.1, 1.5, math.NaN()} {
+		opts := MergeOptions{SourceShare: map[string]float64{"jira": share}}
+		if _, err := MergeActivity(context.Background(), MergeInput{Options: opts}); err == nil {
+			t.Errorf("share %v: expected error", share)
+		}
+	}
+}
+
+func TestMergeActivityRandomQuotaIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	docs := make([]Document, 50)
+	for i := range docs {
+		docs[i] = Document{ID: itoa(i), Source: "jira"}
+	}
+	input := MergeInput{
+		Sources: []DocumentSource{DocumentBatch{Documents: docs}},
+		Options: MergeOptions{SourceQuota: map[string]int{"jira": 10}, SampleStrategy: SampleRandom, Seed: 42},
+	}
+
+	first, err := MergeActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := MergeActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first.Count != 10 {
+		t.Fatalf("got %d documents, want 10", first.Count)
+	}
+	prev := -1
+	for i := range first.Documents {
+		if first.Documents[i].ID != second.Documents[i].ID {
+			t.Errorf("run mismatch at %d: %q vs %q", i, first.Documents[i].ID, second.Documents[i].ID)
+		}
+		n, _ := strconv.Atoi(first.Documents[i].ID)
+		if n <= prev {
+			t.Errorf("documents not in original order: %d after %d", n, prev)
+		}
+		prev = n
+	}
+}
+
+func TestMergeActivityLabelSources(t *testing.T) {
+	t.Parallel()
+
+	shared := map[string]string{"k": "v"}
+	input := MergeInput{
+		Sources: []DocumentSource{
+			DocumentBatch{Documents: []Document{{ID: "a", Source: "web", Metadata: shared}}},
+			DocumentBatch{Documents: []Document{{ID: "b", Source: "web"}}},
+		},
+		Options: MergeOptions{LabelSources: true, SourceLabels: []string{"crawl"}},
+	}
+
+	out, err := MergeActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct{ index, label string }{{"0", "crawl"}, {"1", ""}}
+	for i, w := range want {
+		md := out.Documents[i].Metadata
+		if md[MetadataMergeSourceIndex] != w.index {
+			t.Errorf("doc %d index = %q, want %q", i, md[MetadataMergeSourceIndex], w.index)
+		}
+		if md[MetadataMergeSourceLabel] != w.label {
+			t.Errorf("doc %d label = %q, want %q", i, md[MetadataMergeSourceLabel], w.label)
+		}
+	}
+	if len(shared) != 1 {
+		t.Errorf("input metadata was mutated: %v", shared)
+	}
+
+	input.Options = MergeOptions{}
+	out, err = MergeActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := out.Documents[1].Metadata[MetadataMergeSourceIndex]; ok {
+		t.Error("documents labeled with LabelSources unset")
+	}
+}
+
+func TestMergeActivityTokensBySource(t *testing.T) {
+	t.Parallel()
+
+	input := MergeInput{
+		Sources: []DocumentSource{
+			DocumentBatch{Documents: []Document{
+				{ID: "a", Source: "web", Content: "one two three"},
+				{ID: "b", Source: "web", Content: "four five"},
+			}},
+			DocumentBatch{Documents: []Document{
+				{ID: "c", Source: "jira", Content: "six"},
+				{ID: "d", Source: "jira", Content: "seven eight"},
+			}},
+		},
+		Options: MergeOptions{
+			SourceQuota:       map[string]int{"jira": 1},
+			CountSourceTokens: true,
+			Tokenizer:         TokenizerWords,
+		},
+	}
+
+	out, err := MergeActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]int{"web": 5, "jira": 1}
+	if !reflect.DeepEqual(out.TokensBySource, want) {
+		t.Errorf("TokensBySource = %v, want %v", out.TokensBySource, want)
+	}
+
+	input.Options.Tokenizer = "missing"
+	if _, err := MergeActivity(context.Background(), input); err == nil {
+		t.Error("expected error for unknown tokenizer")
+	}
+
+	input.Options = MergeOptions{}
+	out, err = MergeActivity(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.TokensBySource != nil {
+		t.Errorf("TokensBySource = %v with CountSourceTokens unset, want nil", out.TokensBySource)
+	}
+}
+
+func TestEmptyInputsReturnEmptySlices(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	merged, err := MergeActivity(ctx, MergeInput{})
+	if err != nil {
+		t.Fatalf("MergeActivity: %v", err)
+	}
+	chunked, err := ChunkActivity(ctx, ChunkInput{})
+	if err != nil {
+		t.Fatalf("ChunkActivity: %v", err)
+	}
+	mergedAndChunked, err := MergeAndChunkActivity(ctx, MergeAndChunkInput{})
+	if err != nil {
+		t.Fatalf("MergeAndChunkActivity: %v", err)
+	}
+	mergedRefs, err := MergeRefsActivity(ctx, MergeRefsInput{})
+	if err != nil {
+		t.Fatalf("MergeRefsActivity: %v", err)
+	}
+	loaded, err := LoadDocuments(ctx, mergedRefs.Ref)
+	if err != nil {
+		t.Fatalf("LoadDocuments: %v", err)
+	}
+
+	outputs := map[string][]Document{
+		"MergeActivity":         merged.Documents,
+		"ChunkActivity":         chunked.Documents,
+		"MergeAndChunkActivity": mergedAndChunked.Documents,
+		"LoadDocuments":         loaded,
+		"MergeDocuments":        MergeDocuments(),
+		"MergeSources":          MergeSources(),
+	}
+
+	for name, docs := range outputs {
+		if docs == nil {
+			t.Errorf("%s returned a nil slice", name)
+		}
+		if len(docs) != 0 {
+			t.Errorf("%s returned %d documents, want 0", name, len(docs))
+		}
+		data, err := json.Marshal(docs)
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", name, err)
+		}
+		if string(data) != "[]" {
+			t.Errorf("%s marshals to %s, want []", name, data)
+		}
+	}
+}
+
+func TestMergeDocumentsTo(t *testing.T) {
+	t.Parallel()
+
+	docs1 := []Document{{ID: "1"}, {ID: "2"}}
+	docs2 := []Document{{ID: "3"}}
+
+	var w SliceWriter
+	n, err := MergeDocumentsTo(context.Background(), &w, docs1, nil, docs2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("wrote %d documents, want 3", n)
+	}
+
+	want := MergeDocuments(docs1, nil, docs2)
+	if len(w.Documents) != len(want) {
+		t.Fatalf("got %d documents, want %d", len(w.Documents), len(want))
+	}
+	for i := range want {
+		if w.Documents[i].ID != want[i].ID {
+			t.Errorf("Documents[%d].ID = %q, want %q", i, w.Documents[i].ID, want[i].ID)
+		}
+	}
+}
+
+func TestMergeDocumentsToWriteError(t *testing.T) {
+	t.Parallel()
+
+	w := &failingWriter{after: 1}
+	n, err := MergeDocumentsTo(context.Background(), w, []Document{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if n != 1 {
+		t.Errorf("wrote %d documents before failing, want 1", n)
+	}
+}
+
+func TestDedupWriter(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "1", Content: "a"},
+		{ID: "2", Content: "a"},
+		{ID: "3", Content: "b"},
+		{ID: "4", Content: "c"},
+		{ID: "5", Content: "d"},
+		{ID: "6", Content: "a"},
+		{ID: "7", Content: "d"},
+	}
+	tests := []struct {
+		window int
+		want   string
+	}{
+		// "a" last occurs at 2, four writes before 6, so a window of
+		// three has forgotten it.
+		{window: 3, want: "1,3,4,5,6"},
+		{window: 4, want: "1,3,4,5"},
+		{window: 0, want: "1,3,4,5"},
+	}
+	for _, tt := range tests {
+		var sink SliceWriter
+		w := NewDedupWriter(&sink, tt.window)
+		n, err := MergeDocumentsTo(context.Background(), w, docs[:3], docs[3:])
+		if err != nil {
+			t.Fatalf("window %d: %v", tt.window, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("window %d: close: %v", tt.window, err)
+		}
+
+		ids := make([]string, len(sink.Documents))
+		for i, doc := range sink.Documents {
+			ids[i] = doc.ID
+		}
+		if got := strings.Join(ids, ","); got != tt.want {
+			t.Errorf("window %d: wrote %s, want %s", tt.window, got, tt.want)
+		}
+		if n != len(docs) || w.Removed() != len(docs)-len(ids) {
+			t.Errorf("window %d: n=%d Removed=%d, want %d and %d", tt.window, n, w.Removed(), len(docs), len(docs)-len(ids))
+		}
+		if tt.window > 0 && len(w.counts) > tt.window {
+			t.Errorf("window %d: remembers %d fingerprints", tt.window, len(w.counts))
+		}
+	}
+}
+
+// failingWriter fails every write after the first `after` writes.
+type failingWriter struct {
+	after   int
+	written int
+}
+
+func (w *failingWriter) Write(ctx context.Context, doc Document) error {
+	if w.written >= w.after {
+		return errors.New("sink full")
+	}
+	w.written++
+	return nil
+}
+
+func (w *failingWriter) Close() error { return nil }
+
+// discardWriter is a DocumentWriter that drops every document.
+type discardWriter struct{}
+
+func (discardWriter) Write(context.Context, Document) error { return nil }
+
+func (discardWriter) Close() error { return nil }
+
+func benchmarkSources(numSources, perSource int) [][]Document {
+	sources := make([][]Document, numSources)
+	for i := range sources {
+		sources[i] = make([]Document, perSource)
+		for j := range sources[i] {
+			sources[i][j] = Document{ID: itoa(i*perSource + j), Content: "content", Source: "bench"}
+		}
+	}
+	return sources
+}
+
+func BenchmarkMergeDocuments(b *testing.B) {
+	sources := benchmarkSources(100, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = MergeDocuments(sources...)
+	}
+}
+
+func BenchmarkMergeActivity(b *testing.B) {
+	ctx := context.Background()
+	var input MergeInput
+	for _, docs := range benchmarkSources(100, 1000) {
+		input.Sources = append(input.Sources, DocumentBatch{Documents: docs})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := MergeActivity(ctx, input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMergeDocumentsTo(b *testing.B) {
+	sources := benchmarkSources(100, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := MergeDocumentsTo(context.Background(), discardWriter{}, sources...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMergeRefsActivityIdempotent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var refs []core.DataRef
+	for _, docs := range [][]Document{
+		{{ID: "a", Content: "one", Metadata: map[string]string{"z": "1", "a": "2"}, UpdatedAt: updated}},
+		{{ID: "b", Content: "two", Attributes: Attributes{"score": 0.5, "n": int64(3)}}},
+	} {
+		ref, err := StoreDocuments(ctx, docs)
+		if err != nil {
+			t.Fatalf("StoreDocuments: %v", err)
+		}
+		refs = append(refs, ref)
+	}
+
+	first, err := MergeRefsActivity(ctx, MergeRefsInput{Refs: refs})
+	if err != nil {
+		t.Fatalf("first attempt: %v", err)
+	}
+	retry, err := MergeRefsActivity(ctx, MergeRefsInput{Refs: refs})
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+
+	if first.Ref.Checksum == "" || first.Ref.Checksum != retry.Ref.Checksum {
+		t.Errorf("retry checksum = %q, want %q", retry.Ref.Checksum, first.Ref.Checksum)
+	}
+	if first.Count != 2 || retry.Count != first.Count || retry.Ref.Count != first.Ref.Count {
+		t.Errorf("retry counts = %d/%d, want %d/%d", retry.Count, retry.Ref.Count, first.Count, first.Ref.Count)
+	}
+}
+
+func TestMergeAndChunkToRefActivity(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ref, err := StoreDocuments(ctx, []Document{{ID: "b", Content: "four five six"}})
+	if err != nil {
+		t.Fatalf("StoreDocuments: %v", err)
+	}
+	input := MergeAndChunkToRefInput{
+		Sources: []DocumentSource{DocumentBatch{Documents: []Document{{ID: "a", Content: "one two"}}}},
+		Refs:    []core.DataRef{ref},
+		Options: ChunkOptions{MaxTokens: 2, Separator: " "},
+	}
+
+	out, err := MergeAndChunkToRefActivity(ctx, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs, err := LoadDocuments(ctx, out.Ref)
+	if err != nil {
+		t.Fatalf("LoadDocuments: %v", err)
+	}
+
+	var ids []string
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	if want := []string{"a", "b#0", "b#1"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("stored IDs %q, want %q", ids, want)
+	}
+	if out.Count != 3 || out.Ref.Count != 3 || out.Stats.SplitDocuments != 1 {
+		t.Errorf("got Count=%d Ref.Count=%d SplitDocuments=%d, want 3, 3, 1", out.Count, out.Ref.Count, out.Stats.SplitDocuments)
+	}
+
+	input.Options.DryRun = true
+	dry, err := MergeAndChunkToRefActivity(ctx, input)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if dry.Count != 3 || dry.Ref != (core.DataRef{}) {
+		t.Errorf("dry run got Count=%d Ref=%+v, want 3 and no ref", dry.Count, dry.Ref)
+	}
+}
@@ -0,0 +1,300 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Metadata keys holding a chunk's [start, end) token range within its
+// parent, recorded when ChunkOptions.RecordTokenRanges is set.
+const (
+	MetadataTokenStart = "token_start"
+	MetadataTokenEnd   = "token_end"
+)
+
+// Metadata keys holding a StrategyChars chunk's [start, end) range of
+// characters (runes) within its parent, recorded instead of a token
+// range when ChunkOptions.RecordTokenRanges is set.
+const (
+	MetadataCharStart = "char_start"
+	MetadataCharEnd   = "char_end"
+)
+
+// recordTokenRange stores r on chunk, whose metadata must be its own copy.
+func recordTokenRange(chunk *Document, r [2]int) {
+	recordRange(chunk, r, MetadataTokenStart, MetadataTokenEnd)
+}
+
+// recordCharRange stores the character range r on chunk, whose metadata
+// must be its own copy.
+func recordCharRange(chunk *Document, r [2]int) {
+	recordRange(chunk, r, MetadataCharStart, MetadataCharEnd)
+}
+
+// recordRange stores r on chunk under the given keys.
+func recordRange(chunk *Document, r [2]int, startKey, endKey string) {
+	if chunk.Metadata == nil {
+		chunk.Metadata = make(map[string]string, 2)
+	}
+	chunk.Metadata[startKey] = itoa(r[0])
+	chunk.Metadata[endKey] = itoa(r[1])
+}
+
+// tokenRange returns the token range recorded on chunk.
+func tokenRange(chunk Document) (int, int, bool) {
+	return metadataRange(chunk, MetadataTokenStart, MetadataTokenEnd)
+}
+
+// chunkRange returns the range recorded on chunk and whether it counts
+// characters rather than tokens.
+func chunkRange(chunk Document) (start, end int, chars, ok bool) {
+	if start, end, ok := tokenRange(chunk); ok {
+		return start, end, false, true
+	}
+	start, end, ok = metadataRange(chunk, MetadataCharStart, MetadataCharEnd)
+	return start, end, true, ok
+}
+
+// metadataRange parses the range stored on chunk under the given keys.
+func metadataRange(chunk Document, startKey, endKey string) (int, int, bool) {
+	start, err := strconv.Atoi(chunk.Metadata[startKey])
+	if err != nil {
+		return 0, 0, false
+	}
+	end, err := strconv.Atoi(chunk.Metadata[endKey])
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// withoutRanges returns chunk without any recorded token or character
+// range, e.g. after its content was merged with another chunk's.
+func withoutRanges(chunk Document) Document {
+	keys := []string{MetadataTokenStart, MetadataTokenEnd, MetadataCharStart, MetadataCharEnd}
+	for _, key := range keys {
+		if _, ok := chunk.Metadata[key]; ok {
+			metadata := copyMetadata(chunk.Metadata)
+			for _, key := range keys {
+				delete(metadata, key)
+			}
+			chunk.Metadata = metadata
+			break
+		}
+	}
+	return chunk
+}
+
+// DedupeOverlap stitches retrieved chunks back into continuous text for a
+// prompt. Chunks are ordered by parent and ChunkIndex; where consecutive
+// chunks of the same parent overlap according to their recorded token
+// ranges (see ChunkOptions.RecordTokenRanges), the repeated leading
+// tokens of the later chunk are dropped and the two are joined with a
+// space. Chunks wholly covered by their predecessors are dropped. Chunks
+// from different parents, chunks that are not adjacent, and chunks
+// without token ranges are joined with a blank line.
+//
+// Overlapping tokens are counted as whitespace-separated words, which
+// matches the chunker for whitespace separators. StrategyChars chunks
+// record character ranges instead: their repeated leading characters
+// are dropped and the rest is appended directly, or after a space where
+// consecutive chunks were trimmed apart at whitespace.
+func DedupeOverlap(chunks []Document) string {
+	sorted := make([]Document, len(chunks))
+	copy(sorted, chunks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := overlapParent(sorted[i]), overlapParent(sorted[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return sorted[i].ChunkIndex < sorted[j].ChunkIndex
+	})
+
+	var b strings.Builder
+	var prevParent string
+	prevEnd, prevIndex, prevRanged, prevChars := 0, 0, false, false
+
+	for i, chunk := range sorted {
+		parent := overlapParent(chunk)
+		start, end, chars, ranged := chunkRange(chunk)
+		content := chunk.Content
+		sep := "\n\n"
+
+		if i > 0 && parent == prevParent && ranged && prevRanged && chars == prevChars {
+			switch {
+			case start <= prevEnd && end <= prevEnd:
+				continue
+			case start <= prevEnd && chars:
+				content = dropRunes(content, prevEnd-start)
+				sep = ""
+			case start <= prevEnd:
+				content = dropTokens(content, prevEnd-start)
+				sep = " "
+			case chars && chunk.ChunkIndex == prevIndex+1:
+				sep = " "
+			}
+		}
+
+		if b.Len() > 0 && content != "" {
+			b.WriteString(sep)
+		}
+		b.WriteString(content)
+
+		prevParent, prevIndex, prevRanged = parent, chunk.ChunkIndex, ranged
+		if ranged {
+			prevEnd, prevChars = end, chars
+		}
+	}
+
+	return b.String()
+}
+
+// ReconstructContent rebuilds the parent content of chunks from their
+// recorded token ranges (see ChunkOptions.RecordTokenRanges), dropping
+// the overlap between consecutive chunks, to verify that chunking was
+// lossless. Tokens are joined with single spaces, so the result matches
+// the parent's tokens rather than its exact whitespace. It returns an
+// error if the chunks belong to different parents, a chunk index or
+// token range is missing, ranges leave a gap, or a chunk's content does
+// not match its range or the overlapping tokens of its predecessor.
+//
+// StrategyChars chunks are rebuilt from their character ranges instead,
+// keeping the parent's exact text except whitespace trimmed from the
+// edges of chunks, which is restored as single spaces.
+func ReconstructContent(chunks []Document) (string, error) {
+	if len(chunks) == 0 {
+		return "", errors.New("no chunks")
+	}
+
+	sorted := make([]Document, len(chunks))
+	copy(sorted, chunks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ChunkIndex < sorted[j].ChunkIndex
+	})
+
+	parent := overlapParent(sorted[0])
+	if _, _, chars, ok := chunkRange(sorted[0]); ok && chars {
+		return reconstructChars(sorted, parent)
+	}
+
+	var tokens []string
+	for i, chunk := range sorted {
+		if p := overlapParent(chunk); p != parent {
+			return "", fmt.Errorf("chunk %s belongs to %s, not %s", chunk.ID, p, parent)
+		}
+		if chunk.ChunkIndex != i {
+			return "", fmt.Errorf("chunk %d of %s is missing", i, parent)
+		}
+		start, end, ok := tokenRange(chunk)
+		if !ok {
+			return "", fmt.Errorf("chunk %s has no token range", chunk.ID)
+		}
+		if start > len(tokens) {
+			return "", fmt.Errorf("chunk %s starts at token %d, leaving a gap after token %d", chunk.ID, start, len(tokens))
+		}
+
+		fields := strings.Fields(chunk.Content)
+		if len(fields) != end-start {
+			return "", fmt.Errorf("chunk %s has %d tokens, but its range [%d, %d) holds %d", chunk.ID, len(fields), start, end, end-start)
+		}
+		for k := start; k < min(end, len(tokens)); k++ {
+			if tokens[k] != fields[k-start] {
+				return "", fmt.Errorf("chunk %s disagrees with its predecessor at token %d", chunk.ID, k)
+			}
+		}
+		if end > len(tokens) {
+			tokens = append(tokens, fields[len(tokens)-start:]...)
+		}
+	}
+
+	return strings.Join(tokens, " "), nil
+}
+
+// reconstructChars is ReconstructContent for chunks, sorted by
+// ChunkIndex, with character ranges.
+func reconstructChars(sorted []Document, parent string) (string, error) {
+	var text []rune
+	var known []bool
+	for i, chunk := range sorted {
+		if p := overlapParent(chunk); p != parent {
+			return "", fmt.Errorf("chunk %s belongs to %s, not %s", chunk.ID, p, parent)
+		}
+		if chunk.ChunkIndex != i {
+			return "", fmt.Errorf("chunk %d of %s is missing", i, parent)
+		}
+		start, end, chars, ok := chunkRange(chunk)
+		if !ok || !chars {
+			return "", fmt.Errorf("chunk %s has no character range", chunk.ID)
+		}
+
+		runes := []rune(chunk.Content)
+		if len(runes) != end-start {
+			return "", fmt.Errorf("chunk %s has %d characters, but its range [%d, %d) holds %d", chunk.ID, len(runes), start, end, end-start)
+		}
+		// A gap is whitespace trimmed from the edges of both chunks.
+		for len(text) < start {
+			text = append(text, ' ')
+			known = append(known, false)
+		}
+		for k := start; k < min(end, len(text)); k++ {
+			if !known[k] {
+				text[k], known[k] = runes[k-start], true
+			} else if text[k] != runes[k-start] {
+				return "", fmt.Errorf("chunk %s disagrees with its predecessor at character %d", chunk.ID, k)
+			}
+		}
+		for k := len(text); k < end; k++ {
+			text = append(text, runes[k-start])
+			known = append(known, true)
+		}
+	}
+
+	return string(text), nil
+}
+
+// overlapParent groups chunks by parent; unchunked documents stand alone.
+func overlapParent(doc Document) string {
+	if doc.IsChunk() {
+		return doc.ParentID
+	}
+	return doc.ID
+}
+
+// dropTokens removes the first n whitespace-separated tokens of s and the
+// whitespace that follows them.
+func dropTokens(s string, n int) string {
+	i := 0
+	for ; n > 0 && i < len(s); n-- {
+		i += leadingSpace(s[i:])
+		for i < len(s) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+	}
+	i += leadingSpace(s[i:])
+	return s[i:]
+}
+
+// dropRunes removes the first n runes of s.
+func dropRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[i:]
+		}
+		n--
+	}
+	return ""
+}
+
+// leadingSpace returns the byte length of the whitespace prefix of s.
+func leadingSpace(s string) int {
+	return len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace))
+}
@@ -0,0 +1,147 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedupeOverlap(t *testing.T) {
+	t.Parallel()
+
+	words := numberedWords(30)
+	doc := Document{ID: "doc", Content: strings.Join(words, " ")}
+
+	tests := []struct {
+		name string
+		opts ChunkOptions
+		pick []int
+		want string
+	}{
+		{
+			name: "all chunks out of order",
+			opts: ChunkOptions{MaxTokens: 10, Overlap: 3, Separator: " ", RecordTokenRanges: true},
+			pick: []int{3, 0, 2, 1},
+			want: doc.Content,
+		},
+		{
+			name: "balanced adjacent",
+			opts: ChunkOptions{Strategy: StrategyBalanced, MaxTokens: 12, Overlap: 4, Separator: " ", RecordTokenRanges: true},
+			pick: []int{1, 0},
+			want: strings.Join(words[:15], " "),
+		},
+		{
+			name: "gap",
+			opts: ChunkOptions{MaxTokens: 10, Overlap: 3, Separator: " ", RecordTokenRanges: true},
+			pick: []int{0, 2},
+			want: strings.Join(words[:10], " ") + "\n\n" + strings.Join(words[14:24], " "),
+		},
+		{
+			name: "duplicate chunk",
+			opts: ChunkOptions{MaxTokens: 10, Overlap: 3, Separator: " ", RecordTokenRanges: true},
+			pick: []int{0, 0},
+			want: strings.Join(words[:10], " "),
+		},
+		{
+			name: "no token ranges",
+			opts: ChunkOptions{MaxTokens: 10, Overlap: 3, Separator: " "},
+			pick: []int{0, 1},
+			want: strings.Join(words[:10], " ") + "\n\n" + strings.Join(words[7:17], " "),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			chunks := chunkDocument(doc, tt.opts)
+			picked := make([]Document, len(tt.pick))
+			for i, idx := range tt.pick {
+				picked[i] = chunks[idx]
+			}
+
+			if got := DedupeOverlap(picked); got != tt.want {
+				t.Errorf("DedupeOverlap() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconstructContent(t *testing.T) {
+	t.Parallel()
+
+	words := numberedWords(23)
+	content := strings.Join(words, "\n")
+
+	for _, strategy := range []ChunkStrategy{StrategyTokens, StrategyBalanced} {
+		out := mustChunk(t, ChunkInput{
+			Documents: []Document{{ID: "doc", Content: content}},
+			Options:   ChunkOptions{Strategy: strategy, MaxTokens: 6, Overlap: 2, Separator: " ", RecordTokenRanges: true},
+		})
+
+		got, err := ReconstructContent(out.Documents)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", strategy, err)
+		}
+		if want := strings.Join(words, " "); got != want {
+			t.Errorf("%s: got %q, want %q", strategy, got, want)
+		}
+	}
+
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{{ID: "doc", Content: content}},
+		Options:   ChunkOptions{MaxTokens: 6, Overlap: 2, Separator: " ", RecordTokenRanges: true},
+	})
+	chunks := out.Documents
+
+	tampered := append([]Document(nil), chunks...)
+	tampered[1].Content = strings.Replace(tampered[1].Content, "w4", "w99", 1)
+
+	bad := map[string][]Document{
+		"empty":        nil,
+		"missing":      append(append([]Document(nil), chunks[:1]...), chunks[2:]...),
+		"no range":     {{ID: "x", ParentID: "doc", Content: "w0"}},
+		"inconsistent": tampered,
+	}
+	for name, input := range bad {
+		if _, err := ReconstructContent(input); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCharRangesRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	const content = "alpha beta gamma delta epsilon zeta eta theta iota kappa lambda mu"
+	noSnap := false
+	for _, snap := range []*bool{nil, &noSnap} {
+		out := mustChunk(t, ChunkInput{
+			Documents: []Document{{ID: "doc", Content: content}},
+			Options:   ChunkOptions{Strategy: StrategyChars, MaxTokens: 16, Overlap: 8, SnapToWords: snap, RecordTokenRanges: true},
+		})
+		if out.Count < 6 {
+			t.Fatalf("snap=%v: got %d chunks, want at least 6", snap == nil, out.Count)
+		}
+		for _, chunk := range out.Documents {
+			if _, ok := chunk.Metadata[MetadataTokenStart]; ok {
+				t.Fatalf("snap=%v: chunk %s has a token range", snap == nil, chunk.ID)
+			}
+		}
+
+		reversed := make([]Document, out.Count)
+		for i, chunk := range out.Documents {
+			reversed[out.Count-1-i] = chunk
+		}
+		if got := DedupeOverlap(reversed); got != content {
+			t.Errorf("snap=%v: DedupeOverlap() = %q, want %q", snap == nil, got, content)
+		}
+
+		got, err := ReconstructContent(out.Documents)
+		if err != nil {
+			t.Fatalf("snap=%v: ReconstructContent: %v", snap == nil, err)
+		}
+		if got != content {
+			t.Errorf("snap=%v: ReconstructContent() = %q, want %q", snap == nil, got, content)
+		}
+	}
+}
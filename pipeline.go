@@ -0,0 +1,257 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// Step is a serializable description of a transform applied by
+// PipelineRefActivity. Op names a registered step and Options holds its
+// JSON-encoded options.
+type Step struct {
+	Op      string          `json:"op"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// StepFunc applies a step to a batch of documents. options is the step's
+// JSON-encoded options, which may be empty. PipelineRefActivity streams
+// its source, so the batch holds a single document and the step's output
+// for it; a step must not depend on seeing other documents.
+type StepFunc func(ctx context.Context, docs []Document, options json.RawMessage) ([]Document, error)
+
+// Built-in step operations.
+const (
+	StepChunk            = "chunk"
+	StepParseFrontmatter = "parse_frontmatter"
+	StepExtractKeywords  = "extract_keywords"
+	StepFilter           = "filter"
+	StepHTMLToText       = "html_to_text"
+	StepClean            = "clean"
+)
+
+var steps = struct {
+	mu  sync.RWMutex
+	ops map[string]StepFunc
+}{
+	ops: map[string]StepFunc{
+		StepChunk: func(ctx context.Context, docs []Document, options json.RawMessage) ([]Document, error) {
+			var opts ChunkOptions
+			if err := decodeStepOptions(options, &opts); err != nil {
+				return nil, err
+			}
+			if opts.Order == OrderInterleaved || opts.Order == OrderReverse {
+				return nil, fmt.Errorf("order %q orders chunks across documents, but steps see one document at a time", opts.Order)
+			}
+			out, err := ChunkActivity(ctx, ChunkInput{Documents: docs, Options: opts})
+			return out.Documents, err
+		},
+		StepParseFrontmatter: func(ctx context.Context, docs []Document, _ json.RawMessage) ([]Document, error) {
+			out, err := ParseFrontmatterActivity(ctx, ParseFrontmatterInput{Documents: docs})
+			return out.Documents, err
+		},
+		StepExtractKeywords: func(ctx context.Context, docs []Document, options json.RawMessage) ([]Document, error) {
+			var opts KeywordOptions
+			if err := decodeStepOptions(options, &opts); err != nil {
+				return nil, err
+			}
+			out, err := ExtractKeywordsActivity(ctx, ExtractKeywordsInput{Documents: docs, Options: opts})
+			return out.Documents, err
+		},
+		StepFilter: func(ctx context.Context, docs []Document, options json.RawMessage) ([]Document, error) {
+			var opts FilterOptions
+			if err := decodeStepOptions(options, &opts); err != nil {
+				return nil, err
+			}
+			out, err := FilterActivity(ctx, FilterInput{Documents: docs, Options: opts})
+			return out.Documents, err
+		},
+		StepHTMLToText: func(ctx context.Context, docs []Document, options json.RawMessage) ([]Document, error) {
+			var opts HTMLToTextOptions
+			if err := decodeStepOptions(options, &opts); err != nil {
+				return nil, err
+			}
+			out, err := HTMLToTextActivity(ctx, HTMLToTextInput{Documents: docs, Options: opts})
+			return out.Documents, err
+		},
+		StepClean: func(ctx context.Context, docs []Document, options json.RawMessage) ([]Document, error) {
+			var opts CleanOptions
+			if err := decodeStepOptions(options, &opts); err != nil {
+				return nil, err
+			}
+			out, err := CleanActivity(ctx, CleanInput{Documents: docs, Options: opts})
+			return out.Documents, err
+		},
+	},
+}
+
+// RegisterStep registers a step operation under op, replacing any existing
+// registration. Register custom steps on every worker before starting it.
+func RegisterStep(op string, fn StepFunc) {
+	steps.mu.Lock()
+	defer steps.mu.Unlock()
+	steps.ops[op] = fn
+}
+
+// RegisteredSteps returns the names of all registered step operations.
+func RegisteredSteps() []string {
+	steps.mu.RLock()
+	defer steps.mu.RUnlock()
+	return sortedKeys(steps.ops)
+}
+
+// lookupStep returns the StepFunc registered under op.
+func lookupStep(op string) (StepFunc, bool) {
+	steps.mu.RLock()
+	defer steps.mu.RUnlock()
+	fn, ok := steps.ops[op]
+	return fn, ok
+}
+
+// NewStep creates a Step for op with JSON-encoded options.
+func NewStep(op string, options any) (Step, error) {
+	if options == nil {
+		return Step{Op: op}, nil
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return Step{}, fmt.Errorf("marshal %s options: %w", op, err)
+	}
+	return Step{Op: op, Options: data}, nil
+}
+
+// ChunkStep returns a Step that chunks documents with opts.
+func ChunkStep(opts ChunkOptions) Step {
+	step, _ := NewStep(StepChunk, opts)
+	return step
+}
+
+// ParseFrontmatterStep returns a Step that extracts frontmatter.
+func ParseFrontmatterStep() Step {
+	return Step{Op: StepParseFrontmatter}
+}
+
+// ExtractKeywordsStep returns a Step that extracts keywords with opts.
+func ExtractKeywordsStep(opts KeywordOptions) Step {
+	step, _ := NewStep(StepExtractKeywords, opts)
+	return step
+}
+
+// FilterStep returns a Step that filters documents with opts.
+func FilterStep(opts FilterOptions) Step {
+	step, _ := NewStep(StepFilter, opts)
+	return step
+}
+
+// HTMLToTextStep returns a Step that converts HTML content to text with opts.
+func HTMLToTextStep(opts HTMLToTextOptions) Step {
+	step, _ := NewStep(StepHTMLToText, opts)
+	return step
+}
+
+// CleanStep returns a Step that cleans document content with opts.
+func CleanStep(opts CleanOptions) Step {
+	step, _ := NewStep(StepClean, opts)
+	return step
+}
+
+// decodeStepOptions decodes JSON step options into dest. Empty options
+// leave dest unchanged.
+func decodeStepOptions(options json.RawMessage, dest any) error {
+	if len(options) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(options, dest); err != nil {
+		return fmt.Errorf("decode options: %w", err)
+	}
+	return nil
+}
+
+// PipelineRefInput is the input for PipelineRefActivity.
+type PipelineRefInput struct {
+	SourceRef core.DataRef
+	Steps     []Step
+}
+
+// PipelineRefOutput is the output of PipelineRefActivity.
+type PipelineRefOutput struct {
+	Ref   core.DataRef
+	Count int
+}
+
+// PipelineRefActivity streams the documents in SourceRef through each
+// step in order and stores the result as a new DataRef. Running several
+// steps in one activity avoids passing intermediate results between
+// activities. Documents are decoded one at a time and each passes through
+// every step before the next is decoded; the results are spooled with a
+// RefWriter, so neither the source nor any step's output is materialized
+// as a whole. The storage layer still reads the source payload, and
+// RefWriter.Close the result payload, in one piece.
+func PipelineRefActivity(ctx context.Context, input PipelineRefInput) (PipelineRefOutput, error) {
+	fns := make([]StepFunc, len(input.Steps))
+	for i, step := range input.Steps {
+		fn, ok := lookupStep(step.Op)
+		if !ok {
+			return PipelineRefOutput{}, fmt.Errorf("step %d: unknown op %q (registered: %v)", i, step.Op, RegisteredSteps())
+		}
+		fns[i] = fn
+	}
+
+	w, err := NewRefWriter(ctx)
+	if err != nil {
+		return PipelineRefOutput{}, err
+	}
+
+	var stepErr error
+	err = forEachDocument(ctx, input.SourceRef, func(doc Document) bool {
+		docs := []Document{doc}
+		for i, fn := range fns {
+			if docs, stepErr = fn(ctx, docs, input.Steps[i].Options); stepErr != nil {
+				stepErr = fmt.Errorf("document %s: step %d (%s): %w", doc.ID, i, input.Steps[i].Op, stepErr)
+				return false
+			}
+		}
+		for _, out := range docs {
+			if stepErr = w.Write(ctx, out); stepErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err == nil {
+		err = stepErr
+	}
+	if err != nil {
+		w.discard()
+		return PipelineRefOutput{}, err
+	}
+
+	if err := w.Close(); err != nil {
+		return PipelineRefOutput{}, err
+	}
+
+	return PipelineRefOutput{
+		Ref:   w.Ref(),
+		Count: w.Count(),
+	}, nil
+}
+
+// PipelineRef creates a node that applies steps to the documents in a
+// DataRef and stores the result.
+//
+// Example:
+//
+//	transform.PipelineRef(transform.PipelineRefInput{
+//	    SourceRef: ref,
+//	    Steps: []transform.Step{
+//	        transform.ParseFrontmatterStep(),
+//	        transform.CleanStep(transform.CleanOptions{CollapseWhitespace: true}),
+//	        transform.ChunkStep(transform.DefaultChunkOptions()),
+//	    },
+//	})
+func PipelineRef(input PipelineRefInput) *core.Node[PipelineRefInput, PipelineRefOutput] {
+	return core.NewNode("transform.PipelineRef", PipelineRefActivity, input)
+}
@@ -0,0 +1,128 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPipelineRefActivity(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := []Document{
+		{ID: "doc", Content: "---\nowner: sre\n---\n" + strings.Join(numberedWords(12), " "), Source: "test"},
+	}
+	ref, err := StoreDocuments(ctx, docs)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	input := PipelineRefInput{
+		SourceRef: ref,
+		Steps: []Step{
+			ParseFrontmatterStep(),
+			ChunkStep(ChunkOptions{MaxTokens: 8, Overlap: 2, Separator: "\n\n"}),
+		},
+	}
+
+	// Steps must survive serialization for worker execution.
+	data, err := json.Marshal(input)
+	if err != nil {
+		t.Fatalf("marshal input: %v", err)
+	}
+	var decoded PipelineRefInput
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal input: %v", err)
+	}
+
+	out, err := PipelineRefActivity(ctx, decoded)
+	if err != nil {
+		t.Fatalf("PipelineRefActivity: %v", err)
+	}
+
+	result, err := LoadDocuments(ctx, out.Ref)
+	if err != nil {
+		t.Fatalf("load result: %v", err)
+	}
+	if out.Count != 2 || len(result) != 2 {
+		t.Fatalf("got %d documents (Count %d), want 2", len(result), out.Count)
+	}
+	for _, chunk := range result {
+		if chunk.Metadata["owner"] != "sre" {
+			t.Errorf("chunk %s: owner = %q, want %q", chunk.ID, chunk.Metadata["owner"], "sre")
+		}
+		if strings.Contains(chunk.Content, "---") {
+			t.Errorf("chunk %s still contains frontmatter: %q", chunk.ID, chunk.Content)
+		}
+	}
+}
+
+func TestPipelineRefActivityUnknownOp(t *testing.T) {
+	t.Parallel()
+
+	_, err := PipelineRefActivity(context.Background(), PipelineRefInput{Steps: []Step{{Op: "bogus"}}})
+	if err == nil || !strings.Contains(err.Error(), `unknown op "bogus"`) {
+		t.Errorf("err = %v, want unknown op error", err)
+	}
+}
+
+func TestPipelineRefActivityStreamsDocuments(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := []Document{
+		{ID: "a", Content: "one   two\n\n\n\nthree", Source: "test"},
+		{ID: "b", Content: strings.Join(numberedWords(6), " "), Source: "test"},
+	}
+	ref, err := StoreDocuments(ctx, docs)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	out, err := PipelineRefActivity(ctx, PipelineRefInput{
+		SourceRef: ref,
+		Steps: []Step{
+			CleanStep(CleanOptions{CollapseWhitespace: true}),
+			ChunkStep(ChunkOptions{MaxTokens: 4, Separator: "\n\n"}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("PipelineRefActivity: %v", err)
+	}
+
+	result, err := LoadDocuments(ctx, out.Ref)
+	if err != nil {
+		t.Fatalf("load result: %v", err)
+	}
+	if out.Count != 3 || len(result) != 3 {
+		t.Fatalf("got %d documents (Count %d), want 3", len(result), out.Count)
+	}
+	if result[0].ID != "a" || result[0].Content != "one two\n\nthree" {
+		t.Errorf("first document = %s %q, want cleaned document a", result[0].ID, result[0].Content)
+	}
+	for _, chunk := range result[1:] {
+		if chunk.ParentID != "b" {
+			t.Errorf("chunk %s: parent = %q, want %q", chunk.ID, chunk.ParentID, "b")
+		}
+	}
+}
+
+func TestPipelineRefActivityStepError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ref, err := StoreDocuments(ctx, []Document{{ID: "doc", Content: "one two", Source: "test"}})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	_, err = PipelineRefActivity(ctx, PipelineRefInput{
+		SourceRef: ref,
+		Steps:     []Step{ChunkStep(ChunkOptions{MaxTokens: 4, Order: OrderReverse})},
+	})
+	if err == nil || !strings.Contains(err.Error(), "document doc: step 0 (chunk)") {
+		t.Errorf("err = %v, want step error for document doc", err)
+	}
+}
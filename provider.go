@@ -15,7 +15,33 @@ func Provider() core.Provider {
 	return core.NewProvider(ProviderName, ProviderVersion).
 		AddActivity("transform.Merge", MergeActivity).
 		AddActivity("transform.MergeRefs", MergeRefsActivity).
-		AddActivity("transform.Chunk", ChunkActivity)
+		AddActivity("transform.Chunk", ChunkActivity).
+		AddActivity("transform.ParseFrontmatter", ParseFrontmatterActivity).
+		AddActivity("transform.ExtractKeywords", ExtractKeywordsActivity).
+		AddActivity("transform.DedupByEmbedding", DedupByEmbeddingActivity).
+		AddActivity("transform.PipelineRef", PipelineRefActivity).
+		AddActivity("transform.Filter", FilterActivity).
+		AddActivity("transform.Identity", IdentityActivity).
+		AddActivity("transform.Tag", TagActivity).
+		AddActivity("transform.GenerateIDs", GenerateIDsActivity).
+		AddActivity("transform.EmbedRef", EmbedRefActivity).
+		AddActivity("transform.Dedup", DedupActivity).
+		AddActivity("transform.HTMLToText", HTMLToTextActivity).
+		AddActivity("transform.StripMetadata", StripMetadataActivity).
+		AddActivity("transform.Classify", ClassifyActivity).
+		AddActivity("transform.Derive", DeriveActivity).
+		AddActivity("transform.PurgeExpired", PurgeExpiredActivity).
+		AddActivity("transform.Transform", TransformActivity).
+		AddActivity("transform.Concat", ConcatActivity).
+		AddActivity("transform.DefaultSource", DefaultSourceActivity).
+		AddActivity("transform.Explode", ExplodeActivity).
+		AddActivity("transform.Clean", CleanActivity).
+		AddActivity("transform.FillUpdatedAt", FillUpdatedAtActivity).
+		AddActivity("transform.MergeAndChunkToRef", MergeAndChunkToRefActivity).
+		AddActivity("transform.Shard", ShardActivity).
+		AddActivity("transform.Compact", CompactActivity).
+		AddActivity("transform.Limit", LimitActivity).
+		AddActivity("transform.Snippet", SnippetActivity)
 }
 
 // RegisterActivities registers all transform activities with a Temporal worker.
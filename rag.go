@@ -0,0 +1,45 @@
+package transform
+
+import "github.com/resolute-sh/resolute/core"
+
+// Output keys of the nodes added by StandardRAGPipeline.
+const (
+	RAGMergeKey = "transform.rag.merge"
+	RAGChunkKey = "transform.rag.chunk"
+	RAGEmbedKey = "transform.rag.embed"
+)
+
+// StandardRAGPipeline builds the canonical manually triggered RAG flow:
+// the sources run in parallel, their document refs are merged and
+// chunked with chunkOpts, and the chunks are embedded with the embedder
+// registered under embedder. Each source must output a value with a Ref
+// field holding its documents (see core.OutputRef). The embedded
+// documents are stored under the RAGEmbedKey output.
+//
+// Example:
+//
+//	flow := transform.StandardRAGPipeline("docs",
+//	    []core.ExecutableNode{jiraNode, confluenceNode},
+//	    transform.DefaultChunkOptions(),
+//	    "ollama",
+//	)
+func StandardRAGPipeline(name string, sources []core.ExecutableNode, chunkOpts ChunkOptions, embedder string) *core.Flow {
+	refs := make([]core.DataRef, len(sources))
+	for i, source := range sources {
+		refs[i] = core.OutputRef(source.OutputKey())
+	}
+
+	return core.NewFlow(name).
+		TriggeredBy(core.Manual(name)).
+		ThenParallel(name+".fetch", sources...).
+		Then(MergeRefs(MergeRefsInput{Refs: refs}).As(RAGMergeKey)).
+		Then(PipelineRef(PipelineRefInput{
+			SourceRef: core.OutputRef(RAGMergeKey),
+			Steps:     []Step{ChunkStep(chunkOpts)},
+		}).As(RAGChunkKey)).
+		Then(EmbedRef(EmbedRefInput{
+			SourceRef: core.OutputRef(RAGChunkKey),
+			Options:   EmbedOptions{Embedder: embedder},
+		}).As(RAGEmbedKey)).
+		Build()
+}
@@ -0,0 +1,120 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+type lengthEmbedder struct{}
+
+func (lengthEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+	return vectors, nil
+}
+
+type sourceOutput struct {
+	Ref core.DataRef
+}
+
+func TestStandardRAGPipeline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	RegisterEmbedder("test_length", lengthEmbedder{})
+
+	jiraRef, err := StoreDocuments(ctx, []Document{{ID: "j1", Content: "one two three four five six", Source: "jira"}})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	wikiRef, err := StoreDocuments(ctx, []Document{{ID: "w1", Content: "short page", Source: "wiki"}})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	noop := func(context.Context, struct{}) (sourceOutput, error) { return sourceOutput{}, nil }
+	sources := []core.ExecutableNode{
+		core.NewNode("source.jira", noop, struct{}{}),
+		core.NewNode("source.wiki", noop, struct{}{}),
+	}
+	flow := StandardRAGPipeline("rag", sources, ChunkOptions{MaxTokens: 3, Separator: "\n\n"}, "test_length")
+
+	// FlowTester does not resolve OutputRef markers in mocked node
+	// inputs, so resolve them from the refs each mock produced.
+	outputs := map[string]core.DataRef{"source.jira": jiraRef, "source.wiki": wikiRef}
+	resolve := func(ref core.DataRef) core.DataRef {
+		if !core.IsOutputRefMarker(ref) {
+			return ref
+		}
+		resolved, ok := outputs[ref.StorageKey]
+		if !ok {
+			t.Fatalf("no output for %q", ref.StorageKey)
+		}
+		return resolved
+	}
+
+	tester := core.NewFlowTester().
+		MockValue("source.jira", sourceOutput{Ref: jiraRef}).
+		MockValue("source.wiki", sourceOutput{Ref: wikiRef}).
+		Mock("transform.MergeRefs", func(in MergeRefsInput) (MergeRefsOutput, error) {
+			refs := make([]core.DataRef, len(in.Refs))
+			for i, ref := range in.Refs {
+				refs[i] = resolve(ref)
+			}
+			in.Refs = refs
+			out, err := MergeRefsActivity(ctx, in)
+			outputs[RAGMergeKey] = out.Ref
+			return out, err
+		}).
+		Mock("transform.PipelineRef", func(in PipelineRefInput) (PipelineRefOutput, error) {
+			in.SourceRef = resolve(in.SourceRef)
+			out, err := PipelineRefActivity(ctx, in)
+			outputs[RAGChunkKey] = out.Ref
+			return out, err
+		}).
+		Mock("transform.EmbedRef", func(in EmbedRefInput) (EmbedRefOutput, error) {
+			in.SourceRef = resolve(in.SourceRef)
+			return EmbedRefActivity(ctx, in)
+		})
+
+	state, err := tester.Run(flow, core.FlowInput{})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	out, ok := state.GetResult(RAGEmbedKey).(EmbedRefOutput)
+	if !ok {
+		t.Fatalf("result %q = %T, want EmbedRefOutput", RAGEmbedKey, state.GetResult(RAGEmbedKey))
+	}
+
+	embedded, err := LoadEmbeddedDocuments(ctx, out.Ref)
+	if err != nil {
+		t.Fatalf("load embedded: %v", err)
+	}
+
+	wantIDs := []string{"j1#0", "j1#1", "w1"}
+	if len(embedded) != len(wantIDs) {
+		t.Fatalf("got %d embedded documents, want %d", len(embedded), len(wantIDs))
+	}
+	for i, id := range wantIDs {
+		if embedded[i].Document.ID != id {
+			t.Errorf("embedded[%d].ID = %q, want %q", i, embedded[i].Document.ID, id)
+		}
+		if want := float32(len(embedded[i].Document.Content)); embedded[i].Embedding[0] != want {
+			t.Errorf("embedded[%d] embedding = %v, want [%v]", i, embedded[i].Embedding, want)
+		}
+	}
+}
+
+func TestEmbedRefActivityUnknownEmbedder(t *testing.T) {
+	t.Parallel()
+
+	_, err := EmbedRefActivity(context.Background(), EmbedRefInput{Options: EmbedOptions{Embedder: "missing"}})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -1,4 +1,121 @@
 package transform
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
 // SchemaDocuments is the schema identifier for Document slices.
 const SchemaDocuments = "transform.Document"
+
+// SchemaManifest is the schema identifier for document Manifests.
+const SchemaManifest = "transform.Manifest"
+
+// SchemaFingerprintIndex is the schema identifier for FingerprintIndexes.
+const SchemaFingerprintIndex = "transform.FingerprintIndex"
+
+// DocumentsSchemaVersion is the version of the payload written by
+// StoreDocuments. Version 1 is the original bare JSON array of documents;
+// later versions wrap the array in an object that records the version.
+const DocumentsSchemaVersion = 2
+
+// documentsPayload is the stored form of a Documents ref.
+type documentsPayload struct {
+	SchemaVersion int        `json:"schema_version"`
+	Documents     []Document `json:"documents"`
+}
+
+// DocumentMigration upgrades a single stored document, decoded as a JSON
+// object, from one schema version to the next. It may add, remove or
+// rename fields in place.
+type DocumentMigration func(doc map[string]json.RawMessage) error
+
+var documentMigrations = struct {
+	mu         sync.RWMutex
+	migrations map[int]DocumentMigration
+}{
+	migrations: map[int]DocumentMigration{
+		// Version 2 only changed the payload envelope.
+		1: func(map[string]json.RawMessage) error { return nil },
+	},
+}
+
+// RegisterDocumentMigration registers the migration that upgrades stored
+// documents from version from to from+1, replacing any existing one.
+// Register migrations on every worker before starting it.
+func RegisterDocumentMigration(from int, m DocumentMigration) {
+	documentMigrations.mu.Lock()
+	defer documentMigrations.mu.Unlock()
+	documentMigrations.migrations[from] = m
+}
+
+// documentsBody returns the JSON array of documents stored in data,
+// migrated to DocumentsSchemaVersion.
+func documentsBody(data []byte) (json.RawMessage, error) {
+	version, body := 1, json.RawMessage(data)
+
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		var payload struct {
+			SchemaVersion int             `json:"schema_version"`
+			Documents     json.RawMessage `json:"documents"`
+		}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, fmt.Errorf("decode documents payload: %w", err)
+		}
+		version, body = payload.SchemaVersion, payload.Documents
+		if body == nil {
+			body = json.RawMessage("null")
+		}
+	}
+
+	switch {
+	case version > DocumentsSchemaVersion:
+		return nil, fmt.Errorf("documents schema version %d is newer than supported version %d",
+			version, DocumentsSchemaVersion)
+	case version < 1:
+		return nil, fmt.Errorf("invalid documents schema version %d", version)
+	case version == DocumentsSchemaVersion:
+		return body, nil
+	}
+
+	return migrateDocuments(version, body)
+}
+
+// migrateDocuments applies registered migrations to body, a JSON array of
+// documents at version, up to DocumentsSchemaVersion.
+func migrateDocuments(version int, body json.RawMessage) (json.RawMessage, error) {
+	var docs []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &docs); err != nil {
+		return nil, fmt.Errorf("decode version %d documents: %w", version, err)
+	}
+
+	documentMigrations.mu.RLock()
+	defer documentMigrations.mu.RUnlock()
+
+	for v := version; v < DocumentsSchemaVersion; v++ {
+		migrate, ok := documentMigrations.migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration from documents schema version %d", v)
+		}
+		for i, doc := range docs {
+			if doc == nil {
+				continue
+			}
+			if err := migrate(doc); err != nil {
+				return nil, fmt.Errorf("migrate document %d from version %d: %w", i, v, err)
+			}
+		}
+	}
+
+	migrated, err := json.Marshal(docs)
+	if err != nil {
+		return nil, fmt.Errorf("encode migrated documents: %w", err)
+	}
+	return migrated, nil
+}
+
+// SchemaEmbeddedDocuments is the schema identifier for slices of
+// DocumentWithEmbedding.
+const SchemaEmbeddedDocuments = "transform.DocumentWithEmbedding"
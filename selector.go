@@ -0,0 +1,57 @@
+package transform
+
+import "strings"
+
+// Selector restricts a transform to a subset of documents; documents it
+// does not match pass through unchanged. Each non-empty criterion must
+// match, and a criterion matches when any of its values does. A zero
+// Selector matches every document.
+type Selector struct {
+	// Sources matches Document.Source exactly.
+	Sources []string `json:"sources,omitempty"`
+
+	// ContentTypes matches Metadata["content_type"] exactly.
+	ContentTypes []string `json:"content_types,omitempty"`
+
+	// IDPrefixes matches the start of Document.ID.
+	IDPrefixes []string `json:"id_prefixes,omitempty"`
+}
+
+// IsZero reports whether s has no criteria and so matches every document.
+func (s Selector) IsZero() bool {
+	return len(s.Sources) == 0 && len(s.ContentTypes) == 0 && len(s.IDPrefixes) == 0
+}
+
+// Matches reports whether doc satisfies every criterion of s.
+func (s Selector) Matches(doc Document) bool {
+	if len(s.Sources) > 0 && !containsString(s.Sources, doc.Source) {
+		return false
+	}
+	if len(s.ContentTypes) > 0 && !containsString(s.ContentTypes, doc.Metadata[MetadataContentType]) {
+		return false
+	}
+	if len(s.IDPrefixes) > 0 && !hasAnyPrefix(doc.ID, s.IDPrefixes) {
+		return false
+	}
+	return true
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyPrefix reports whether s starts with any of prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
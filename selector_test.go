@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	t.Parallel()
+
+	doc := Document{ID: "kb-1", Source: "confluence", Metadata: map[string]string{MetadataContentType: "html"}}
+
+	tests := []struct {
+		name     string
+		selector Selector
+		want     bool
+	}{
+		{name: "zero matches all", selector: Selector{}, want: true},
+		{name: "source", selector: Selector{Sources: []string{"jira", "confluence"}}, want: true},
+		{name: "other source", selector: Selector{Sources: []string{"jira"}}, want: false},
+		{name: "content type", selector: Selector{ContentTypes: []string{"html"}}, want: true},
+		{name: "id prefix", selector: Selector{IDPrefixes: []string{"kb-"}}, want: true},
+		{name: "all criteria must match", selector: Selector{Sources: []string{"confluence"}, IDPrefixes: []string{"web-"}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.selector.Matches(doc); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToTextSelector(t *testing.T) {
+	t.Parallel()
+
+	out, err := HTMLToTextActivity(context.Background(), HTMLToTextInput{
+		Documents: []Document{
+			{ID: "web", Source: "web", Content: "<p>a &amp; b</p>"},
+			{ID: "md", Source: "git", Content: "<kbd>Ctrl</kbd> &amp; C"},
+		},
+		Options: HTMLToTextOptions{Selector: Selector{Sources: []string{"web"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"a & b", "<kbd>Ctrl</kbd> &amp; C"}
+	for i, doc := range out.Documents {
+		if doc.Content != want[i] {
+			t.Errorf("doc %d content = %q, want %q", i, doc.Content, want[i])
+		}
+	}
+}
+
+func TestChunkSelector(t *testing.T) {
+	t.Parallel()
+
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{
+			{ID: "a", Source: "wiki", Content: "one two three four"},
+			{ID: "b", Source: "chat", Content: "one two three four"},
+		},
+		Options: ChunkOptions{MaxTokens: 2, Separator: " ", Selector: Selector{Sources: []string{"wiki"}}},
+	})
+
+	if out.Count != 3 {
+		t.Fatalf("got %d documents, want 2 chunks and 1 passthrough", out.Count)
+	}
+	if last := out.Documents[2]; last.ID != "b" || last.IsChunk() {
+		t.Errorf("last document = %+v, want unsplit b", last)
+	}
+}
@@ -0,0 +1,123 @@
+package transform
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SentenceSplitter splits a paragraph into sentences.
+type SentenceSplitter interface {
+	Split(text string) []string
+}
+
+// SentenceSplitterEnglish names the default rule-based splitter.
+const SentenceSplitterEnglish = "english"
+
+// EnglishSentenceSplitter ends a sentence at '.', '!' or '?' (optionally
+// followed by closing quotes or brackets) that is followed by whitespace.
+type EnglishSentenceSplitter struct{}
+
+// Split implements SentenceSplitter.
+func (EnglishSentenceSplitter) Split(text string) []string {
+	return appendSentences(nil, text)
+}
+
+var sentenceSplitters = struct {
+	mu        sync.RWMutex
+	splitters map[string]SentenceSplitter
+}{
+	splitters: map[string]SentenceSplitter{
+		SentenceSplitterEnglish: EnglishSentenceSplitter{},
+	},
+}
+
+// RegisterSentenceSplitter registers a splitter under name, replacing any
+// existing registration, so it can be selected with
+// ChunkOptions.SentenceSplitter. Splitters may be used concurrently.
+// Register custom splitters on every worker before starting it.
+func RegisterSentenceSplitter(name string, splitter SentenceSplitter) {
+	sentenceSplitters.mu.Lock()
+	defer sentenceSplitters.mu.Unlock()
+	sentenceSplitters.splitters[name] = splitter
+}
+
+// RegisteredSentenceSplitters returns the names of all registered splitters.
+func RegisteredSentenceSplitters() []string {
+	sentenceSplitters.mu.RLock()
+	defer sentenceSplitters.mu.RUnlock()
+	return sortedKeys(sentenceSplitters.splitters)
+}
+
+// lookupSentenceSplitter returns the splitter registered under name. An
+// empty name selects SentenceSplitterEnglish.
+func lookupSentenceSplitter(name string) (SentenceSplitter, bool) {
+	if name == "" {
+		name = SentenceSplitterEnglish
+	}
+	sentenceSplitters.mu.RLock()
+	defer sentenceSplitters.mu.RUnlock()
+	splitter, ok := sentenceSplitters.splitters[name]
+	return splitter, ok
+}
+
+// splitSentences splits text into sentences. Paragraphs separated by
+// separator always end a sentence; splitter divides each paragraph.
+// Empty sentences are dropped.
+func splitSentences(text, separator string, splitter SentenceSplitter) []string {
+	paragraphs := []string{text}
+	if separator != "" {
+		paragraphs = strings.Split(text, separator)
+	}
+
+	var sentences []string
+	for _, para := range paragraphs {
+		for _, sentence := range splitter.Split(para) {
+			if sentence = strings.TrimSpace(sentence); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+		}
+	}
+
+	return sentences
+}
+
+// appendSentences appends the sentences of a single paragraph.
+func appendSentences(sentences []string, para string) []string {
+	start := 0
+	for i := 0; i < len(para); {
+		r, size := utf8.DecodeRuneInString(para[i:])
+		i += size
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+
+		// Include trailing terminators and closing punctuation.
+		for i < len(para) {
+			next, n := utf8.DecodeRuneInString(para[i:])
+			if !strings.ContainsRune(".!?\"')]”’", next) {
+				break
+			}
+			i += n
+		}
+
+		if i < len(para) {
+			next, _ := utf8.DecodeRuneInString(para[i:])
+			if !unicode.IsSpace(next) {
+				continue
+			}
+		}
+
+		if s := strings.TrimSpace(para[start:i]); s != "" {
+			sentences = append(sentences, s)
+		}
+		start = i
+	}
+
+	if s := strings.TrimSpace(para[start:]); s != "" {
+		sentences = append(sentences, s)
+	}
+
+	return sentences
+}
@@ -0,0 +1,113 @@
+package transform
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "terminators",
+			text: "First one. Second one! Third one? Fourth",
+			want: []string{"First one.", "Second one!", "Third one?", "Fourth"},
+		},
+		{
+			name: "decimal and closing quote",
+			text: `Version 1.5 shipped. He said "done." Then left.`,
+			want: []string{"Version 1.5 shipped.", `He said "done."`, "Then left."},
+		},
+		{
+			name: "paragraph ends sentence",
+			text: "Heading\n\nBody text here.",
+			want: []string{"Heading", "Body text here."},
+		},
+		{
+			name: "empty",
+			text: "  ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := splitSentences(tt.text, "\n\n", EnglishSentenceSplitter{})
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("sentence %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChunkSentenceWindow(t *testing.T) {
+	t.Parallel()
+
+	doc := Document{ID: "doc", Content: "S0. S1. S2. S3. S4.", Source: "test"}
+	chunks := chunkDocument(doc, ChunkOptions{Strategy: StrategySentenceWindow, WindowSize: 1, Separator: "\n\n"})
+
+	wantWindows := []string{"S0. S1.", "S0. S1. S2.", "S1. S2. S3.", "S2. S3. S4.", "S3. S4."}
+	if len(chunks) != len(wantWindows) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(wantWindows))
+	}
+
+	for i, chunk := range chunks {
+		if chunk.Content != "S"+itoa(i)+"." {
+			t.Errorf("chunk %d: Content = %q, want %q", i, chunk.Content, "S"+itoa(i)+".")
+		}
+		if chunk.Metadata[MetadataWindow] != wantWindows[i] {
+			t.Errorf("chunk %d: window = %q, want %q", i, chunk.Metadata[MetadataWindow], wantWindows[i])
+		}
+		if chunk.ParentID != "doc" || chunk.ChunkIndex != i {
+			t.Errorf("chunk %d: ParentID = %q, ChunkIndex = %d", i, chunk.ParentID, chunk.ChunkIndex)
+		}
+	}
+}
+
+type pipeSplitter struct{}
+
+func (pipeSplitter) Split(text string) []string {
+	return strings.Split(text, "|")
+}
+
+func TestChunkSentenceSplitter(t *testing.T) {
+	t.Parallel()
+
+	RegisterSentenceSplitter("test_pipe", pipeSplitter{})
+
+	doc := Document{ID: "doc", Content: "สวัสดี|ครับ. ไม่|ใช่", Source: "test"}
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{doc},
+		Options:   ChunkOptions{Strategy: StrategySentenceWindow, MaxTokens: 10, Separator: "\n\n", SentenceSplitter: "test_pipe"},
+	})
+
+	want := []string{"สวัสดี", "ครับ. ไม่", "ใช่"}
+	if out.Count != len(want) {
+		t.Fatalf("got %d chunks, want %d", out.Count, len(want))
+	}
+	for i, w := range want {
+		if out.Documents[i].Content != w {
+			t.Errorf("chunk %d: Content = %q, want %q", i, out.Documents[i].Content, w)
+		}
+	}
+
+	_, err := ChunkActivity(context.Background(), ChunkInput{
+		Documents: []Document{doc},
+		Options:   ChunkOptions{Strategy: StrategySentenceWindow, MaxTokens: 10, SentenceSplitter: "missing"},
+	})
+	if err == nil {
+		t.Error("expected error for unknown sentence splitter, got nil")
+	}
+}
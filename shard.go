@@ -0,0 +1,97 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// ShardOf returns the shard in [0, shards) that the document ID maps
+// to: the 64-bit FNV-1a hash of the ID modulo shards. The assignment is
+// stable across runs and processes. shards below 1 are treated as 1.
+func ShardOf(id string, shards int) int {
+	if shards < 1 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return int(h.Sum64() % uint64(shards))
+}
+
+// ShardDocuments splits docs into shards buckets by ShardOf their ID,
+// keeping input order within each bucket. Every bucket is non-nil, so
+// the result always has shards entries; shards below 1 are treated as
+// 1.
+func ShardDocuments(docs []Document, shards int) [][]Document {
+	shards = max(shards, 1)
+
+	out := make([][]Document, shards)
+	for i := range out {
+		out[i] = make([]Document, 0, len(docs)/shards+1)
+	}
+	for _, doc := range docs {
+		s := ShardOf(doc.ID, shards)
+		out[s] = append(out[s], doc)
+	}
+	return out
+}
+
+// ShardInput is the input for the Shard transformer.
+type ShardInput struct {
+	Documents []Document
+	Shards    int
+}
+
+// ShardOutput is the output of the Shard transformer.
+type ShardOutput struct {
+	// Shards holds one batch per shard, in shard order. Each batch is a
+	// DocumentSource for the node processing that shard.
+	Shards []DocumentBatch
+	Count  int
+}
+
+// ToDocuments implements DocumentSource for ShardOutput, returning the
+// documents of every shard in shard order.
+func (o ShardOutput) ToDocuments() []Document {
+	docs := make([]Document, 0, o.Count)
+	for _, shard := range o.Shards {
+		docs = append(docs, shard.Documents...)
+	}
+	return docs
+}
+
+// ShardActivity routes every document to the shard ShardOf its ID.
+func ShardActivity(ctx context.Context, input ShardInput) (ShardOutput, error) {
+	if input.Shards < 1 {
+		return ShardOutput{}, fmt.Errorf("shards must be positive, got %d", input.Shards)
+	}
+
+	buckets := ShardDocuments(input.Documents, input.Shards)
+	shards := make([]DocumentBatch, len(buckets))
+	for i, docs := range buckets {
+		shards[i] = DocumentBatch{Documents: docs}
+	}
+
+	return ShardOutput{
+		Shards: shards,
+		Count:  len(input.Documents),
+	}, nil
+}
+
+// Shard creates a node that deterministically splits documents into
+// shards buckets by a hash of their ID, so downstream work such as
+// embedding can be spread across workers and the same document always
+// lands on the same shard.
+//
+// Example:
+//
+//	flow := core.NewFlow("index").
+//	    Then(chunkNode).
+//	    Then(transform.Shard(4)).
+//	    ThenParallel("embed", embedShard0, embedShard1, embedShard2, embedShard3).
+//	    Build()
+func Shard(shards int) *core.Node[ShardInput, ShardOutput] {
+	return core.NewNode("transform.Shard", ShardActivity, ShardInput{Shards: shards})
+}
@@ -0,0 +1,66 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestShardDocuments(t *testing.T) {
+	t.Parallel()
+
+	const n, shards = 10000, 8
+	docs := make([]Document, n)
+	for i := range docs {
+		docs[i] = Document{ID: "doc-" + itoa(i)}
+	}
+
+	buckets := ShardDocuments(docs, shards)
+	if len(buckets) != shards {
+		t.Fatalf("got %d shards, want %d", len(buckets), shards)
+	}
+
+	var total int
+	for s, bucket := range buckets {
+		total += len(bucket)
+		// A uniform hash keeps every shard within 10% of n/shards.
+		if want := n / shards; len(bucket) < want*9/10 || len(bucket) > want*11/10 {
+			t.Errorf("shard %d has %d documents, want about %d", s, len(bucket), want)
+		}
+		for _, doc := range bucket {
+			if got := ShardOf(doc.ID, shards); got != s {
+				t.Fatalf("document %s in shard %d, ShardOf = %d", doc.ID, s, got)
+			}
+		}
+	}
+	if total != n {
+		t.Errorf("sharded %d documents, want %d", total, n)
+	}
+
+	// The assignment is a fixed function of the ID, so it must not
+	// change between releases.
+	if got := ShardOf("doc-42", shards); got != 2 {
+		t.Errorf("ShardOf(doc-42, %d) = %d, want 2", shards, got)
+	}
+}
+
+func TestShardActivity(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	out, err := ShardActivity(context.Background(), ShardInput{Documents: docs, Shards: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Shards) != 3 || out.Count != 4 || len(out.ToDocuments()) != 4 {
+		t.Errorf("got %d shards, Count %d, %d documents; want 3, 4, 4", len(out.Shards), out.Count, len(out.ToDocuments()))
+	}
+	for s, shard := range out.Shards {
+		if shard.Documents == nil {
+			t.Errorf("shard %d is nil", s)
+		}
+	}
+
+	if _, err := ShardActivity(context.Background(), ShardInput{Documents: docs}); err == nil {
+		t.Error("expected error for zero shards")
+	}
+}
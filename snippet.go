@@ -0,0 +1,110 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// MetadataSnippet is the metadata key holding a document's preview set by
+// SnippetActivity.
+const MetadataSnippet = "snippet"
+
+// SnippetEllipsis is appended to truncated snippets.
+const SnippetEllipsis = "…"
+
+// Snippet returns a preview of the document's content for search result
+// UIs: the content with runs of whitespace collapsed to single spaces,
+// cut to at most maxRunes runes. A cut falls between words and is marked
+// by SnippetEllipsis, which counts against maxRunes; a first word longer
+// than the whole snippet is cut mid-word. Content that fits is returned
+// in full without the ellipsis. A maxRunes below one returns "".
+func (d Document) Snippet(maxRunes int) string {
+	if maxRunes < 1 {
+		return ""
+	}
+
+	text := strings.Join(strings.Fields(d.Content), " ")
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+
+	budget := maxRunes - utf8.RuneCountInString(SnippetEllipsis)
+	if budget < 1 {
+		return string([]rune(text)[:maxRunes])
+	}
+
+	// end is the byte offset of the budget-th rune; the snippet ends at
+	// the last space at or before it, unless the first word is longer.
+	end, runes := 0, 0
+	for i := range text {
+		if runes == budget {
+			end = i
+			break
+		}
+		runes++
+	}
+	cut := text[:end]
+	if text[end] != ' ' {
+		if space := strings.LastIndexByte(cut, ' '); space > 0 {
+			cut = cut[:space]
+		}
+	}
+	return strings.TrimRight(cut, " ") + SnippetEllipsis
+}
+
+// SnippetInput is the input for the Snippet transformer.
+type SnippetInput struct {
+	Documents []Document
+
+	// MaxRunes is the maximum length of each snippet in runes, including
+	// the ellipsis.
+	MaxRunes int
+}
+
+// SnippetOutput is the output of the Snippet transformer.
+type SnippetOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for SnippetOutput.
+func (o SnippetOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// SnippetActivity stores each document's Snippet in
+// Metadata["snippet"].
+func SnippetActivity(ctx context.Context, input SnippetInput) (SnippetOutput, error) {
+	if input.MaxRunes < 1 {
+		return SnippetOutput{}, fmt.Errorf("max runes must be positive, got %d", input.MaxRunes)
+	}
+
+	docs := make([]Document, len(input.Documents))
+	for i, doc := range input.Documents {
+		docs[i] = doc.withMetadataCopy(MetadataSnippet, doc.Snippet(input.MaxRunes))
+	}
+
+	return SnippetOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// Snippet creates a node that stores a preview of at most maxRunes runes
+// of each document's content in Metadata["snippet"] (see
+// Document.Snippet).
+//
+// Example:
+//
+//	flow := core.NewFlow("kb").
+//	    Then(chunkNode).
+//	    Then(transform.Snippet(160)).
+//	    Then(embedNode).
+//	    Build()
+func Snippet(maxRunes int) *core.Node[SnippetInput, SnippetOutput] {
+	return core.NewNode("transform.Snippet", SnippetActivity, SnippetInput{MaxRunes: maxRunes})
+}
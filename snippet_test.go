@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDocumentSnippet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		content  string
+		maxRunes int
+		want     string
+	}{
+		{"short  text\n", 20, "short text"},
+		{"exactly ten", 11, "exactly ten"},
+		{"the quick brown fox", 12, "the quick…"},
+		{"the quick brown fox", 10, "the quick…"},
+		{"the quick brown fox", 9, "the…"},
+		{"supercalifragilistic words", 6, "super…"},
+		{"héllo wörld ünïcode", 13, "héllo wörld…"},
+		{"日本語のテキスト", 5, "日本語の…"},
+		{"abc", 1, "a"},
+		{"abc", 0, ""},
+	}
+	for _, tt := range tests {
+		got := Document{Content: tt.content}.Snippet(tt.maxRunes)
+		if got != tt.want {
+			t.Errorf("Snippet(%q, %d) = %q, want %q", tt.content, tt.maxRunes, got, tt.want)
+		}
+		if !utf8.ValidString(got) || utf8.RuneCountInString(got) > max(tt.maxRunes, 0) {
+			t.Errorf("Snippet(%q, %d) = %q is invalid or too long", tt.content, tt.maxRunes, got)
+		}
+	}
+}
+
+func TestSnippetActivity(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "1", Content: "one two three", Metadata: map[string]string{"k": "v"}}}
+	out, err := SnippetActivity(context.Background(), SnippetInput{Documents: docs, MaxRunes: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Documents[0].Metadata[MetadataSnippet]; got != "one two…" {
+		t.Errorf("snippet = %q, want %q", got, "one two…")
+	}
+	if _, ok := docs[0].Metadata[MetadataSnippet]; ok {
+		t.Error("input metadata was modified")
+	}
+
+	if _, err := SnippetActivity(context.Background(), SnippetInput{Documents: docs}); err == nil {
+		t.Error("expected error for zero MaxRunes")
+	}
+}
@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"context"
+	"errors"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// DefaultSourceInput is the input for the DefaultSource transformer.
+type DefaultSourceInput struct {
+	Documents []Document
+
+	// Source is assigned to documents with an empty Source.
+	Source string
+}
+
+// DefaultSourceOutput is the output of the DefaultSource transformer.
+type DefaultSourceOutput struct {
+	Documents []Document
+	Count     int
+
+	// Filled is the number of documents that had no Source.
+	Filled int
+}
+
+// ToDocuments implements DocumentSource for DefaultSourceOutput.
+func (o DefaultSourceOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// DefaultSourceActivity sets Source on every document that has none. An
+// empty Source is rejected.
+func DefaultSourceActivity(ctx context.Context, input DefaultSourceInput) (DefaultSourceOutput, error) {
+	if input.Source == "" {
+		return DefaultSourceOutput{}, errors.New("default source must not be empty")
+	}
+
+	docs := make([]Document, len(input.Documents))
+	var filled int
+	for i, doc := range input.Documents {
+		if doc.Source == "" {
+			doc.Source = input.Source
+			filled++
+		}
+		docs[i] = doc
+	}
+
+	return DefaultSourceOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Filled:    filled,
+	}, nil
+}
+
+// DefaultSource creates a node that fills an empty Source with name, so
+// source quotas, labels and selectors see every document, even when an
+// upstream provider leaves the field unset.
+//
+// Example:
+//
+//	flow := core.NewFlow("ingest").
+//	    Then(fetchNode).
+//	    Then(transform.DefaultSource("legacy-wiki")).
+//	    Then(transform.MergeWith(transform.MergeOptions{SourceQuota: quotas})).
+//	    Build()
+func DefaultSource(name string) *core.Node[DefaultSourceInput, DefaultSourceOutput] {
+	return core.NewNode("transform.DefaultSource", DefaultSourceActivity, DefaultSourceInput{Source: name})
+}
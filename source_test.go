@@ -0,0 +1,62 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefaultSourceActivity(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "a", Source: "wiki"}, {ID: "b", Content: "one two"}}
+	out, err := DefaultSourceActivity(context.Background(), DefaultSourceInput{Documents: docs, Source: "unknown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.Filled != 1 || out.Documents[0].Source != "wiki" || out.Documents[1].Source != "unknown" {
+		t.Errorf("got Filled=%d sources %q %q, want 1, wiki, unknown", out.Filled, out.Documents[0].Source, out.Documents[1].Source)
+	}
+	if docs[1].Source != "" {
+		t.Error("input document was modified")
+	}
+
+	chunks := mustChunk(t, ChunkInput{
+		Documents: out.Documents[1:],
+		Options:   ChunkOptions{MaxTokens: 1, Separator: " "},
+	})
+	if chunks.Count != 2 {
+		t.Fatalf("got %d chunks, want 2", chunks.Count)
+	}
+	for _, chunk := range chunks.Documents {
+		if chunk.Source != "unknown" {
+			t.Errorf("chunk %s Source = %q, want unknown", chunk.ID, chunk.Source)
+		}
+	}
+}
+
+func TestDefaultSourceActivityEmptySource(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{{ID: "a", Content: "one two"}}
+	_, err := DefaultSourceActivity(context.Background(), DefaultSourceInput{Documents: docs})
+	if err == nil || err.Error() != "default source must not be empty" {
+		t.Errorf("err = %v, want empty default source error", err)
+	}
+}
+
+func TestStoreDocumentsRequireSource(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := []Document{{ID: "a", Source: "wiki"}, {ID: "b"}}
+
+	_, err := StoreDocumentsWith(ctx, docs, StoreOptions{RequireSource: true})
+	if !errors.Is(err, ErrMissingSource) || err.Error() != "document has no source: b" {
+		t.Errorf("err = %v, want ErrMissingSource naming b", err)
+	}
+	if _, err := StoreDocumentsWith(ctx, docs, StoreOptions{}); err != nil {
+		t.Errorf("without RequireSource: unexpected error: %v", err)
+	}
+}
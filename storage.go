@@ -1,49 +1,404 @@
 package transform
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/resolute-sh/resolute/core"
 )
 
+// StoreOptions configures StoreDocumentsWith.
+type StoreOptions struct {
+	// ExcludeMetadata lists metadata keys removed from the stored
+	// documents, with the same prefix matching as StripMetadata.
+	// Default: keep all metadata
+	ExcludeMetadata []string
+
+	// MaxDocuments rejects storing more documents than this in one ref.
+	// Default: 0 (no limit)
+	MaxDocuments int
+
+	// MaxBytes rejects storing a serialized payload larger than this
+	// many bytes in one ref.
+	// Default: 0 (no limit)
+	MaxBytes int
+
+	// RequireSource rejects documents with an empty Source, which would
+	// break source-keyed operations downstream (see DefaultSource).
+	RequireSource bool
+}
+
+// ErrStoreLimit is returned when documents exceed StoreOptions.MaxDocuments
+// or StoreOptions.MaxBytes.
+var ErrStoreLimit = errors.New("store limit exceeded")
+
+// ErrMissingSource is returned by StoreDocumentsWith under
+// StoreOptions.RequireSource for documents without a Source.
+var ErrMissingSource = errors.New("document has no source")
+
 // StoreDocuments stores a slice of Documents and returns a DataRef.
+// The payload records DocumentsSchemaVersion so LoadDocuments can
+// migrate it if the Document schema changes. The documents are
+// serialized once; the checksum is computed by the storage layer over
+// the same bytes it persists. Storage backends take the whole payload as
+// a single buffer, so the serialized form of docs is held in memory for
+// the duration of the call.
 func StoreDocuments(ctx context.Context, docs []Document) (core.DataRef, error) {
+	return StoreDocumentsWith(ctx, docs, StoreOptions{})
+}
+
+// StoreDocumentsWith stores docs like StoreDocuments, applying opts.
+// Checking MaxBytes encodes the documents an extra time to measure the
+// payload, but does not buffer that encoding.
+func StoreDocumentsWith(ctx context.Context, docs []Document, opts StoreOptions) (core.DataRef, error) {
+	if len(opts.ExcludeMetadata) > 0 {
+		docs = stripMetadata(docs, opts.ExcludeMetadata)
+	}
+
+	if opts.RequireSource {
+		var missing []string
+		for _, doc := range docs {
+			if doc.Source == "" {
+				missing = append(missing, doc.ID)
+			}
+		}
+		if len(missing) > 0 {
+			return core.DataRef{}, fmt.Errorf("%w: %s", ErrMissingSource, strings.Join(missing, ", "))
+		}
+	}
+	if opts.MaxDocuments > 0 && len(docs) > opts.MaxDocuments {
+		return core.DataRef{}, fmt.Errorf("%w: %d documents, limit %d", ErrStoreLimit, len(docs), opts.MaxDocuments)
+	}
+
 	storage, err := core.GetStorage()
 	if err != nil {
 		return core.DataRef{}, fmt.Errorf("get storage: %w", err)
 	}
 
-	data, err := json.Marshal(docs)
-	if err != nil {
-		return core.DataRef{}, fmt.Errorf("marshal documents: %w", err)
+	payload := documentsPayload{SchemaVersion: DocumentsSchemaVersion, Documents: docs}
+	if opts.MaxBytes > 0 {
+		size, err := encodedSize(payload)
+		if err != nil {
+			return core.DataRef{}, fmt.Errorf("marshal documents: %w", err)
+		}
+		if size > opts.MaxBytes {
+			return core.DataRef{}, fmt.Errorf("%w: %d bytes, limit %d", ErrStoreLimit, size, opts.MaxBytes)
+		}
 	}
 
-	ref, err := storage.StoreJSON(ctx, SchemaDocuments, docs)
+	ref, err := storage.StoreJSON(ctx, SchemaDocuments, payload)
 	if err != nil {
-		return core.DataRef{}, err
+		return core.DataRef{}, fmt.Errorf("store documents: %w", err)
 	}
 
 	ref.Count = len(docs)
-	return ref.WithChecksum(data), nil
+	return ref, nil
 }
 
-// LoadDocuments loads Documents from a DataRef.
+// encodedSize returns the length of the JSON encoding of v without
+// holding the encoding in memory.
+func encodedSize(v any) (int, error) {
+	var w countingWriter
+	if err := json.NewEncoder(&w).Encode(v); err != nil {
+		return 0, err
+	}
+	// Encode terminates the value with a newline that json.Marshal omits.
+	return w.n - 1, nil
+}
+
+// countingWriter is an io.Writer that counts and discards its input.
+type countingWriter struct {
+	n int
+}
+
+// Write implements io.Writer.
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+// documentsEnvelope is the size in bytes of a serialized documentsPayload
+// around its documents, with a margin for the schema version.
+var documentsEnvelope = len(`{"schema_version":,"documents":[]}`) + 10
+
+// StoreDocumentShards stores docs in as many refs as needed to keep each
+// within opts.MaxDocuments and opts.MaxBytes, preserving order, instead
+// of failing like StoreDocumentsWith. A single document larger than
+// MaxBytes is an error wrapping ErrStoreLimit.
+func StoreDocumentShards(ctx context.Context, docs []Document, opts StoreOptions) ([]core.DataRef, error) {
+	if len(opts.ExcludeMetadata) > 0 {
+		docs = stripMetadata(docs, opts.ExcludeMetadata)
+		opts.ExcludeMetadata = nil
+	}
+
+	var refs []core.DataRef
+	store := func(shard []Document) error {
+		ref, err := StoreDocumentsWith(ctx, shard, opts)
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", len(refs), err)
+		}
+		refs = append(refs, ref)
+		return nil
+	}
+
+	start, size := 0, documentsEnvelope
+	for i, doc := range docs {
+		n := 0
+		if opts.MaxBytes > 0 {
+			data, err := json.Marshal(doc)
+			if err != nil {
+				return nil, fmt.Errorf("marshal document %s: %w", doc.ID, err)
+			}
+			n = len(data) + 1
+			if documentsEnvelope+n > opts.MaxBytes {
+				return nil, fmt.Errorf("%w: document %s is %d bytes, limit %d", ErrStoreLimit, doc.ID, len(data), opts.MaxBytes)
+			}
+		}
+
+		full := opts.MaxDocuments > 0 && i-start == opts.MaxDocuments
+		if full || (opts.MaxBytes > 0 && size+n > opts.MaxBytes) {
+			if err := store(docs[start:i]); err != nil {
+				return nil, err
+			}
+			start, size = i, documentsEnvelope
+		}
+		size += n
+	}
+	if start < len(docs) || len(refs) == 0 {
+		if err := store(docs[start:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return refs, nil
+}
+
+// LoadDocuments loads Documents from a DataRef. Payloads written with an
+// older DocumentsSchemaVersion are migrated with the registered
+// migrations; newer versions are rejected. With SetDocumentCacheSize,
+// refs already loaded are served from memory by checksum.
 func LoadDocuments(ctx context.Context, ref core.DataRef) ([]Document, error) {
 	if ref.Schema != SchemaDocuments {
 		return nil, fmt.Errorf("schema mismatch: expected %s, got %s", SchemaDocuments, ref.Schema)
 	}
+	if docs, ok := cachedDocuments(ref.Checksum); ok {
+		return docs, nil
+	}
 
 	storage, err := core.GetStorage()
 	if err != nil {
 		return nil, fmt.Errorf("get storage: %w", err)
 	}
 
+	var raw json.RawMessage
+	if err := storage.LoadJSON(ctx, ref, &raw); err != nil {
+		return nil, fmt.Errorf("load documents: %w", err)
+	}
+	body, err := documentsBody(raw)
+	if err != nil {
+		return nil, err
+	}
+
 	var docs []Document
-	if err := storage.LoadJSON(ctx, ref, &docs); err != nil {
+	if err := json.Unmarshal(body, &docs); err != nil {
+		return nil, fmt.Errorf("decode documents: %w", err)
+	}
+	if docs == nil {
+		docs = []Document{}
+	}
+
+	cacheDocuments(ref.Checksum, docs)
+	return docs, nil
+}
+
+// forEachDocument calls fn with each document in ref, in the order they
+// are stored, until fn returns false. Like LoadDocumentsByIDs, the stored
+// array is decoded one document at a time; refs already in the document
+// cache are served from memory.
+func forEachDocument(ctx context.Context, ref core.DataRef, fn func(Document) bool) error {
+	if ref.Schema != SchemaDocuments {
+		return fmt.Errorf("schema mismatch: expected %s, got %s", SchemaDocuments, ref.Schema)
+	}
+	if docs, ok := cachedDocuments(ref.Checksum); ok {
+		for _, doc := range docs {
+			if !fn(doc) {
+				break
+			}
+		}
+		return nil
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return fmt.Errorf("get storage: %w", err)
+	}
+
+	var raw json.RawMessage
+	if err := storage.LoadJSON(ctx, ref, &raw); err != nil {
+		return fmt.Errorf("load documents: %w", err)
+	}
+	body, err := documentsBody(raw)
+	if err != nil {
+		return err
+	}
+
+	if err := scanDocuments(body, fn); err != nil {
+		return fmt.Errorf("scan documents: %w", err)
+	}
+	return nil
+}
+
+// ErrDocumentNotFound is returned when a requested document ID is not
+// present in a DataRef.
+var ErrDocumentNotFound = errors.New("document not found")
+
+// LoadDocumentByID loads the document with the given ID from a DataRef.
+// See LoadDocumentsByIDs for how the ref is scanned.
+func LoadDocumentByID(ctx context.Context, ref core.DataRef, id string) (Document, error) {
+	docs, err := LoadDocumentsByIDs(ctx, ref, []string{id})
+	if err != nil {
+		return Document{}, err
+	}
+	return docs[0], nil
+}
+
+// LoadDocumentsByIDs loads the documents with the given IDs from a
+// DataRef, in the order they are stored. The stored array is decoded one
+// document at a time and only matches are kept, so the full set of
+// Documents is never materialized; the serialized payload itself is
+// still read in one piece by the storage backend. The returned error
+// wraps ErrDocumentNotFound if any ID is absent.
+func LoadDocumentsByIDs(ctx context.Context, ref core.DataRef, ids []string) ([]Document, error) {
+	if ref.Schema != SchemaDocuments {
+		return nil, fmt.Errorf("schema mismatch: expected %s, got %s", SchemaDocuments, ref.Schema)
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return nil, fmt.Errorf("get storage: %w", err)
+	}
+
+	var raw json.RawMessage
+	if err := storage.LoadJSON(ctx, ref, &raw); err != nil {
 		return nil, fmt.Errorf("load documents: %w", err)
 	}
 
+	body, err := documentsBody(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	docs := []Document{}
+	err = scanDocuments(body, func(doc Document) bool {
+		if wanted[doc.ID] {
+			delete(wanted, doc.ID)
+			docs = append(docs, doc)
+		}
+		return len(wanted) > 0
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan documents: %w", err)
+	}
+
+	if len(wanted) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrDocumentNotFound, strings.Join(sortedKeys(wanted), ", "))
+	}
 	return docs, nil
 }
+
+// DropStaleChunks returns the IDs of the documents stored in ref that
+// current supersedes, in the order they are stored, so they can be
+// deleted downstream after re-chunking. current is the new output for
+// one or more parents: every parent it covers, through a chunk's
+// ParentID or an unsplit document's ID, is considered, and a stored
+// chunk of such a parent, or the stored parent itself, is stale unless
+// its ID is in current. Documents of other parents are never stale, so
+// parent IDs must be stable across runs. Like LoadDocumentsByIDs, the
+// stored array is decoded one document at a time.
+func DropStaleChunks(ctx context.Context, ref core.DataRef, current []Document) ([]string, error) {
+	if ref.Schema != SchemaDocuments {
+		return nil, fmt.Errorf("schema mismatch: expected %s, got %s", SchemaDocuments, ref.Schema)
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return nil, fmt.Errorf("get storage: %w", err)
+	}
+
+	var raw json.RawMessage
+	if err := storage.LoadJSON(ctx, ref, &raw); err != nil {
+		return nil, fmt.Errorf("load documents: %w", err)
+	}
+
+	body, err := documentsBody(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[string]bool, len(current))
+	keep := make(map[string]bool, len(current))
+	for _, doc := range current {
+		keep[doc.ID] = true
+		if doc.IsChunk() {
+			parents[doc.ParentID] = true
+		} else {
+			parents[doc.ID] = true
+		}
+	}
+
+	stale := []string{}
+	seen := make(map[string]bool)
+	err = scanDocuments(body, func(doc Document) bool {
+		parent := doc.ID
+		if doc.IsChunk() {
+			parent = doc.ParentID
+		}
+		if parents[parent] && !keep[doc.ID] && !seen[doc.ID] {
+			seen[doc.ID] = true
+			stale = append(stale, doc.ID)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan documents: %w", err)
+	}
+
+	return stale, nil
+}
+
+// scanDocuments decodes a JSON array of documents one element at a time,
+// calling fn for each until it returns false.
+func scanDocuments(data []byte, fn func(Document) bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected array, got %v", tok)
+	}
+
+	for dec.More() {
+		var doc Document
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+		if !fn(doc) {
+			return nil
+		}
+	}
+	return nil
+}
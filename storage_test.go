@@ -0,0 +1,388 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "resolute-transform-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// GetStorage lazily installs the default .resolute/data backend on its
+	// first call, so trigger it before overriding the global storage.
+	if _, err := core.GetStorage(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	backend, err := core.NewLocalStorage(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	core.SetStorage(core.NewStorage(backend))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestStoreDocumentsWithManifest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := []Document{
+		{ID: "1", Content: "aaaa bbbb", Source: "jira"},
+		{ID: "2", Content: "cccc", Source: "jira"},
+		{ID: "3", Content: "dddd eeee ffff", Source: "confluence"},
+	}
+
+	ref, manifestRef, err := StoreDocumentsWithManifest(ctx, docs)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	m, err := LoadManifest(ctx, manifestRef)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+
+	if m.Version != ManifestVersion {
+		t.Errorf("Version = %d, want %d", m.Version, ManifestVersion)
+	}
+	if m.Count != 3 {
+		t.Errorf("Count = %d, want 3", m.Count)
+	}
+	if m.Sources["jira"] != 2 || m.Sources["confluence"] != 1 {
+		t.Errorf("Sources = %v, want jira=2 confluence=1", m.Sources)
+	}
+	if m.TotalTokens != 6 {
+		t.Errorf("TotalTokens = %d, want 6", m.TotalTokens)
+	}
+	if m.Checksum != ref.Checksum {
+		t.Errorf("Checksum = %q, want %q", m.Checksum, ref.Checksum)
+	}
+	if m.Documents.StorageKey != ref.StorageKey {
+		t.Errorf("Documents.StorageKey = %q, want %q", m.Documents.StorageKey, ref.StorageKey)
+	}
+
+	if _, err := LoadManifest(ctx, ref); err == nil {
+		t.Error("expected schema mismatch loading documents ref as manifest")
+	}
+}
+
+func TestStoreDocumentsChecksum(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := []Document{
+		{ID: "1", Content: "<b>bold</b> & more", Source: "jira"},
+		{ID: "2", Content: "ünïcödé", Source: "confluence", Metadata: map[string]string{"k": "v"}},
+	}
+
+	ref, err := StoreDocuments(ctx, docs)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	data, err := json.Marshal(documentsPayload{SchemaVersion: DocumentsSchemaVersion, Documents: docs})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if want := (core.DataRef{}).WithChecksum(data).Checksum; ref.Checksum != want {
+		t.Errorf("Checksum = %q, want %q", ref.Checksum, want)
+	}
+	if ref.Count != len(docs) {
+		t.Errorf("Count = %d, want %d", ref.Count, len(docs))
+	}
+
+	loaded, err := LoadDocuments(ctx, ref)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded) != len(docs) || loaded[1].Content != docs[1].Content {
+		t.Errorf("loaded %+v, want %+v", loaded, docs)
+	}
+}
+
+func TestLoadDocumentsByIDs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ref, err := StoreDocuments(ctx, []Document{
+		{ID: "1", Content: "one"},
+		{ID: "2", Content: "two"},
+		{ID: "3", Content: "three"},
+	})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	doc, err := LoadDocumentByID(ctx, ref, "2")
+	if err != nil {
+		t.Fatalf("load by id: %v", err)
+	}
+	if doc.Content != "two" {
+		t.Errorf("Content = %q, want %q", doc.Content, "two")
+	}
+
+	docs, err := LoadDocumentsByIDs(ctx, ref, []string{"3", "1"})
+	if err != nil {
+		t.Fatalf("load by ids: %v", err)
+	}
+	if len(docs) != 2 || docs[0].ID != "1" || docs[1].ID != "3" {
+		t.Errorf("got %+v, want documents 1 and 3 in stored order", docs)
+	}
+
+	if _, err := LoadDocumentByID(ctx, ref, "missing"); !errors.Is(err, ErrDocumentNotFound) {
+		t.Errorf("err = %v, want ErrDocumentNotFound", err)
+	}
+
+	empty, err := StoreDocuments(ctx, nil)
+	if err != nil {
+		t.Fatalf("store empty: %v", err)
+	}
+	if _, err := LoadDocumentByID(ctx, empty, "1"); !errors.Is(err, ErrDocumentNotFound) {
+		t.Errorf("empty ref err = %v, want ErrDocumentNotFound", err)
+	}
+}
+
+func TestDropStaleChunks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	chunk := func(parent string, index int) Document {
+		return Document{Content: "text"}.AsChunk(parent, index)
+	}
+	ref, err := StoreDocuments(ctx, []Document{
+		chunk("a", 0), chunk("a", 1), chunk("a", 2),
+		chunk("b", 0), chunk("b", 1),
+		{ID: "c", Content: "unsplit"},
+	})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	// a was re-chunked into two chunks and c is now split; b was not
+	// re-chunked.
+	current := []Document{chunk("a", 0), chunk("a", 1), chunk("c", 0), chunk("c", 1)}
+	stale, err := DropStaleChunks(ctx, ref, current)
+	if err != nil {
+		t.Fatalf("drop stale: %v", err)
+	}
+	if want := []string{"a#2", "c"}; !reflect.DeepEqual(stale, want) {
+		t.Errorf("stale = %v, want %v", stale, want)
+	}
+
+	stale, err = DropStaleChunks(ctx, ref, []Document{{ID: "a", Content: "now short"}})
+	if err != nil {
+		t.Fatalf("drop stale: %v", err)
+	}
+	if want := []string{"a#0", "a#1", "a#2"}; !reflect.DeepEqual(stale, want) {
+		t.Errorf("stale = %v, want %v", stale, want)
+	}
+
+	manifestRef := core.DataRef{Schema: SchemaManifest}
+	if _, err := DropStaleChunks(ctx, manifestRef, current); err == nil {
+		t.Error("expected schema mismatch error")
+	}
+}
+
+func TestLoadDocumentsSchemaVersions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage, err := core.GetStorage()
+	if err != nil {
+		t.Fatalf("get storage: %v", err)
+	}
+
+	legacy, err := storage.StoreJSON(ctx, SchemaDocuments, []Document{{ID: "1", Content: "legacy"}})
+	if err != nil {
+		t.Fatalf("store legacy: %v", err)
+	}
+	docs, err := LoadDocuments(ctx, legacy)
+	if err != nil {
+		t.Fatalf("load legacy: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Content != "legacy" {
+		t.Errorf("legacy documents = %+v, want one document with content %q", docs, "legacy")
+	}
+	if doc, err := LoadDocumentByID(ctx, legacy, "1"); err != nil || doc.Content != "legacy" {
+		t.Errorf("LoadDocumentByID(legacy) = %+v, %v", doc, err)
+	}
+
+	future, err := storage.StoreJSON(ctx, SchemaDocuments, map[string]any{
+		"schema_version": DocumentsSchemaVersion + 1,
+		"documents":      []Document{},
+	})
+	if err != nil {
+		t.Fatalf("store future: %v", err)
+	}
+	if _, err := LoadDocuments(ctx, future); err == nil {
+		t.Error("expected error loading a newer schema version, got nil")
+	}
+}
+
+func TestMigrateDocuments(t *testing.T) {
+	t.Parallel()
+
+	body, err := migrateDocuments(1, json.RawMessage(`[{"id":"1","content":"x"},null]`))
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var docs []Document
+	if err := json.Unmarshal(body, &docs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(docs) != 2 || docs[0].ID != "1" {
+		t.Errorf("migrated documents = %+v", docs)
+	}
+
+	if _, err := migrateDocuments(0, json.RawMessage(`[]`)); err == nil {
+		t.Error("expected error for a version without a registered migration, got nil")
+	}
+}
+
+func TestRefWriter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	w, err := NewRefWriter(ctx)
+	if err != nil {
+		t.Fatalf("NewRefWriter: %v", err)
+	}
+
+	docs := []Document{{ID: "1", Content: "line\nbreak"}, {ID: "2", Metadata: map[string]string{"k": "v"}}}
+	if _, err := MergeDocumentsTo(ctx, w, docs); err != nil {
+		t.Fatalf("MergeDocumentsTo: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := w.Write(ctx, Document{ID: "3"}); err == nil {
+		t.Error("expected error writing after Close")
+	}
+
+	ref := w.Ref()
+	if ref.Count != len(docs) {
+		t.Errorf("Count = %d, want %d", ref.Count, len(docs))
+	}
+	loaded, err := LoadDocuments(ctx, ref)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Content != docs[0].Content || loaded[1].Metadata["k"] != "v" {
+		t.Errorf("loaded %+v, want %+v", loaded, docs)
+	}
+}
+
+func TestStoreDocumentsAttributeTypes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := []Document{{ID: "1", Attributes: Attributes{
+		"views":    int64(9007199254740993),
+		"count":    3,
+		"score":    0.25,
+		"ratio":    float32(0.5),
+		"public":   true,
+		"deleted":  nil,
+		"owner":    "ops",
+		"tags":     []any{"a", 2},
+		"location": map[string]any{"lat": 51.5, "floor": 4},
+	}}}
+
+	ref, err := StoreDocuments(ctx, docs)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	loaded, err := LoadDocuments(ctx, ref)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	want := Attributes{
+		"views":    int64(9007199254740993),
+		"count":    int64(3),
+		"score":    0.25,
+		"ratio":    0.5,
+		"public":   true,
+		"deleted":  nil,
+		"owner":    "ops",
+		"tags":     []any{"a", int64(2)},
+		"location": map[string]any{"lat": 51.5, "floor": int64(4)},
+	}
+	if got := loaded[0].Attributes; !reflect.DeepEqual(got, want) {
+		t.Errorf("Attributes = %#v, want %#v", got, want)
+	}
+}
+
+func TestStoreDocumentsLimits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := make([]Document, 5)
+	for i := range docs {
+		docs[i] = Document{ID: "doc-" + itoa(i), Content: strings.Repeat("x", 100)}
+	}
+
+	if _, err := StoreDocumentsWith(ctx, docs, StoreOptions{MaxDocuments: 4}); !errors.Is(err, ErrStoreLimit) || !strings.Contains(err.Error(), "5 documents, limit 4") {
+		t.Errorf("MaxDocuments err = %v, want ErrStoreLimit with sizes", err)
+	}
+	if _, err := StoreDocumentsWith(ctx, docs, StoreOptions{MaxBytes: 200}); !errors.Is(err, ErrStoreLimit) {
+		t.Errorf("MaxBytes err = %v, want ErrStoreLimit", err)
+	}
+	if _, err := StoreDocumentsWith(ctx, docs, StoreOptions{MaxDocuments: 5, MaxBytes: 1 << 20}); err != nil {
+		t.Errorf("within limits: unexpected error: %v", err)
+	}
+
+	// The limit applies to exactly the bytes StoreJSON persists.
+	data, err := json.Marshal(documentsPayload{SchemaVersion: DocumentsSchemaVersion, Documents: docs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StoreDocumentsWith(ctx, docs, StoreOptions{MaxBytes: len(data)}); err != nil {
+		t.Errorf("MaxBytes at payload size: unexpected error: %v", err)
+	}
+	if _, err := StoreDocumentsWith(ctx, docs, StoreOptions{MaxBytes: len(data) - 1}); !errors.Is(err, ErrStoreLimit) {
+		t.Errorf("MaxBytes below payload size err = %v, want ErrStoreLimit", err)
+	}
+
+	const maxBytes = 500
+	refs, err := StoreDocumentShards(ctx, docs, StoreOptions{MaxDocuments: 2, MaxBytes: maxBytes})
+	if err != nil {
+		t.Fatalf("shards: unexpected error: %v", err)
+	}
+	var loaded []Document
+	for _, ref := range refs {
+		if ref.Count > 2 {
+			t.Errorf("shard holds %d documents, want at most 2", ref.Count)
+		}
+		shard, err := LoadDocuments(ctx, ref)
+		if err != nil {
+			t.Fatalf("load shard: %v", err)
+		}
+		loaded = append(loaded, shard...)
+	}
+	if len(refs) != 3 || len(loaded) != len(docs) || loaded[4].ID != "doc-4" {
+		t.Errorf("got %d shards with %d documents, want 3 shards with all 5 in order", len(refs), len(loaded))
+	}
+
+	if _, err := StoreDocumentShards(ctx, docs, StoreOptions{MaxBytes: 100}); !errors.Is(err, ErrStoreLimit) {
+		t.Errorf("oversized document err = %v, want ErrStoreLimit", err)
+	}
+}
@@ -0,0 +1,97 @@
+package transform
+
+import (
+	"context"
+	"strings"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// StripMetadataInput is the input for the StripMetadata transformer.
+type StripMetadataInput struct {
+	Documents []Document
+
+	// Keys lists the metadata keys to remove. A key ending in "*"
+	// matches every key with that prefix, e.g. "internal_*".
+	Keys []string
+}
+
+// StripMetadataOutput is the output of the StripMetadata transformer.
+type StripMetadataOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for StripMetadataOutput.
+func (o StripMetadataOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// StripMetadataActivity removes the matching metadata keys from every
+// document. The input metadata maps are not modified.
+func StripMetadataActivity(ctx context.Context, input StripMetadataInput) (StripMetadataOutput, error) {
+	docs := stripMetadata(input.Documents, input.Keys)
+	return StripMetadataOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// StripMetadata creates a node that removes intermediate metadata, such
+// as raw content or token offsets, before documents are stored or
+// exported.
+//
+// Example:
+//
+//	flow := core.NewFlow("ingest").
+//	    Then(chunkNode).
+//	    Then(transform.StripMetadata("raw_content", "internal_*")).
+//	    Then(embedNode).
+//	    Build()
+func StripMetadata(keys ...string) *core.Node[StripMetadataInput, StripMetadataOutput] {
+	return core.NewNode("transform.StripMetadata", StripMetadataActivity, StripMetadataInput{Keys: keys})
+}
+
+// stripMetadata returns copies of docs without the metadata keys matching
+// patterns. Documents without matching keys share their original map.
+func stripMetadata(docs []Document, patterns []string) []Document {
+	out := make([]Document, len(docs))
+	for i, doc := range docs {
+		if len(patterns) > 0 && hasMatchingKey(doc.Metadata, patterns) {
+			metadata := make(map[string]string, len(doc.Metadata))
+			for k, v := range doc.Metadata {
+				if !matchesMetadataKey(k, patterns) {
+					metadata[k] = v
+				}
+			}
+			doc.Metadata = metadata
+		}
+		out[i] = doc
+	}
+	return out
+}
+
+// hasMatchingKey reports whether any key of metadata matches patterns.
+func hasMatchingKey(metadata map[string]string, patterns []string) bool {
+	for k := range metadata {
+		if matchesMetadataKey(k, patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMetadataKey reports whether key equals a pattern, or starts with
+// the prefix of a pattern ending in "*".
+func matchesMetadataKey(key string, patterns []string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
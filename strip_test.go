@@ -0,0 +1,72 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestStripMetadataActivity(t *testing.T) {
+	t.Parallel()
+
+	metadata := map[string]string{"raw_content": "x", "internal_a": "1", "internal_b": "2", "lang": "en"}
+
+	tests := []struct {
+		name string
+		keys []string
+		want map[string]string
+	}{
+		{name: "no keys", want: metadata},
+		{name: "exact", keys: []string{"raw_content"}, want: map[string]string{"internal_a": "1", "internal_b": "2", "lang": "en"}},
+		{name: "prefix", keys: []string{"raw_content", "internal_*"}, want: map[string]string{"lang": "en"}},
+		{name: "no match", keys: []string{"internal"}, want: metadata},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := StripMetadataActivity(context.Background(), StripMetadataInput{
+				Documents: []Document{{ID: "1", Metadata: metadata}},
+				Keys:      tt.keys,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := out.Documents[0].Metadata; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Metadata = %v, want %v", got, tt.want)
+			}
+			if len(metadata) != 4 {
+				t.Errorf("input metadata was mutated: %v", metadata)
+			}
+		})
+	}
+}
+
+func TestExcludeMetadataOnStoreAndExport(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	docs := []Document{{ID: "1", Content: "body", Metadata: map[string]string{"token_start": "0", "lang": "en"}}}
+	want := map[string]string{"lang": "en"}
+
+	ref, err := StoreDocumentsWith(ctx, docs, StoreOptions{ExcludeMetadata: []string{"token_*"}})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	loaded, err := LoadDocuments(ctx, ref)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded[0].Metadata, want) {
+		t.Errorf("stored Metadata = %v, want %v", loaded[0].Metadata, want)
+	}
+
+	data, err := docs[0].ToJSONWith(ExportSchema{ExcludeMetadata: []string{"token_start"}})
+	if err != nil {
+		t.Fatalf("ToJSONWith: %v", err)
+	}
+	if got := string(data); got != `{"id":"1","content":"body","source":"","metadata":{"lang":"en"},"updated_at":"0001-01-01T00:00:00Z"}` {
+		t.Errorf("ToJSONWith = %s", got)
+	}
+}
@@ -0,0 +1,80 @@
+package transform
+
+import (
+	"context"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// TagInput is the input for the Tag transformer.
+type TagInput struct {
+	Documents []Document
+	Metadata  map[string]string
+
+	// Overwrite replaces existing metadata values for the given keys.
+	// By default keys already present on a document are left unchanged.
+	Overwrite bool
+}
+
+// TagOutput is the output of the Tag transformer.
+type TagOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for TagOutput.
+func (o TagOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// TagActivity merges the given metadata into every document.
+func TagActivity(ctx context.Context, input TagInput) (TagOutput, error) {
+	docs := tagDocuments(input.Documents, input.Metadata, input.Overwrite)
+	return TagOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// Tag creates a node that merges kv into the metadata of every document,
+// e.g. to attach a run ID or tenant tag to a whole batch. Existing keys
+// are kept; set TagInput.Overwrite to replace them.
+//
+// Example:
+//
+//	flow := core.NewFlow("ingest").
+//	    Then(fetchNode).
+//	    Then(transform.Tag(map[string]string{"tenant": "acme"})).
+//	    Build()
+func Tag(kv map[string]string) *core.Node[TagInput, TagOutput] {
+	return core.NewNode("transform.Tag", TagActivity, TagInput{Metadata: kv})
+}
+
+// TagAll returns copies of docs with kv merged into their metadata.
+// Keys already present on a document are not overwritten. The input
+// documents and their metadata maps are not modified.
+func TagAll(docs []Document, kv map[string]string) []Document {
+	return tagDocuments(docs, kv, false)
+}
+
+// tagDocuments merges kv into a fresh metadata map for each document.
+func tagDocuments(docs []Document, kv map[string]string, overwrite bool) []Document {
+	out := make([]Document, len(docs))
+	for i, doc := range docs {
+		if len(kv) > 0 {
+			metadata := make(map[string]string, len(doc.Metadata)+len(kv))
+			for k, v := range doc.Metadata {
+				metadata[k] = v
+			}
+			for k, v := range kv {
+				if _, ok := metadata[k]; ok && !overwrite {
+					continue
+				}
+				metadata[k] = v
+			}
+			doc.Metadata = metadata
+		}
+		out[i] = doc
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package transform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTagAll(t *testing.T) {
+	t.Parallel()
+
+	shared := map[string]string{"tenant": "old", "kind": "page"}
+	docs := []Document{
+		{ID: "a", Metadata: shared},
+		{ID: "b", Metadata: shared},
+		{ID: "c"},
+	}
+
+	tagged := TagAll(docs, map[string]string{"tenant": "acme", "run": "42"})
+
+	for i, doc := range tagged {
+		if doc.Metadata["run"] != "42" {
+			t.Errorf("Documents[%d] run = %q, want %q", i, doc.Metadata["run"], "42")
+		}
+	}
+	if got := tagged[0].Metadata["tenant"]; got != "old" {
+		t.Errorf("existing tenant = %q, want %q", got, "old")
+	}
+	if got := tagged[2].Metadata["tenant"]; got != "acme" {
+		t.Errorf("new tenant = %q, want %q", got, "acme")
+	}
+
+	tagged[0].Metadata["kind"] = "changed"
+	if shared["kind"] != "page" || len(shared) != 2 {
+		t.Errorf("shared metadata was mutated: %v", shared)
+	}
+	if tagged[1].Metadata["kind"] != "page" {
+		t.Errorf("documents share a tagged metadata map")
+	}
+	if docs[2].Metadata != nil {
+		t.Errorf("input document metadata was modified: %v", docs[2].Metadata)
+	}
+}
+
+func TestTagActivityOverwrite(t *testing.T) {
+	t.Parallel()
+
+	out, err := TagActivity(context.Background(), TagInput{
+		Documents: []Document{{ID: "a", Metadata: map[string]string{"tenant": "old"}}},
+		Metadata:  map[string]string{"tenant": "acme"},
+		Overwrite: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := out.Documents[0].Metadata["tenant"]; got != "acme" {
+		t.Errorf("tenant = %q, want %q", got, "acme")
+	}
+	if out.Count != 1 {
+		t.Errorf("Count = %d, want 1", out.Count)
+	}
+}
@@ -0,0 +1,73 @@
+package transform
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Metadata keys used to cache a document's token count.
+const (
+	// MetadataTokenCount holds the cached word-token count of Content.
+	MetadataTokenCount = "token_count"
+
+	// MetadataTokenHash holds a fingerprint of the Content the cached
+	// count was computed from. A mismatch invalidates the cache.
+	MetadataTokenHash = "token_count_hash"
+)
+
+// TokenCount returns the number of word tokens in doc.Content.
+// It uses the cached count in Metadata when the content is unchanged,
+// and counts the tokens otherwise.
+func TokenCount(doc Document) int {
+	if n, ok := cachedTokenCount(doc); ok {
+		return n
+	}
+	return len(strings.Fields(doc.Content))
+}
+
+// WithTokenCount returns doc with its token count cached in Metadata, so
+// later transforms can reuse it without re-tokenizing the content.
+func WithTokenCount(doc Document) Document {
+	if _, ok := cachedTokenCount(doc); ok {
+		return doc
+	}
+	return withCachedTokenCount(doc, len(strings.Fields(doc.Content)))
+}
+
+// withCachedTokenCount stores n as doc's token count, copying Metadata so
+// maps shared with other documents are not mutated.
+func withCachedTokenCount(doc Document, n int) Document {
+	doc.Metadata = copyMetadata(doc.Metadata)
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]string, 2)
+	}
+	doc.Metadata[MetadataTokenCount] = strconv.Itoa(n)
+	doc.Metadata[MetadataTokenHash] = contentFingerprint(doc.Content)
+	return doc
+}
+
+// cachedTokenCount returns the cached token count if it is present and
+// was computed from the current content.
+func cachedTokenCount(doc Document) (int, bool) {
+	raw, ok := doc.Metadata[MetadataTokenCount]
+	if !ok {
+		return 0, false
+	}
+	if doc.Metadata[MetadataTokenHash] != contentFingerprint(doc.Content) {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// contentFingerprint returns a cheap, non-cryptographic fingerprint of s.
+func contentFingerprint(s string) string {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,78 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenCountCache(t *testing.T) {
+	t.Parallel()
+
+	shared := map[string]string{"team": "sre"}
+	doc := Document{ID: "1", Content: "one two three", Metadata: shared}
+
+	cached := WithTokenCount(doc)
+	if _, exists := shared[MetadataTokenCount]; exists {
+		t.Error("WithTokenCount mutated the original metadata map")
+	}
+	if got := cached.Metadata[MetadataTokenCount]; got != "3" {
+		t.Errorf("cached count = %q, want %q", got, "3")
+	}
+	if got := TokenCount(cached); got != 3 {
+		t.Errorf("TokenCount = %d, want 3", got)
+	}
+
+	// Changing the content invalidates the cached count.
+	cached.Content = "one two three four five"
+	if got := TokenCount(cached); got != 5 {
+		t.Errorf("TokenCount after edit = %d, want 5", got)
+	}
+
+	// A forged count with a matching fingerprint is trusted, which shows
+	// the cache is consulted rather than recomputed.
+	forged := withCachedTokenCount(Document{Content: "a b c d e f g h i j k l"}, 2)
+	if got := TokenCount(forged); got != 2 {
+		t.Errorf("TokenCount with cache = %d, want 2", got)
+	}
+	chunks := chunkDocument(forged, ChunkOptions{MaxTokens: 4, Separator: "\n\n"})
+	if len(chunks) != 1 {
+		t.Errorf("got %d chunks, want 1 (cached count below MaxTokens)", len(chunks))
+	}
+}
+
+func TestChunkActivityCacheTokenCounts(t *testing.T) {
+	t.Parallel()
+
+	out := mustChunk(t, ChunkInput{
+		Documents: []Document{{ID: "doc", Content: "a b c d e f g h i j", Source: "test"}},
+		Options:   ChunkOptions{MaxTokens: 4, Separator: "\n\n", CacheTokenCounts: true},
+	})
+
+	for _, chunk := range out.Documents {
+		n, ok := cachedTokenCount(chunk)
+		if !ok {
+			t.Fatalf("chunk %s has no cached token count", chunk.ID)
+		}
+		if want := len(tokenize(chunk.Content, "\n\n", nil)); n != want {
+			t.Errorf("chunk %s: cached count = %d, want %d", chunk.ID, n, want)
+		}
+	}
+}
+
+func TestCachedTokenCountCharacterSplit(t *testing.T) {
+	t.Parallel()
+
+	// Without whitespace or a listed marker, the empty marker falls back
+	// to splitting characters, which the whitespace count cannot describe.
+	doc := Document{ID: "cjk", Content: strings.Repeat("漢字", 25), Source: "test"}
+	opts := ChunkOptions{MaxTokens: 10, Separator: "\n\n", ParagraphMarkers: []string{""}}
+
+	uncached := mustChunk(t, ChunkInput{Documents: []Document{doc}, Options: opts})
+	if uncached.Count != 5 {
+		t.Fatalf("uncached: got %d chunks, want 5", uncached.Count)
+	}
+	cached := mustChunk(t, ChunkInput{Documents: []Document{WithTokenCount(doc)}, Options: opts})
+	if cached.Count != uncached.Count {
+		t.Errorf("cached: got %d chunks, want %d", cached.Count, uncached.Count)
+	}
+}
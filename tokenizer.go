@@ -0,0 +1,120 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Tokenizer counts the tokens a model would see for a piece of text.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// WordTokenizer counts whitespace-separated words, matching how chunking
+// measures MaxTokens.
+type WordTokenizer struct{}
+
+// CountTokens implements Tokenizer.
+func (WordTokenizer) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// HeuristicTokenizer approximates BPE token counts at ~4 characters per
+// token.
+type HeuristicTokenizer struct{}
+
+// CountTokens implements Tokenizer.
+func (HeuristicTokenizer) CountTokens(text string) int {
+	return estimateHeuristic(text)
+}
+
+// Names of the built-in tokenizers.
+const (
+	TokenizerWords     = "words"
+	TokenizerHeuristic = "heuristic"
+)
+
+var tokenizers = struct {
+	mu         sync.RWMutex
+	tokenizers map[string]Tokenizer
+	// defaultName is the tokenizer selected by an empty name and used by
+	// EstimateTokens.
+	defaultName string
+}{
+	defaultName: TokenizerHeuristic,
+	tokenizers: map[string]Tokenizer{
+		TokenizerWords:     WordTokenizer{},
+		TokenizerHeuristic: HeuristicTokenizer{},
+	},
+}
+
+// RegisterTokenizer registers a tokenizer under name, replacing any
+// existing registration, so it can be selected by name in node options.
+// Tokenizers may be used concurrently. Register custom tokenizers on
+// every worker before starting it.
+func RegisterTokenizer(name string, tokenizer Tokenizer) {
+	tokenizers.mu.Lock()
+	defer tokenizers.mu.Unlock()
+	tokenizers.tokenizers[name] = tokenizer
+}
+
+// RegisteredTokenizers returns the names of all registered tokenizers.
+func RegisteredTokenizers() []string {
+	tokenizers.mu.RLock()
+	defer tokenizers.mu.RUnlock()
+	return sortedKeys(tokenizers.tokenizers)
+}
+
+// SetDefaultTokenizer selects the registered tokenizer used by
+// EstimateTokens and by options that leave their tokenizer name empty,
+// so token estimates across the package match the deployment's model.
+// An empty name restores TokenizerHeuristic.
+func SetDefaultTokenizer(name string) error {
+	if name == "" {
+		name = TokenizerHeuristic
+	}
+
+	tokenizers.mu.Lock()
+	defer tokenizers.mu.Unlock()
+	if _, ok := tokenizers.tokenizers[name]; !ok {
+		return fmt.Errorf("unknown tokenizer: %q", name)
+	}
+	tokenizers.defaultName = name
+	return nil
+}
+
+// defaultTokenizer returns the tokenizer selected by SetDefaultTokenizer.
+func defaultTokenizer() Tokenizer {
+	tokenizer, _ := lookupTokenizer("")
+	return tokenizer
+}
+
+// lookupTokenizer returns the tokenizer registered under name. An empty
+// name selects the default tokenizer.
+func lookupTokenizer(name string) (Tokenizer, bool) {
+	tokenizers.mu.RLock()
+	defer tokenizers.mu.RUnlock()
+	if name == "" {
+		name = tokenizers.defaultName
+	}
+	tokenizer, ok := tokenizers.tokenizers[name]
+	return tokenizer, ok
+}
+
+// ValidateChunkSizes returns the IDs of documents whose content exceeds
+// maxTokens as counted by tokenizer, e.g. to check chunks against a
+// model's context window before embedding. A nil tokenizer counts words.
+func ValidateChunkSizes(docs []Document, maxTokens int, tokenizer Tokenizer) []string {
+	if tokenizer == nil {
+		tokenizer = WordTokenizer{}
+	}
+
+	var oversized []string
+	for _, doc := range docs {
+		if tokenizer.CountTokens(doc.Content) > maxTokens {
+			oversized = append(oversized, doc.ID)
+		}
+	}
+	return oversized
+}
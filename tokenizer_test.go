@@ -0,0 +1,78 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateChunkSizes(t *testing.T) {
+	t.Parallel()
+
+	docs := []Document{
+		{ID: "short", Content: "one two three"},
+		{ID: "long-words", Content: "one two three four five six"},
+		{ID: "long-chars", Content: strings.Repeat("x", 40)},
+	}
+
+	tests := []struct {
+		name      string
+		tokenizer Tokenizer
+		want      []string
+	}{
+		{name: "nil counts words", tokenizer: nil, want: []string{"long-words"}},
+		{name: "heuristic", tokenizer: HeuristicTokenizer{}, want: []string{"long-words", "long-chars"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ValidateChunkSizes(docs, 5, tt.tokenizer)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("got[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateTokensWith(t *testing.T) {
+	t.Parallel()
+
+	s := "one two three four five six seven eight"
+	if got := EstimateTokensWith(s, nil); got != 9 {
+		t.Errorf("nil tokenizer = %d, want 9", got)
+	}
+	if got := EstimateTokensWith(s, WordTokenizer{}); got != 8 {
+		t.Errorf("word tokenizer = %d, want 8", got)
+	}
+}
+
+func TestSetDefaultTokenizer(t *testing.T) {
+	t.Cleanup(func() { _ = SetDefaultTokenizer("") })
+
+	s := "one two three four five six seven eight"
+	if err := SetDefaultTokenizer(TokenizerWords); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := EstimateTokens(s); got != 8 {
+		t.Errorf("EstimateTokens with words default = %d, want 8", got)
+	}
+	if got := (HeuristicTokenizer{}).CountTokens(s); got != 9 {
+		t.Errorf("HeuristicTokenizer = %d, want 9", got)
+	}
+
+	if err := SetDefaultTokenizer("missing"); err == nil {
+		t.Error("expected error for unknown tokenizer")
+	}
+	if err := SetDefaultTokenizer(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := EstimateTokens(s); got != 9 {
+		t.Errorf("EstimateTokens after reset = %d, want 9", got)
+	}
+}
@@ -0,0 +1,183 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// Transformer is the common contract of document transforms: it maps a
+// batch of documents to a new batch. ChunkOptions, MergeOptions,
+// FilterOptions, DedupOptions, HTMLToTextOptions and CleanOptions
+// implement it, so built-in and custom transforms compose with Chain and
+// run as flow nodes with FromTransformer.
+type Transformer interface {
+	Transform(ctx context.Context, docs []Document) ([]Document, error)
+}
+
+// TransformerFunc adapts a function to the Transformer interface.
+type TransformerFunc func(ctx context.Context, docs []Document) ([]Document, error)
+
+// Transform implements Transformer.
+func (f TransformerFunc) Transform(ctx context.Context, docs []Document) ([]Document, error) {
+	return f(ctx, docs)
+}
+
+// Transform implements Transformer by chunking docs with o.
+func (o ChunkOptions) Transform(ctx context.Context, docs []Document) ([]Document, error) {
+	out, err := ChunkActivity(ctx, ChunkInput{Documents: docs, Options: o})
+	return out.Documents, err
+}
+
+// Transform implements Transformer by merging docs as a single source
+// with o, applying quotas and labels.
+func (o MergeOptions) Transform(ctx context.Context, docs []Document) ([]Document, error) {
+	out, err := MergeActivity(ctx, MergeInput{Sources: []DocumentSource{DocumentBatch{Documents: docs}}, Options: o})
+	return out.Documents, err
+}
+
+// Transform implements Transformer by filtering docs with o.
+func (o FilterOptions) Transform(ctx context.Context, docs []Document) ([]Document, error) {
+	out, err := FilterActivity(ctx, FilterInput{Documents: docs, Options: o})
+	return out.Documents, err
+}
+
+// Transform implements Transformer by removing duplicates from docs
+// with o.
+func (o DedupOptions) Transform(ctx context.Context, docs []Document) ([]Document, error) {
+	out, err := DedupActivity(ctx, DedupInput{Documents: docs, Options: o})
+	return out.Documents, err
+}
+
+// Transform implements Transformer by converting HTML content in docs
+// to text with o.
+func (o HTMLToTextOptions) Transform(ctx context.Context, docs []Document) ([]Document, error) {
+	out, err := HTMLToTextActivity(ctx, HTMLToTextInput{Documents: docs, Options: o})
+	return out.Documents, err
+}
+
+// Transform implements Transformer by cleaning the content of docs
+// with o.
+func (o CleanOptions) Transform(ctx context.Context, docs []Document) ([]Document, error) {
+	out, err := CleanActivity(ctx, CleanInput{Documents: docs, Options: o})
+	return out.Documents, err
+}
+
+// chain applies transformers in order.
+type chain []Transformer
+
+// Transform implements Transformer.
+func (c chain) Transform(ctx context.Context, docs []Document) ([]Document, error) {
+	for i, t := range c {
+		var err error
+		if docs, err = t.Transform(ctx, docs); err != nil {
+			return nil, fmt.Errorf("transformer %d: %w", i, err)
+		}
+	}
+	return docs, nil
+}
+
+// Chain returns a Transformer that applies transformers in order, each
+// to the output of the previous one.
+func Chain(transformers ...Transformer) Transformer {
+	return chain(append([]Transformer(nil), transformers...))
+}
+
+var transformers = struct {
+	mu           sync.RWMutex
+	transformers map[string]Transformer
+}{
+	transformers: make(map[string]Transformer),
+}
+
+// RegisterTransformer registers a transformer under name, replacing any
+// existing registration, so TransformActivity can reference it by name.
+// Transformers may be used concurrently. Register transformers on every
+// worker before starting it.
+func RegisterTransformer(name string, t Transformer) {
+	transformers.mu.Lock()
+	defer transformers.mu.Unlock()
+	transformers.transformers[name] = t
+}
+
+// RegisteredTransformers returns the names of all registered transformers.
+func RegisteredTransformers() []string {
+	transformers.mu.RLock()
+	defer transformers.mu.RUnlock()
+	return sortedKeys(transformers.transformers)
+}
+
+// lookupTransformer returns the transformer registered under name.
+func lookupTransformer(name string) (Transformer, error) {
+	transformers.mu.RLock()
+	defer transformers.mu.RUnlock()
+	t, ok := transformers.transformers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown transformer: %q", name)
+	}
+	return t, nil
+}
+
+// TransformInput is the input for the Transform transformer.
+type TransformInput struct {
+	Documents []Document
+
+	// Transformer names a registered Transformer (see RegisterTransformer).
+	Transformer string
+}
+
+// TransformOutput is the output of the Transform transformer.
+type TransformOutput struct {
+	Documents []Document
+	Count     int
+}
+
+// ToDocuments implements DocumentSource for TransformOutput.
+func (o TransformOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// TransformActivity applies the named transformer to the documents.
+func TransformActivity(ctx context.Context, input TransformInput) (TransformOutput, error) {
+	t, err := lookupTransformer(input.Transformer)
+	if err != nil {
+		return TransformOutput{}, err
+	}
+
+	docs, err := t.Transform(ctx, input.Documents)
+	if err != nil {
+		return TransformOutput{}, fmt.Errorf("transform %s: %w", input.Transformer, err)
+	}
+	if docs == nil {
+		docs = []Document{}
+	}
+
+	return TransformOutput{
+		Documents: docs,
+		Count:     len(docs),
+	}, nil
+}
+
+// FromTransformer registers t under name and creates a node named name
+// that applies it, so custom transforms slot into flows like the
+// built-ins. The flow must be built on every worker, or t registered
+// there with RegisterTransformer, before the worker starts.
+//
+// Example:
+//
+//	clean := transform.Chain(
+//	    transform.HTMLToTextOptions{},
+//	    transform.TransformerFunc(redact),
+//	    transform.ChunkOptions{MaxTokens: 512},
+//	)
+//
+//	flow := core.NewFlow("kb").
+//	    Then(fetchNode).
+//	    Then(transform.FromTransformer("kb.clean", clean)).
+//	    Build()
+func FromTransformer(name string, t Transformer) *core.Node[TransformInput, TransformOutput] {
+	RegisterTransformer(name, t)
+	return core.NewNode(name, TransformActivity, TransformInput{Transformer: name})
+}
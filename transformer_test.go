@@ -0,0 +1,74 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	upper := TransformerFunc(func(ctx context.Context, docs []Document) ([]Document, error) {
+		out := make([]Document, len(docs))
+		for i, doc := range docs {
+			doc.Content = strings.ToUpper(doc.Content)
+			out[i] = doc
+		}
+		return out, nil
+	})
+
+	docs := []Document{
+		{ID: "a", Content: "<p>one two three four</p>"},
+		{ID: "b", Content: "<p>one two three four</p>"},
+	}
+
+	got, err := Chain(HTMLToTextOptions{}, DedupOptions{}, upper, ChunkOptions{MaxTokens: 2, Separator: " "}).
+		Transform(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"ONE TWO", "THREE FOUR"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d documents, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Content != want[i] {
+			t.Errorf("doc %d content = %q, want %q", i, got[i].Content, want[i])
+		}
+	}
+
+	failing := TransformerFunc(func(ctx context.Context, docs []Document) ([]Document, error) {
+		return nil, errors.New("boom")
+	})
+	if _, err := Chain(upper, failing).Transform(context.Background(), docs); err == nil || !strings.Contains(err.Error(), "transformer 1") {
+		t.Errorf("err = %v, want error naming transformer 1", err)
+	}
+}
+
+func TestFromTransformer(t *testing.T) {
+	t.Parallel()
+
+	node := FromTransformer("test.from_transformer", FilterOptions{MaxAge: time.Hour, DropUndated: true})
+	if node.Name() != "test.from_transformer" {
+		t.Errorf("Name() = %q, want %q", node.Name(), "test.from_transformer")
+	}
+
+	out, err := TransformActivity(context.Background(), TransformInput{
+		Documents:   []Document{{ID: "undated", Content: "x"}},
+		Transformer: "test.from_transformer",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Count != 0 || out.Documents == nil {
+		t.Errorf("got %d documents (nil=%v), want empty non-nil", out.Count, out.Documents == nil)
+	}
+
+	if _, err := TransformActivity(context.Background(), TransformInput{Transformer: "missing"}); err == nil {
+		t.Error("expected error for unknown transformer")
+	}
+}
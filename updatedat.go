@@ -0,0 +1,144 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// UpdatedAtMode determines where FillUpdatedAt takes missing update
+// times from.
+type UpdatedAtMode string
+
+const (
+	// UpdatedAtNow stamps the current time of the package clock (see
+	// SetClock).
+	UpdatedAtNow UpdatedAtMode = "now"
+
+	// UpdatedAtMetadata parses the time from a metadata field.
+	UpdatedAtMetadata UpdatedAtMode = "metadata"
+)
+
+// UpdatedAtPolicy configures how FillUpdatedAt fills a zero UpdatedAt.
+// Build one with UseNow or UseMetadata.
+type UpdatedAtPolicy struct {
+	Mode UpdatedAtMode
+
+	// Key is the metadata field holding the time for UpdatedAtMetadata.
+	Key string
+
+	// Layout is the time.Parse layout of the Key field.
+	// Default: time.RFC3339
+	Layout string
+
+	// Selector limits filling to the documents it matches, e.g. the
+	// sources that leave UpdatedAt unset.
+	Selector Selector
+}
+
+// UseNow returns a policy that stamps documents with the current time.
+func UseNow() UpdatedAtPolicy {
+	return UpdatedAtPolicy{Mode: UpdatedAtNow}
+}
+
+// UseMetadata returns a policy that parses documents' update time from
+// Metadata[key] as RFC 3339; see WithLayout for other formats.
+func UseMetadata(key string) UpdatedAtPolicy {
+	return UpdatedAtPolicy{Mode: UpdatedAtMetadata, Key: key}
+}
+
+// WithLayout returns a copy of p that parses the metadata field with the
+// time.Parse layout.
+func (p UpdatedAtPolicy) WithLayout(layout string) UpdatedAtPolicy {
+	p.Layout = layout
+	return p
+}
+
+// FillUpdatedAtInput is the input for the FillUpdatedAt transformer.
+type FillUpdatedAtInput struct {
+	Documents []Document
+	Policy    UpdatedAtPolicy
+}
+
+// FillUpdatedAtOutput is the output of the FillUpdatedAt transformer.
+type FillUpdatedAtOutput struct {
+	Documents []Document
+	Count     int
+
+	// Filled is the number of documents given an UpdatedAt.
+	Filled int
+}
+
+// ToDocuments implements DocumentSource for FillUpdatedAtOutput.
+func (o FillUpdatedAtOutput) ToDocuments() []Document {
+	return o.Documents
+}
+
+// FillUpdatedAtActivity sets UpdatedAt on every selected document whose
+// UpdatedAt is zero, according to the policy. Under UpdatedAtMetadata,
+// documents without the field keep a zero UpdatedAt, and a value that
+// does not match the layout is an error rather than being skipped
+// silently.
+func FillUpdatedAtActivity(ctx context.Context, input FillUpdatedAtInput) (FillUpdatedAtOutput, error) {
+	policy := input.Policy
+	switch policy.Mode {
+	case UpdatedAtNow:
+	case UpdatedAtMetadata:
+		if policy.Key == "" {
+			return FillUpdatedAtOutput{}, fmt.Errorf("updated-at policy %q requires a metadata key", policy.Mode)
+		}
+		if policy.Layout == "" {
+			policy.Layout = time.RFC3339
+		}
+	default:
+		return FillUpdatedAtOutput{}, fmt.Errorf("unknown updated-at mode: %q", policy.Mode)
+	}
+
+	current := now()
+	docs := make([]Document, len(input.Documents))
+	var filled int
+	for i, doc := range input.Documents {
+		docs[i] = doc
+		if !doc.UpdatedAt.IsZero() || !policy.Selector.Matches(doc) {
+			continue
+		}
+
+		updatedAt := current
+		if policy.Mode == UpdatedAtMetadata {
+			value, ok := doc.Metadata[policy.Key]
+			if !ok {
+				continue
+			}
+			var err error
+			if updatedAt, err = time.Parse(policy.Layout, value); err != nil {
+				return FillUpdatedAtOutput{}, fmt.Errorf("document %s: parse %s: %w", doc.ID, policy.Key, err)
+			}
+		}
+
+		docs[i].UpdatedAt = updatedAt
+		filled++
+	}
+
+	return FillUpdatedAtOutput{
+		Documents: docs,
+		Count:     len(docs),
+		Filled:    filled,
+	}, nil
+}
+
+// FillUpdatedAt creates a node that fills a zero UpdatedAt according to
+// policy, so freshness filters and newest-wins deduplication work with
+// providers that leave the field unset.
+//
+// Example:
+//
+//	flow := core.NewFlow("ingest").
+//	    Then(fetchNode).
+//	    Then(transform.FillUpdatedAt(transform.UseMetadata("modified").WithLayout("2006-01-02"))).
+//	    Then(transform.Filter(transform.FilterOptions{MaxAge: 90 * 24 * time.Hour})).
+//	    Build()
+func FillUpdatedAt(policy UpdatedAtPolicy) *core.Node[FillUpdatedAtInput, FillUpdatedAtOutput] {
+	return core.NewNode("transform.FillUpdatedAt", FillUpdatedAtActivity, FillUpdatedAtInput{Policy: policy})
+}
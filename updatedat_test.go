@@ -0,0 +1,114 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestFillUpdatedAtNow is not parallel because it replaces the package
+// clock.
+func TestFillUpdatedAtNow(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return fixed }))
+	t.Cleanup(func() { SetClock(nil) })
+
+	dated := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := []Document{
+		{ID: "undated"},
+		{ID: "dated", UpdatedAt: dated},
+		{ID: "other", Source: "other"},
+	}
+	policy := UseNow()
+	policy.Selector = Selector{Sources: []string{""}}
+
+	out, err := FillUpdatedAtActivity(context.Background(), FillUpdatedAtInput{Documents: docs, Policy: policy})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.Filled != 1 {
+		t.Errorf("Filled = %d, want 1", out.Filled)
+	}
+	if !out.Documents[0].UpdatedAt.Equal(fixed) {
+		t.Errorf("undated UpdatedAt = %v, want %v", out.Documents[0].UpdatedAt, fixed)
+	}
+	if !out.Documents[1].UpdatedAt.Equal(dated) {
+		t.Errorf("dated UpdatedAt = %v, want it unchanged", out.Documents[1].UpdatedAt)
+	}
+	if !out.Documents[2].UpdatedAt.IsZero() {
+		t.Errorf("unselected UpdatedAt = %v, want zero", out.Documents[2].UpdatedAt)
+	}
+	if !docs[0].UpdatedAt.IsZero() {
+		t.Error("input document was modified")
+	}
+}
+
+func TestFillUpdatedAtMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  UpdatedAtPolicy
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:   "rfc3339",
+			policy: UseMetadata("modified"),
+			value:  "2024-05-06T07:08:09Z",
+			want:   time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+		},
+		{
+			name:   "layout",
+			policy: UseMetadata("modified").WithLayout("2006-01-02"),
+			value:  "2024-05-06",
+			want:   time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "unparseable",
+			policy:  UseMetadata("modified"),
+			value:   "yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "no key",
+			policy:  UpdatedAtPolicy{Mode: UpdatedAtMetadata},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			policy:  UpdatedAtPolicy{Mode: "later"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			docs := []Document{
+				{ID: "a", Metadata: map[string]string{"modified": tt.value}},
+				{ID: "b"},
+			}
+			out, err := FillUpdatedAtActivity(context.Background(), FillUpdatedAtInput{Documents: docs, Policy: tt.policy})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !out.Documents[0].UpdatedAt.Equal(tt.want) {
+				t.Errorf("UpdatedAt = %v, want %v", out.Documents[0].UpdatedAt, tt.want)
+			}
+			if !out.Documents[1].UpdatedAt.IsZero() || out.Filled != 1 {
+				t.Errorf("got Filled=%d and %v for a document without the key, want 1 and zero", out.Filled, out.Documents[1].UpdatedAt)
+			}
+		})
+	}
+}
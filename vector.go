@@ -0,0 +1,109 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// CosineSimilarity returns the cosine similarity of a and b, in [-1, 1].
+// It returns 0 if either vector has zero magnitude, and an error if a
+// vector is empty or their dimensions differ.
+func CosineSimilarity(a, b []float32) (float32, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, errors.New("empty vector")
+	}
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vector dimensions differ: %d and %d", len(a), len(b))
+	}
+	return cosine(a, b), nil
+}
+
+// Normalize scales v in place to unit length, so dot products of
+// normalized vectors are their cosine similarity. It returns an error,
+// leaving v unchanged, if v is empty or has zero magnitude.
+func Normalize(v []float32) error {
+	if len(v) == 0 {
+		return errors.New("empty vector")
+	}
+
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return errors.New("zero vector cannot be normalized")
+	}
+
+	norm := math.Sqrt(sum)
+	for i, x := range v {
+		v[i] = float32(float64(x) / norm)
+	}
+	return nil
+}
+
+// Dimensions returns the embedding dimension shared by items. It returns
+// a *DimensionError if an embedding is empty or differs in dimension from
+// the first, and 0 if there are no items.
+func Dimensions(items []DocumentWithEmbedding) (int, error) {
+	return ValidateDimensions(items, 0)
+}
+
+// DimensionError reports documents whose embeddings do not have the
+// expected dimension, e.g. because embeddings from two models were mixed.
+type DimensionError struct {
+	// Want is the expected dimension.
+	Want int
+
+	// IDs lists the offending documents, in input order.
+	IDs []string
+}
+
+// Error implements error.
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("%d embeddings do not have dimension %d (%s)", len(e.IDs), e.Want, strings.Join(e.IDs, ", "))
+}
+
+// ValidateDimensions checks that every embedding in items is non-empty
+// and has dimension want, or the dimension of the first item when want is
+// zero, and returns that dimension. Otherwise it returns a
+// *DimensionError listing every offending document, so mismatches are
+// caught before they reach a vector store.
+func ValidateDimensions(items []DocumentWithEmbedding, want int) (int, error) {
+	if len(items) == 0 {
+		return want, nil
+	}
+	if want < 0 {
+		return 0, fmt.Errorf("embedding dimension must not be negative, got %d", want)
+	}
+	if want == 0 {
+		want = len(items[0].Embedding)
+	}
+
+	var ids []string
+	for _, item := range items {
+		if len(item.Embedding) == 0 || len(item.Embedding) != want {
+			ids = append(ids, item.Document.ID)
+		}
+	}
+	if len(ids) > 0 {
+		return 0, &DimensionError{Want: want, IDs: ids}
+	}
+	return want, nil
+}
+
+// cosine returns the cosine similarity of two equal-length vectors, or 0
+// if either vector has zero magnitude.
+func cosine(a, b []float32) float32 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
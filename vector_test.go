@@ -0,0 +1,114 @@
+package transform
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		a, b    []float32
+		want    float32
+		wantErr bool
+	}{
+		{name: "identical", a: []float32{1, 2, 3}, b: []float32{2, 4, 6}, want: 1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 1}, want: 0},
+		{name: "opposite", a: []float32{1, 1}, b: []float32{-1, -1}, want: -1},
+		{name: "zero magnitude", a: []float32{0, 0}, b: []float32{1, 1}, want: 0},
+		{name: "empty", a: nil, b: []float32{1}, wantErr: true},
+		{name: "mismatched", a: []float32{1, 2}, b: []float32{1, 2, 3}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := CosineSimilarity(tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if math.Abs(float64(got-tt.want)) > 1e-6 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	v := []float32{3, 4}
+	if err := Normalize(v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(float64(v[0]-0.6)) > 1e-6 || math.Abs(float64(v[1]-0.8)) > 1e-6 {
+		t.Errorf("got %v, want [0.6 0.8]", v)
+	}
+
+	zero := []float32{0, 0}
+	if err := Normalize(zero); err == nil {
+		t.Error("expected error for zero vector")
+	}
+	if err := Normalize(nil); err == nil {
+		t.Error("expected error for empty vector")
+	}
+}
+
+func TestDimensions(t *testing.T) {
+	t.Parallel()
+
+	item := func(id string, dim int) DocumentWithEmbedding {
+		return DocumentWithEmbedding{Document: Document{ID: id}, Embedding: make([]float32, dim)}
+	}
+	tests := []struct {
+		name    string
+		items   []DocumentWithEmbedding
+		want    int
+		wantErr bool
+	}{
+		{name: "none", want: 0},
+		{name: "shared", items: []DocumentWithEmbedding{item("a", 3), item("b", 3)}, want: 3},
+		{name: "mismatched", items: []DocumentWithEmbedding{item("a", 3), item("b", 4)}, wantErr: true},
+		{name: "empty embedding", items: []DocumentWithEmbedding{item("a", 0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Dimensions(tt.items)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDimensions(t *testing.T) {
+	t.Parallel()
+
+	items := []DocumentWithEmbedding{
+		{Document: Document{ID: "a"}, Embedding: []float32{1, 2, 3}},
+		{Document: Document{ID: "b"}, Embedding: []float32{1, 2, 3}},
+	}
+	if dim, err := ValidateDimensions(items, 3); err != nil || dim != 3 {
+		t.Errorf("got %d, %v; want 3, nil", dim, err)
+	}
+	if dim, err := ValidateDimensions(nil, 3); err != nil || dim != 3 {
+		t.Errorf("no items: got %d, %v; want 3, nil", dim, err)
+	}
+	if _, err := ValidateDimensions(items, -1); err == nil {
+		t.Error("expected error for negative dimension")
+	}
+
+	_, err := ValidateDimensions(items, 4)
+	if want := "2 embeddings do not have dimension 4 (a, b)"; err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,247 @@
+package transform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/resolute-sh/resolute/core"
+)
+
+// DocumentWriter is a sink that accepts documents one at a time.
+// Streaming operations write to a DocumentWriter instead of returning
+// a single large slice, so they never hold the combined documents
+// themselves; how much the sink buffers is up to the implementation.
+// Close flushes the sink; no documents may be written after it.
+type DocumentWriter interface {
+	Write(ctx context.Context, doc Document) error
+	Close() error
+}
+
+// SliceWriter is a DocumentWriter that collects documents in memory.
+type SliceWriter struct {
+	Documents []Document
+}
+
+// Write appends doc to the collected documents.
+func (w *SliceWriter) Write(ctx context.Context, doc Document) error {
+	w.Documents = append(w.Documents, doc)
+	return nil
+}
+
+// Close implements DocumentWriter. The collected documents stay available.
+func (w *SliceWriter) Close() error {
+	return nil
+}
+
+// RefWriter is a DocumentWriter that spools documents as NDJSON to a
+// temporary file and stores them as a single documents payload on Close,
+// readable with LoadDocuments. Only one encoded document is held in
+// memory while writing. Storage backends take the whole payload as a
+// single buffer, so Close reads the spool back in full and the storage
+// layer copies it again: memory peaks at about twice the payload size
+// during Close. To bound each ref instead, collect the documents and
+// split them with StoreDocumentShards.
+type RefWriter struct {
+	ctx    context.Context
+	file   *os.File
+	buf    *bufio.Writer
+	count  int
+	ref    core.DataRef
+	closed bool
+}
+
+// NewRefWriter creates a RefWriter that stores its documents with ctx.
+func NewRefWriter(ctx context.Context) (*RefWriter, error) {
+	file, err := os.CreateTemp("", "transform-documents-*.ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("create spool: %w", err)
+	}
+
+	return &RefWriter{ctx: ctx, file: file, buf: bufio.NewWriter(file)}, nil
+}
+
+// Write appends doc to the spool.
+func (w *RefWriter) Write(ctx context.Context, doc Document) error {
+	if w.closed {
+		return errors.New("write to closed RefWriter")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal document %s: %w", doc.ID, err)
+	}
+	if _, err := w.buf.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write spool: %w", err)
+	}
+
+	w.count++
+	return nil
+}
+
+// Close stores the spooled documents and removes the spool. Ref returns
+// the stored DataRef once Close succeeds. Closing twice is a no-op.
+func (w *RefWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer os.Remove(w.file.Name())
+	defer w.file.Close()
+
+	payload, err := w.payload()
+	if err != nil {
+		return err
+	}
+
+	storage, err := core.GetStorage()
+	if err != nil {
+		return fmt.Errorf("get storage: %w", err)
+	}
+	ref, err := storage.StoreJSON(w.ctx, SchemaDocuments, json.RawMessage(payload))
+	if err != nil {
+		return fmt.Errorf("store documents: %w", err)
+	}
+
+	ref.Count = w.count
+	w.ref = ref
+	return nil
+}
+
+// discard removes the spool without storing it. The writer is closed
+// afterwards.
+func (w *RefWriter) discard() {
+	if w.closed {
+		return
+	}
+	w.closed = true
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// Ref returns the DataRef of the stored documents after Close.
+func (w *RefWriter) Ref() core.DataRef {
+	return w.ref
+}
+
+// Count returns the number of documents written.
+func (w *RefWriter) Count() int {
+	return w.count
+}
+
+// payload reads the spool back as a documentsPayload JSON object.
+func (w *RefWriter) payload() ([]byte, error) {
+	if err := w.buf.Flush(); err != nil {
+		return nil, fmt.Errorf("flush spool: %w", err)
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("rewind spool: %w", err)
+	}
+
+	var payload bytes.Buffer
+	payload.WriteString(`{"schema_version":` + itoa(DocumentsSchemaVersion) + `,"documents":[`)
+
+	scanner := bufio.NewScanner(w.file)
+	scanner.Buffer(nil, 1<<30)
+	for first := true; scanner.Scan(); first = false {
+		if !first {
+			payload.WriteByte(',')
+		}
+		payload.Write(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read spool: %w", err)
+	}
+
+	payload.WriteString("]}")
+	return payload.Bytes(), nil
+}
+
+// DedupWriter is a DocumentWriter that drops documents whose content is
+// identical to a recent document's before passing the rest to the
+// wrapped writer, for deduplicating unbounded streams such as
+// MergeDocumentsTo over streaming sources. Like DedupActivity it keeps
+// the first occurrence and matches on the content hash (see
+// ContentHashAllocator).
+//
+// With a positive Window, only the fingerprints of the last Window
+// documents written, kept or dropped, are remembered, so memory stays
+// bounded by Window. The result is approximate: a duplicate that arrives
+// more than Window documents after its last occurrence is kept. It suits
+// near-real-time ingestion where duplicates are clustered in time; size
+// the window to the expected spread of duplicates. A Window of zero or
+// less remembers every fingerprint, like DedupActivity, and grows with
+// the number of distinct documents.
+type DedupWriter struct {
+	w      DocumentWriter
+	window int
+
+	// counts holds the number of occurrences of each fingerprint in
+	// recent, a ring of the last window fingerprints.
+	counts  map[string]int
+	recent  []string
+	next    int
+	removed int
+}
+
+// NewDedupWriter creates a DedupWriter that remembers the fingerprints
+// of the last window documents and writes the others to w.
+func NewDedupWriter(w DocumentWriter, window int) *DedupWriter {
+	return &DedupWriter{w: w, window: window, counts: make(map[string]int)}
+}
+
+// Write passes doc to the wrapped writer unless its content was written
+// within the window.
+func (w *DedupWriter) Write(ctx context.Context, doc Document) error {
+	hash := ContentHashAllocator{}.Allocate(doc)
+	duplicate := w.counts[hash] > 0
+	w.remember(hash)
+
+	if duplicate {
+		w.removed++
+		if log := debugLogger(ctx); log != nil {
+			log.DebugContext(ctx, "transform: duplicate removed", "id", doc.ID, "content_hash", hash)
+		}
+		return nil
+	}
+	return w.w.Write(ctx, doc)
+}
+
+// remember records hash as the most recent fingerprint, evicting the
+// oldest one once the window is full.
+func (w *DedupWriter) remember(hash string) {
+	if w.window <= 0 {
+		w.counts[hash] = 1
+		return
+	}
+
+	if len(w.recent) < w.window {
+		w.recent = append(w.recent, hash)
+	} else {
+		evicted := w.recent[w.next]
+		if w.counts[evicted]--; w.counts[evicted] == 0 {
+			delete(w.counts, evicted)
+		}
+		w.recent[w.next] = hash
+		w.next = (w.next + 1) % w.window
+	}
+	w.counts[hash]++
+}
+
+// Removed returns the number of duplicates dropped.
+func (w *DedupWriter) Removed() int {
+	return w.removed
+}
+
+// Close closes the wrapped writer.
+func (w *DedupWriter) Close() error {
+	return w.w.Close()
+}